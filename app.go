@@ -4,11 +4,14 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"net"
 	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
 	"sync"
-	"syscall"
 	"time"
-	"unsafe"
 
 	"github.com/wailsapp/wails/v2/pkg/runtime"
 
@@ -23,8 +26,11 @@ import (
 // LaunchRDPApp manages storage, credentials, RDP generator and window state
 type LaunchRDPApp struct {
 	ctx              context.Context
-	storage          *storage.Storage
-	credManager      *credentials.CredentialManager
+	storage          *storage.Storage            // JSON files: app-wide settings, and the repository unless SQLite is in use
+	repo             storage.Repository          // hosts, users, groups and launch history
+	repoMu           sync.RWMutex                // guards repo while SetStorageBackend switches it
+	cipher           credentials.SecretCipher    // encrypts the passwords in users.json
+	credStore        credentials.CredentialStore // TERMSRV credentials the RDP client logs in with
 	rdpGen           *rdp.Generator
 	winState         *WindowState
 	winStateMu       sync.Mutex
@@ -39,15 +45,44 @@ func NewLaunchRDPApp() *LaunchRDPApp {
 	debug := true
 	logging.Log(debug, "Creating new LaunchRDP Wails app instance (simplified)")
 	app := &LaunchRDPApp{
-		storage:     storage.NewStorage(),
-		credManager: credentials.NewCredentialManager(),
-		rdpGen:      rdp.NewGenerator(),
-		winState:    &WindowState{X: -7, Y: 0, Width: 275, Height: 500, DeltaX: 0, DeltaY: 0},
-	}
+		storage:  storage.NewStorage(),
+		cipher:   credentials.NewPlatformCipher(),
+		rdpGen:   rdp.NewGenerator(),
+		winState: &WindowState{X: -7, Y: 0, Width: 275, Height: 500, DeltaX: 0, DeltaY: 0},
+	}
+	app.repo = openRepository(app.storage)
+	logging.Log(debug, "Storage backend:", app.repo.Backend())
+	if config.IsPortable() {
+		// Portable mode: passwords must decrypt on any machine, DPAPI is machine-bound
+		app.cipher = credentials.NewPassphraseStore(config.GetConfigPath(credentials.PassphraseFileName))
+	}
+	app.credStore = credentials.NewPlatformStore(app.cipher)
 	app.rdpGen.SetSaveUserCallback(app.saveUserAfterMigration)
+	app.rdpGen.SetPasswordCallback(app.userPassword)
+	if profiles, err := app.GetExperienceProfiles(); err == nil {
+		app.rdpGen.SetExperienceProfiles(profiles)
+	} else {
+		logging.Log(true, "Warning: Failed to load experience profiles:", err)
+	}
+	if err := app.loadSigner(); err != nil {
+		logging.Log(true, "Warning: Failed to load signing certificate, files stay unsigned:", err)
+	}
 	return app
 }
 
+// openRepository opens the repository in use. A database that cannot be opened
+// (newer schema, locked, corrupt) is not silently replaced by the JSON files,
+// which are outdated after a switch to SQLite: they are served read-only and
+// GetStorageError reports why.
+func openRepository(jsonStorage *storage.Storage) storage.Repository {
+	repo, err := storage.OpenRepository(jsonStorage)
+	if err != nil {
+		logging.Log(true, "ERROR: Failed to open the database, JSON files are read-only:", err)
+		return &storage.ReadOnlyRepository{Repository: jsonStorage, Err: err}
+	}
+	return repo
+}
+
 // Startup loads saved window state
 func (a *LaunchRDPApp) Startup(ctx context.Context) {
 	a.ctx = ctx
@@ -86,6 +121,18 @@ func (a *LaunchRDPApp) DomReady(ctx context.Context) {
 		logging.Log(true, "DomReady: startMoveSizeHook failed", err)
 	}
 	a.startGeometryTicker()
+
+	// Changes would fail one by one, tell the user why right away
+	if storageErr := a.GetStorageError(); storageErr != "" {
+		_, err := runtime.MessageDialog(ctx, runtime.MessageDialogOptions{
+			Type:    runtime.WarningDialog,
+			Title:   "LaunchRDP storage is read-only",
+			Message: "The database could not be opened, hosts and users cannot be changed:\n\n" + storageErr,
+		})
+		if err != nil {
+			logging.Log(true, "ERROR: Failed to show storage error:", err)
+		}
+	}
 }
 
 // CreateUser creates a new user and saves it
@@ -99,27 +146,25 @@ func (a *LaunchRDPApp) CreateUser(username, login, domain, password string) erro
 	// Store encrypted password as backup (DPAPI)
 	// We'll only decrypt it when assigning to hosts or updating credentials
 	if password != "" {
-		encryptedPassword, err := a.credManager.EncryptPasswordDPAPI(password)
+		encryptedPassword, err := a.cipher.Encrypt(password)
 		if err != nil {
 			return err
 		}
 		user.EncryptedPassword = encryptedPassword
+		user.PasswordChangedAt = time.Now()
 		logging.Log(debug, "Password encrypted with DPAPI for user:", username)
 	}
 
-	users, _ := a.storage.LoadUsers()
-	users = append(users, user)
-	if err := a.storage.SaveUsers(users); err != nil {
-		return err
-	}
-	return nil
+	return a.repository().UpdateUsers(func(users []models.User) ([]models.User, error) {
+		return append(users, user), nil
+	})
 }
 
 // GetUsers returns all saved users
 func (a *LaunchRDPApp) GetUsers() ([]models.User, error) {
 	debug := false
 	logging.Log(debug, "API: Loading users")
-	users, err := a.storage.LoadUsers()
+	users, err := a.repository().LoadUsers()
 	if err != nil {
 		logging.Log(true, "ERROR: Failed to load users:", err)
 		return nil, err
@@ -128,53 +173,73 @@ func (a *LaunchRDPApp) GetUsers() ([]models.User, error) {
 	return users, nil
 }
 
-// UpdateUser updates basic data + password (if not __UNCHANGED__)
-func (a *LaunchRDPApp) UpdateUser(userID, username, login, domain, password string) error {
+// UpdateUser updates basic data + password (if not __UNCHANGED__). The user is saved
+// even if the new password could not be stored for every host; the returned warnings
+// name those hosts (credential conflicts, store errors).
+func (a *LaunchRDPApp) UpdateUser(userID, username, login, domain, password string) ([]string, error) {
 	debug := false
-	users, err := a.storage.LoadUsers()
-	if err != nil {
-		return err
-	}
-	idx := -1
-	for i, u := range users {
-		if u.ID == userID {
-			idx = i
-			break
+	passwordChanged := password != "" && password != "__UNCHANGED__"
+	enc := ""
+	if passwordChanged {
+		var err error
+		enc, err = a.cipher.Encrypt(password)
+		if err != nil {
+			return nil, err
 		}
 	}
-	if idx == -1 {
-		return fmt.Errorf("user not found")
-	}
-	usr := &users[idx]
-	usr.Username = username
-	usr.Login = login
-	usr.Domain = domain
 
-	if password != "" && password != "__UNCHANGED__" {
-		enc, err := a.credManager.EncryptPasswordDPAPI(password)
-		if err != nil {
-			return err
+	err := a.repository().UpdateUsers(func(users []models.User) ([]models.User, error) {
+		idx := -1
+		for i, u := range users {
+			if u.ID == userID {
+				idx = i
+				break
+			}
+		}
+		if idx == -1 {
+			return nil, fmt.Errorf("user not found")
 		}
-		usr.EncryptedPassword = enc
-		hosts, _ := a.storage.LoadHosts()
+		usr := &users[idx]
+		usr.Username = username
+		usr.Login = login
+		usr.Domain = domain
+		usr.ModifiedAt = time.Now()
+		if passwordChanged {
+			usr.EncryptedPassword = enc
+			usr.PasswordChangedAt = usr.ModifiedAt
+		}
+		return users, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	warnings := []string{}
+	if passwordChanged {
+		hosts, _ := a.repository().LoadHosts()
+		users, _ := a.repository().LoadUsers()
 		logging.Log(true, "UpdateUser: Storing credentials for hosts associated with user", username)
 		for _, h := range hosts {
 			if h.UserID == userID {
-				logging.Log(true, "UpdateUser: Calling StoreCredential for host:", h.Address, "user:", username)
-				err := a.credManager.StoreCredential(h.Address, username, password)
+				// Never overwrite the credential of a host with another user on the same address
+				if err := credentials.CheckHost(hosts, users, h); err != nil {
+					logging.Log(true, "UpdateUser: WARNING not storing credential for host", h.Name, ":", err)
+					warnings = append(warnings, fmt.Sprintf("credential of host %q not stored: %v", h.Name, err))
+					continue
+				}
+				logging.Log(true, "UpdateUser: Calling StoreCredential for host:", h.CredentialTarget(), "user:", username)
+				err := a.credStore.Store(h.CredentialTarget(), username, password)
 				if err != nil {
-					logging.Log(true, "UpdateUser: ERROR storing credential for host", h.Address, ":", err)
+					logging.Log(true, "UpdateUser: ERROR storing credential for host", h.CredentialTarget(), ":", err)
+					warnings = append(warnings, fmt.Sprintf("credential of host %q not stored: %v", h.Name, err))
 				} else {
-					logging.Log(true, "UpdateUser: Successfully stored credential for host", h.Address)
+					logging.Log(true, "UpdateUser: Successfully stored credential for host", h.CredentialTarget())
 				}
 			}
 		}
 	}
-	if err := a.storage.SaveUsers(users); err != nil {
-		return err
-	}
 	logging.Log(debug, "API: User updated", userID)
-	return nil
+	return warnings, nil
 }
 
 // DeleteUser - Replaces DELETE /api/users/{id}
@@ -182,42 +247,42 @@ func (a *LaunchRDPApp) DeleteUser(userID string) error {
 	debug := false
 	logging.Log(debug, "API: Deleting user", userID)
 
-	// Load users and filter out the deleted one
-	users, err := a.storage.LoadUsers()
+	// Filter out the deleted user
+	err := a.repository().UpdateUsers(func(users []models.User) ([]models.User, error) {
+		var updatedUsers []models.User
+		found := false
+		for _, user := range users {
+			if user.ID == userID {
+				found = true
+			} else {
+				updatedUsers = append(updatedUsers, user)
+			}
+		}
+		if !found {
+			logging.Log(true, "ERROR: User not found:", userID)
+			return nil, fmt.Errorf("user not found")
+		}
+		return updatedUsers, nil
+	})
 	if err != nil {
+		logging.Log(true, "ERROR: Failed to delete user:", err)
 		return err
 	}
 
-	var updatedUsers []models.User
-	var deletedUser *models.User
-
-	for _, user := range users {
-		if user.ID == userID {
-			deletedUser = &user
-		} else {
-			updatedUsers = append(updatedUsers, user)
+	// Delete credentials, unless a host of another user shares the target
+	hosts, _ := a.repository().LoadHosts()
+	var otherHosts []models.Host
+	for _, host := range hosts {
+		if host.UserID != userID {
+			otherHosts = append(otherHosts, host)
 		}
 	}
-
-	if deletedUser == nil {
-		logging.Log(true, "ERROR: User not found:", userID)
-		return fmt.Errorf("user not found")
-	}
-
-	// Delete credentials
-	hosts, _ := a.storage.LoadHosts()
 	for _, host := range hosts {
 		if host.UserID == userID {
-			a.credManager.DeleteCredential(host.Address)
+			a.deleteUnusedCredential(otherHosts, host.CredentialTarget())
 		}
 	}
 
-	err = a.storage.SaveUsers(updatedUsers)
-	if err != nil {
-		logging.Log(true, "ERROR: Failed to delete user:", err)
-		return err
-	}
-
 	logging.Log(debug, "User deleted successfully:", userID)
 	return nil
 }
@@ -226,7 +291,7 @@ func (a *LaunchRDPApp) DeleteUser(userID string) error {
 func (a *LaunchRDPApp) GetHosts() ([]models.Host, error) {
 	debug := false
 	logging.Log(debug, "API: Loading hosts")
-	hosts, err := a.storage.LoadHosts()
+	hosts, err := a.repository().LoadHosts()
 	if err != nil {
 		logging.Log(true, "ERROR: Failed to load hosts:", err)
 		return nil, err
@@ -246,9 +311,7 @@ func (a *LaunchRDPApp) CreateHost(name, address, userID string, port int) error
 	// Note: Extended storage is handled via separate Update function after creation if needed.
 
 	// Save host using array pattern
-	hosts, _ := a.storage.LoadHosts()
-	hosts = append(hosts, host)
-	err := a.storage.SaveHosts(hosts)
+	err := a.addHost(host)
 	if err != nil {
 		logging.Log(true, "ERROR: Failed to save host:", err)
 		return err
@@ -263,37 +326,19 @@ func (a *LaunchRDPApp) UpdateHost(hostID, name, address, userID string, port int
 	debug := false
 	logging.Log(debug, "API: Updating host", hostID)
 
-	// Load hosts and find the one to update
-	hosts, err := a.storage.LoadHosts()
-	if err != nil {
-		return err
-	}
-
-	hostIndex := -1
-	var oldAddress string
-	var oldUserID string
-	for i, host := range hosts {
-		if host.ID == hostID {
-			hostIndex = i
-			oldAddress = host.Address
-			oldUserID = host.UserID
-			break
-		}
-	}
-
-	if hostIndex == -1 {
-		logging.Log(true, "ERROR: Host not found:", hostID)
-		return fmt.Errorf("host not found")
-	}
-
 	// Basic host data update
-	h := &hosts[hostIndex]
-	h.Name = name
-	h.Address = address
-	h.Port = port
-	h.UserID = userID
-
-	err = a.storage.SaveHosts(hosts)
+	var oldAddress, oldTarget string
+	var oldUserID string
+	err := a.updateHost(hostID, func(h *models.Host) error {
+		oldAddress = h.Address
+		oldTarget = h.CredentialTarget()
+		oldUserID = h.UserID
+		h.Name = name
+		h.Address = address
+		h.Port = port
+		h.UserID = userID
+		return nil
+	})
 	if err != nil {
 		logging.Log(true, "ERROR: Failed to update host:", err)
 		return err
@@ -302,32 +347,7 @@ func (a *LaunchRDPApp) UpdateHost(hostID, name, address, userID string, port int
 	// Update credentials if address or user changed
 	if address != oldAddress || userID != oldUserID {
 		logging.Log(debug, "Host address or user changed, updating credentials")
-
-		// Delete old credential if address changed
-		if address != oldAddress {
-			logging.Log(debug, "Deleting old credential for:", oldAddress)
-			a.credManager.DeleteCredential(oldAddress)
-		}
-
-		// Store new credential
-		users, err := a.storage.LoadUsers()
-		if err == nil {
-			for _, user := range users {
-				if user.ID == userID {
-					if user.EncryptedPassword != "" {
-						password, err := a.credManager.DecryptPasswordDPAPI(user.EncryptedPassword)
-						if err == nil {
-							logging.Log(debug, "Storing credential for new host/user:", address, user.Username)
-							err = a.credManager.StoreCredential(address, user.Username, password)
-							if err != nil {
-								logging.Log(true, "ERROR: Failed to store credential:", err)
-							}
-						}
-					}
-					break
-				}
-			}
-		}
+		a.moveHostCredential(hostID, oldTarget)
 	}
 
 	logging.Log(debug, "Host updated successfully:", name)
@@ -337,90 +357,206 @@ func (a *LaunchRDPApp) UpdateHost(hostID, name, address, userID string, port int
 // UpdateHostFull updates all host settings including RDP/window properties.
 func (a *LaunchRDPApp) UpdateHostFull(hostID, name, address, userID string, port int,
 	displayMode string, positionX, positionY, windowWidth, windowHeight int,
-	redirectClipboard, redirectDrives bool) error {
+	redirectClipboard, redirectDrives bool, drivesToRedirect string, dynamicResolution bool,
+	gatewayHostname string, gatewayUsageMethod, gatewayCredentialsSource int, gatewayBypassLocal bool) error {
 	debug := false
-	hosts, err := a.storage.LoadHosts()
-	if err != nil {
+	if err := rdp.ValidateDrivesToRedirect(drivesToRedirect); err != nil {
 		return err
 	}
-	idx := -1
-	var oldAddress string
-	var oldUserID string
-	for i, host := range hosts {
-		if host.ID == hostID {
-			idx = i
-			oldAddress = host.Address
-			oldUserID = host.UserID
-			break
+	if gatewayHostname != "" {
+		if err := rdp.ValidateGatewaySettings(gatewayUsageMethod, gatewayCredentialsSource); err != nil {
+			return err
 		}
 	}
-	if idx == -1 {
-		return fmt.Errorf("host not found")
-	}
-	h := &hosts[idx]
-	h.Name = name
-	h.Address = address
-	h.Port = port
-	h.UserID = userID
-	h.DisplayMode = displayMode
-	if displayMode == "fullscreen" {
-		h.ScreenMode = 2
-	} else {
-		h.ScreenMode = 1
-	}
-	h.PositionX = positionX
-	h.PositionY = positionY
-	h.WindowWidth = windowWidth
-	h.WindowHeight = windowHeight
-	// Approximate client area
-	h.DesktopWidth = windowWidth - 16
-	h.DesktopHeight = windowHeight - 59
-	h.RedirectClipboard = redirectClipboard
-	h.RedirectDrives = redirectDrives
-	h.ModifiedAt = time.Now()
-	if err := a.storage.SaveHosts(hosts); err != nil {
+	var oldAddress, oldTarget string
+	var oldUserID string
+	err := a.updateHost(hostID, func(h *models.Host) error {
+		oldAddress = h.Address
+		oldTarget = h.CredentialTarget()
+		oldUserID = h.UserID
+		h.Name = name
+		h.Address = address
+		h.Port = port
+		if redirectDrives {
+			if err := rdp.ValidateDrivesForBackend(drivesToRedirect, rdp.HostBackend(*h)); err != nil {
+				return err
+			}
+		}
+		if err := rdp.ValidateGatewayForBackend(gatewayHostname, gatewayCredentialsSource, rdp.HostBackend(*h)); err != nil {
+			return err
+		}
+		h.UserID = userID
+		h.DisplayMode = displayMode
+		if displayMode == "fullscreen" {
+			h.ScreenMode = 2
+		} else {
+			h.ScreenMode = 1
+		}
+		h.PositionX = positionX
+		h.PositionY = positionY
+		h.SetWindowSize(windowWidth, windowHeight)
+		h.RedirectClipboard = redirectClipboard
+		h.RedirectDrives = redirectDrives
+		h.DrivesToRedirect = drivesToRedirect
+		h.DynamicResolution = dynamicResolution
+		h.GatewayHostname = gatewayHostname
+		h.GatewayUsageMethod = gatewayUsageMethod
+		h.GatewayCredentialsSource = gatewayCredentialsSource
+		h.GatewayBypassLocal = gatewayBypassLocal
+		return nil
+	})
+	if err != nil {
 		return err
 	}
 
 	// Update credentials if address or user changed
 	if address != oldAddress || userID != oldUserID {
 		logging.Log(debug, "Host address or user changed, updating credentials")
+		a.moveHostCredential(hostID, oldTarget)
+	}
+
+	return nil
+}
 
-		// Delete old credential if address changed
-		if address != oldAddress {
-			logging.Log(debug, "Deleting old credential for:", oldAddress)
-			a.credManager.DeleteCredential(oldAddress)
-		}
-
-		// Store new credential
-		users, err := a.storage.LoadUsers()
-		if err == nil {
-			for _, user := range users {
-				if user.ID == userID {
-					if user.EncryptedPassword != "" {
-						password, err := a.credManager.DecryptPasswordDPAPI(user.EncryptedPassword)
-						if err == nil {
-							logging.Log(debug, "Storing credential for new host/user:", address, user.Username)
-							err = a.credManager.StoreCredential(address, user.Username, password)
-							if err != nil {
-								logging.Log(true, "ERROR: Failed to store credential:", err)
-							}
-						}
+// updateHost applies fn to a host and saves it under the storage lock, so
+// concurrent changes to other hosts or fields are not lost
+func (a *LaunchRDPApp) updateHost(hostID string, fn func(host *models.Host) error) error {
+	// The update doesn't change users, load them once before it
+	users, err := a.repository().LoadUsers()
+	if err != nil {
+		return err
+	}
+	return a.repository().UpdateHosts(func(hosts []models.Host) ([]models.Host, error) {
+		for i := range hosts {
+			if hosts[i].ID == hostID {
+				before := hosts[i]
+				if err := fn(&hosts[i]); err != nil {
+					return nil, err
+				}
+				// Refuse changes that would overwrite the credential of another host
+				if hosts[i].CredentialTarget() != before.CredentialTarget() || hosts[i].UserID != before.UserID {
+					if err := credentials.CheckHost(hosts, users, hosts[i]); err != nil {
+						return nil, err
 					}
-					break
+				}
+				hosts[i].ModifiedAt = time.Now()
+				return hosts, nil
+			}
+		}
+		return nil, fmt.Errorf("host not found")
+	})
+}
+
+// moveHostCredential stores the credential of a host after its target or user
+// changed, and deletes the credential of the old target unless another host uses it
+func (a *LaunchRDPApp) moveHostCredential(hostID, oldTarget string) {
+	debug := false
+	hosts, err := a.repository().LoadHosts()
+	if err != nil {
+		logging.Log(true, "ERROR: Failed to load hosts:", err)
+		return
+	}
+	users, err := a.repository().LoadUsers()
+	if err != nil {
+		logging.Log(true, "ERROR: Failed to load users:", err)
+		return
+	}
+	var host *models.Host
+	for i := range hosts {
+		if hosts[i].ID == hostID {
+			host = &hosts[i]
+			break
+		}
+	}
+	if host == nil {
+		return
+	}
+
+	// Delete old credential if the target changed
+	if !strings.EqualFold(oldTarget, host.CredentialTarget()) {
+		a.deleteUnusedCredential(hosts, oldTarget)
+	}
+
+	// Store new credential
+	for _, user := range users {
+		if user.ID == host.UserID {
+			if user.EncryptedPassword != "" {
+				password, err := a.cipher.Decrypt(user.EncryptedPassword)
+				if err == nil {
+					logging.Log(debug, "Storing credential for new host/user:", host.CredentialTarget(), user.Username)
+					err = a.credStore.Store(host.CredentialTarget(), user.Username, password)
+				}
+				if err != nil {
+					logging.Log(true, "ERROR: Failed to store credential:", err)
 				}
 			}
+			break
 		}
 	}
+}
+
+// deleteUnusedCredential deletes the credential of a target unless one of hosts
+// still uses it
+func (a *LaunchRDPApp) deleteUnusedCredential(hosts []models.Host, target string) {
+	debug := false
+	if target == "" {
+		return
+	}
+	if credentials.TargetInUse(hosts, target) {
+		logging.Log(true, "WARNING: Keeping credential for", target, "- another host still uses it")
+		return
+	}
+	logging.Log(debug, "Deleting credential for:", target)
+	if err := a.credStore.Delete(target); err != nil {
+		logging.Log(debug, "Credential for", target, "not deleted:", err)
+	}
+}
+
+// SetHostCustomProperties replaces the per-host RDP property overrides.
+// Every key must be a registered property and every value must match its type.
+func (a *LaunchRDPApp) SetHostCustomProperties(hostID string, properties map[string]string) error {
+	debug := false
+	logging.Log(debug, "API: Setting", len(properties), "custom RDP properties for host", hostID)
+
+	normalized, err := rdp.NormalizeCustomProperties(properties)
+	if err != nil {
+		logging.Log(true, "ERROR: Invalid custom RDP properties:", err)
+		return err
+	}
 
+	err = a.updateHost(hostID, func(host *models.Host) error {
+		host.CustomProperties = normalized
+		return nil
+	})
+	if err != nil {
+		logging.Log(true, "ERROR: Failed to save custom RDP properties:", err)
+		return err
+	}
 	return nil
 }
 
 // CreateHostFull creates a host with advanced properties.
 func (a *LaunchRDPApp) CreateHostFull(name, address, userID string, port int,
 	displayMode string, positionX, positionY, windowWidth, windowHeight int,
-	redirectClipboard, redirectDrives bool) error {
+	redirectClipboard, redirectDrives bool, drivesToRedirect string, dynamicResolution bool,
+	gatewayHostname string, gatewayUsageMethod, gatewayCredentialsSource int, gatewayBypassLocal bool) error {
+	if err := rdp.ValidateDrivesToRedirect(drivesToRedirect); err != nil {
+		return err
+	}
+	if gatewayHostname != "" {
+		if err := rdp.ValidateGatewaySettings(gatewayUsageMethod, gatewayCredentialsSource); err != nil {
+			return err
+		}
+	}
 	host := models.NewHost(name, address, port, userID)
+	if redirectDrives {
+		if err := rdp.ValidateDrivesForBackend(drivesToRedirect, rdp.HostBackend(host)); err != nil {
+			return err
+		}
+	}
+	if err := rdp.ValidateGatewayForBackend(gatewayHostname, gatewayCredentialsSource, rdp.HostBackend(host)); err != nil {
+		return err
+	}
 	host.DisplayMode = displayMode
 	if displayMode == "fullscreen" {
 		host.ScreenMode = 2
@@ -429,18 +565,32 @@ func (a *LaunchRDPApp) CreateHostFull(name, address, userID string, port int,
 	}
 	host.PositionX = positionX
 	host.PositionY = positionY
-	host.WindowWidth = windowWidth
-	host.WindowHeight = windowHeight
-	host.DesktopWidth = windowWidth - 16
-	host.DesktopHeight = windowHeight - 59
+	host.SetWindowSize(windowWidth, windowHeight)
 	host.RedirectClipboard = redirectClipboard
 	host.RedirectDrives = redirectDrives
-	hosts, _ := a.storage.LoadHosts()
-	hosts = append(hosts, host)
-	if err := a.storage.SaveHosts(hosts); err != nil {
+	host.DrivesToRedirect = drivesToRedirect
+	host.DynamicResolution = dynamicResolution
+	host.GatewayHostname = gatewayHostname
+	host.GatewayUsageMethod = gatewayUsageMethod
+	host.GatewayCredentialsSource = gatewayCredentialsSource
+	host.GatewayBypassLocal = gatewayBypassLocal
+	return a.addHost(host)
+}
+
+// addHost saves a new host, unless its credential would overwrite the credential
+// of another host
+func (a *LaunchRDPApp) addHost(host models.Host) error {
+	// The update doesn't change users, load them once before it
+	users, err := a.repository().LoadUsers()
+	if err != nil {
 		return err
 	}
-	return nil
+	return a.repository().UpdateHosts(func(hosts []models.Host) ([]models.Host, error) {
+		if err := credentials.CheckHost(hosts, users, host); err != nil {
+			return nil, err
+		}
+		return append(hosts, host), nil
+	})
 }
 
 // GenerateHostRDP creates/updates the RDP file for a given host (used after save)
@@ -448,7 +598,7 @@ func (a *LaunchRDPApp) GenerateHostRDP(hostID string) (string, error) {
 	debug := false
 	logging.Log(debug, "API: GenerateHostRDP invoked for host", hostID)
 	// Load host
-	hosts, err := a.storage.LoadHosts()
+	hosts, err := a.repository().LoadHosts()
 	if err != nil {
 		return "", err
 	}
@@ -467,7 +617,7 @@ func (a *LaunchRDPApp) GenerateHostRDP(hostID string) (string, error) {
 	}
 
 	// Load user from storage
-	users, err := a.storage.LoadUsers()
+	users, err := a.repository().LoadUsers()
 	if err != nil {
 		return "", err
 	}
@@ -492,41 +642,144 @@ func (a *LaunchRDPApp) GenerateHostRDP(hostID string) (string, error) {
 	return path, nil
 }
 
+// GetRDPProperties returns the registry of supported RDP properties (for the settings UI)
+func (a *LaunchRDPApp) GetRDPProperties() []rdp.PropertyDef {
+	return rdp.Properties()
+}
+
 // DeleteHost - Replaces DELETE /api/hosts/{id}
 func (a *LaunchRDPApp) DeleteHost(hostID string) error {
 	debug := false
 	logging.Log(debug, "API: Deleting host", hostID)
 
-	// Load hosts and filter out the deleted one
-	hosts, err := a.storage.LoadHosts()
+	// Filter out the deleted host
+	var deleted models.Host
+	var remaining []models.Host
+	err := a.repository().UpdateHosts(func(hosts []models.Host) ([]models.Host, error) {
+		var updatedHosts []models.Host
+		found := false
+		for _, host := range hosts {
+			if host.ID == hostID {
+				found = true
+				deleted = host
+			} else {
+				updatedHosts = append(updatedHosts, host)
+			}
+		}
+		if !found {
+			logging.Log(true, "ERROR: Host not found:", hostID)
+			return nil, fmt.Errorf("host not found")
+		}
+		remaining = updatedHosts
+		return updatedHosts, nil
+	})
 	if err != nil {
+		logging.Log(true, "ERROR: Failed to delete host:", err)
 		return err
 	}
 
-	var updatedHosts []models.Host
-	found := false
+	// Remove the stored password unless another host connects to the same target
+	a.deleteUnusedCredential(remaining, deleted.CredentialTarget())
 
-	for _, host := range hosts {
-		if host.ID == hostID {
-			found = true
-		} else {
-			updatedHosts = append(updatedHosts, host)
+	logging.Log(debug, "Host deleted successfully:", hostID)
+	return nil
+}
+
+// ImportResult summarizes an ImportRDPFiles run for the frontend
+type ImportResult struct {
+	Imported     int      `json:"imported"`
+	UsersCreated int      `json:"usersCreated"`
+	Errors       []string `json:"errors"`
+}
+
+// ImportRDPFiles creates hosts from existing .rdp files. The "username" of each
+// file is matched against existing users; unknown usernames get a new user without
+// password, so the password can be set later via UpdateUser.
+func (a *LaunchRDPApp) ImportRDPFiles(paths []string) (*ImportResult, error) {
+	debug := false
+	logging.Log(debug, "API: Importing", len(paths), "RDP files")
+
+	// Parse the files first, the storage lock is only held while merging
+	result := &ImportResult{Errors: []string{}}
+	var importedHosts []rdp.ImportedHost
+	for _, path := range paths {
+		imported, err := rdp.ImportFile(path)
+		if err != nil {
+			logging.Log(true, "ERROR: Failed to import RDP file:", path, err)
+			result.Errors = append(result.Errors, err.Error())
+			continue
 		}
-	}
+		if imported.Host.Address == "" {
+			result.Errors = append(result.Errors, fmt.Sprintf("%s: missing full address", path))
+			continue
+		}
+		importedHosts = append(importedHosts, imported)
+		logging.Log(debug, "Imported host from", path, "as", imported.Host.Name)
+	}
+	if len(importedHosts) == 0 {
+		return result, nil
+	}
+
+	err := a.repository().UpdateUsersAndHosts(func(users []models.User, hosts []models.Host) ([]models.User, []models.Host, error) {
+		for _, imported := range importedHosts {
+			// Importing a file again must not duplicate its host
+			duplicate := slices.ContainsFunc(hosts, func(h models.Host) bool {
+				return strings.EqualFold(h.Name, imported.Host.Name) && strings.EqualFold(h.Address, imported.Host.Address)
+			})
+			if duplicate {
+				result.Errors = append(result.Errors, fmt.Sprintf("%s: a host with this name and address already exists", imported.Host.Name))
+				continue
+			}
 
-	if !found {
-		logging.Log(true, "ERROR: Host not found:", hostID)
-		return fmt.Errorf("host not found")
-	}
+			// The user is only created once the host is accepted
+			var newUser *models.User
+			if imported.Username != "" {
+				userID := ""
+				for _, u := range users {
+					if strings.EqualFold(u.Username, imported.Username) {
+						userID = u.ID
+						break
+					}
+				}
+				if userID == "" {
+					user := models.NewUser(imported.Username, imported.Username)
+					user.Login = imported.Username
+					if domain, login, found := strings.Cut(imported.Username, "\\"); found {
+						user.Login, user.Domain = login, domain
+					}
+					newUser = &user
+					userID = user.ID
+				}
+				imported.Host.UserID = userID
+			}
 
-	err = a.storage.SaveHosts(updatedHosts)
+			// Never import a host whose credential would overwrite another one
+			candidates := users
+			if newUser != nil {
+				candidates = append(users[:len(users):len(users)], *newUser)
+			}
+			if err := credentials.CheckHost(hosts, candidates, imported.Host); err != nil {
+				logging.Log(true, "ERROR: Not importing", imported.Host.Name+":", err)
+				result.Errors = append(result.Errors, fmt.Sprintf("%s: %v", imported.Host.Name, err))
+				continue
+			}
+			if newUser != nil {
+				users = candidates
+				result.UsersCreated++
+				logging.Log(debug, "Created user from RDP import:", newUser.Username)
+			}
+			hosts = append(hosts, imported.Host)
+			result.Imported++
+		}
+		return users, hosts, nil
+	})
 	if err != nil {
-		logging.Log(true, "ERROR: Failed to delete host:", err)
-		return err
+		logging.Log(true, "ERROR: Failed to save imported hosts:", err)
+		return nil, err
 	}
 
-	logging.Log(debug, "Host deleted successfully:", hostID)
-	return nil
+	logging.Log(debug, "API: Imported", result.Imported, "hosts,", result.UsersCreated, "new users,", len(result.Errors), "errors")
+	return result, nil
 }
 
 // MousePosition represents a screen position
@@ -535,28 +788,6 @@ type MousePosition struct {
 	Y int `json:"y"`
 }
 
-// GetMousePosition - Replaces GET /api/mouse-position
-func (a *LaunchRDPApp) GetMousePosition() (*MousePosition, error) {
-	debug := false
-	logging.Log(debug, "API: Getting mouse position")
-
-	user32 := syscall.NewLazyDLL("user32.dll")
-	procGetCursorPos := user32.NewProc("GetCursorPos")
-	type point struct {
-		X int32
-		Y int32
-	}
-	var pt point
-	r, _, err := procGetCursorPos.Call(uintptr(unsafe.Pointer(&pt)))
-	if r == 0 { // failed
-		logging.Log(true, "ERROR: GetCursorPos failed", err)
-		return &MousePosition{X: 0, Y: 0}, nil
-	}
-	position := &MousePosition{X: int(pt.X), Y: int(pt.Y)}
-	logging.Log(debug, "Mouse position:", position.X, position.Y)
-	return position, nil
-}
-
 // LaunchRDP - Replaces POST /api/launch - THE MAIN FUNCTION!
 func (a *LaunchRDPApp) LaunchRDP(hostID, userID string, positionX, positionY int32) (bool, error) {
 	debug := false
@@ -566,7 +797,7 @@ func (a *LaunchRDPApp) LaunchRDP(hostID, userID string, positionX, positionY int
 	_ = &MousePosition{X: int(positionX), Y: int(positionY)} // Position not used by LaunchHost
 
 	// Load host
-	hosts, err := a.storage.LoadHosts()
+	hosts, err := a.repository().LoadHosts()
 	if err != nil {
 		logging.Log(true, "ERROR: Failed to load hosts:", err)
 		return false, err
@@ -590,7 +821,7 @@ func (a *LaunchRDPApp) LaunchRDP(hostID, userID string, positionX, positionY int
 	var user *models.User
 
 	// Load user from storage
-	users, err := a.storage.LoadUsers()
+	users, err := a.repository().LoadUsers()
 	if err != nil {
 		logging.Log(true, "ERROR: Failed to load users:", err)
 		return false, err
@@ -609,6 +840,9 @@ func (a *LaunchRDPApp) LaunchRDP(hostID, userID string, positionX, positionY int
 	}
 	logging.Log(debug, "User loaded:", user.Username)
 
+	// Monitor selection may depend on where the launcher window is
+	a.resolveMonitorSelection(host)
+
 	// Password is already stored in Windows Credential Manager
 	// No need to decrypt from DPAPI here - Windows will handle it automatically
 	logging.Log(debug, "Credentials will be loaded from Windows Credential Manager")
@@ -624,97 +858,1085 @@ func (a *LaunchRDPApp) LaunchRDP(hostID, userID string, positionX, positionY int
 		logging.Log(debug, "RDP window reused (existing connection activated)")
 	} else {
 		logging.Log(debug, "RDP connection launched successfully!")
+		a.recordLaunch(*host, "", user.ID)
 	}
 	return wasReused, nil
 }
 
-// WindowBorderInfo represents window border information
-type WindowBorderInfo struct {
-	Left         int `json:"left"`
-	Right        int `json:"right"`
-	Top          int `json:"top"`
-	Bottom       int `json:"bottom"`
-	ClientWidth  int `json:"clientWidth"`
-	ClientHeight int `json:"clientHeight"`
-	WindowWidth  int `json:"windowWidth"`
-	WindowHeight int `json:"windowHeight"`
+// LaunchItem is a single launchable entry: a host's desktop or one of its RemoteApps
+type LaunchItem struct {
+	HostID  string `json:"hostId"`
+	AppID   string `json:"appId"` // empty for the full desktop session
+	Name    string `json:"name"`
+	Address string `json:"address"`
+	UserID  string `json:"userId"`
 }
 
-// GetWindowInfo - Replaces GET /api/window-info
-func (a *LaunchRDPApp) GetWindowInfo() (*WindowBorderInfo, error) {
+// GetLaunchItems returns all hosts followed by their RemoteApps as separate launchable items
+func (a *LaunchRDPApp) GetLaunchItems() ([]LaunchItem, error) {
 	debug := false
-	logging.Log(debug, "API: Getting window info - not implemented in Wails yet")
-
-	// For now, return a placeholder - can be implemented later if needed
-	info := &WindowBorderInfo{
-		Left:         0,
-		Right:        0,
-		Top:          30, // MyDockFinder offset
-		Bottom:       0,
-		ClientWidth:  275,
-		ClientHeight: 1349,
-		WindowWidth:  275,
-		WindowHeight: 1349,
+	logging.Log(debug, "API: Loading launch items")
+	hosts, err := a.repository().LoadHosts()
+	if err != nil {
+		logging.Log(true, "ERROR: Failed to load hosts:", err)
+		return nil, err
 	}
-
-	logging.Log(debug, "Window info placeholder returned")
-	return info, nil
-} // LogMessage - For frontend logging - Replaces POST /api/log
-func (a *LaunchRDPApp) LogMessage(level, message string) {
-	isError := level == "error"
-	logging.Log(isError, "Frontend:", message)
+	items := make([]LaunchItem, 0, len(hosts))
+	for _, host := range hosts {
+		items = append(items, LaunchItem{HostID: host.ID, Name: host.Name, Address: host.Address, UserID: host.UserID})
+		for _, app := range host.RemoteApps {
+			items = append(items, LaunchItem{
+				HostID:  host.ID,
+				AppID:   app.ID,
+				Name:    fmt.Sprintf("%s (%s)", app.Name, host.Name),
+				Address: host.Address,
+				UserID:  host.UserID,
+			})
+		}
+	}
+	logging.Log(debug, "API: Loaded", len(items), "launch items")
+	return items, nil
 }
 
-// GetWindowBorderInfo returns the real window frame metrics from the OS so the
-// frontend can calculate the usable client size and WinPosStr accurately.
-// This uses GetSystemMetrics for size frame, padded border and caption height.
-// Reference metrics:
-//
-//	SM_CXSIZEFRAME / SM_CYSIZEFRAME: Thickness of the sizing border
-//	SM_CXPADDEDBORDER: Extra border padding introduced with Aero
-//	SM_CYCAPTION: Caption (title bar) height
-//
-// We return a simplified model: left/right/top/bottom total non-client offsets.
-func (a *LaunchRDPApp) GetWindowBorderInfo() (*WindowBorderInfo, error) {
-	user32 := syscall.NewLazyDLL("user32.dll")
-	procGetSystemMetrics := user32.NewProc("GetSystemMetrics")
-
-	// Helper to read metric
-	getMetric := func(index int) int {
-		r, _, _ := procGetSystemMetrics.Call(uintptr(index))
-		return int(r)
-	}
-
-	const (
-		SM_CXSIZEFRAME    = 32
-		SM_CYSIZEFRAME    = 33
-		SM_CXPADDEDBORDER = 92
-		SM_CYCAPTION      = 4
-	)
-
-	sizeFrameX := getMetric(SM_CXSIZEFRAME)
-	sizeFrameY := getMetric(SM_CYSIZEFRAME)
-	padded := getMetric(SM_CXPADDEDBORDER)
-	caption := getMetric(SM_CYCAPTION)
-
-	// Non-client borders left/right include size frame + padded border
-	left := sizeFrameX + padded
-	right := sizeFrameX + padded
-	top := sizeFrameY + padded + caption
-	bottom := sizeFrameY + padded
-
-	// We do not know the runtime window size here; fill with zeroes and let frontend compute client size from user input.
-	info := &WindowBorderInfo{
-		Left:         left,
-		Right:        right,
-		Top:          top,
-		Bottom:       bottom,
-		ClientWidth:  0,
-		ClientHeight: 0,
-		WindowWidth:  0,
-		WindowHeight: 0,
-	}
+// SetHostRemoteApps replaces the RemoteApp entries of a host. Entries without
+// ID are new and get one assigned.
+func (a *LaunchRDPApp) SetHostRemoteApps(hostID string, apps []models.RemoteApp) error {
+	debug := false
+	logging.Log(debug, "API: Setting", len(apps), "RemoteApps for host", hostID)
+
+	for i := range apps {
+		if err := rdp.ValidateRemoteApp(apps[i]); err != nil {
+			return err
+		}
+		if apps[i].ID == "" {
+			apps[i].ID = models.NewRemoteApp(apps[i].Name, apps[i].Program).ID
+		}
+	}
+
+	err := a.updateHost(hostID, func(host *models.Host) error {
+		host.RemoteApps = apps
+		return nil
+	})
+	if err != nil {
+		logging.Log(true, "ERROR: Failed to save RemoteApps:", err)
+		return err
+	}
+	return nil
+}
+
+// LaunchRemoteApp launches a single RemoteApp program of a host
+func (a *LaunchRDPApp) LaunchRemoteApp(hostID, appID, userID string) error {
+	debug := false
+	logging.Log(debug, "API: Launching RemoteApp - Host:", hostID, "App:", appID, "User:", userID)
+
+	hosts, err := a.repository().LoadHosts()
+	if err != nil {
+		logging.Log(true, "ERROR: Failed to load hosts:", err)
+		return err
+	}
+	var host *models.Host
+	for i := range hosts {
+		if hosts[i].ID == hostID {
+			host = &hosts[i]
+			break
+		}
+	}
+	if host == nil {
+		logging.Log(true, "ERROR: Host not found:", hostID)
+		return fmt.Errorf("host not found")
+	}
+	app, ok := rdp.FindRemoteApp(*host, appID)
+	if !ok {
+		logging.Log(true, "ERROR: RemoteApp not found:", appID)
+		return fmt.Errorf("remote app not found")
+	}
+
+	users, err := a.repository().LoadUsers()
+	if err != nil {
+		logging.Log(true, "ERROR: Failed to load users:", err)
+		return err
+	}
+	var user *models.User
+	for i := range users {
+		if users[i].ID == userID {
+			user = &users[i]
+			break
+		}
+	}
+	if user == nil {
+		logging.Log(true, "ERROR: User not found:", userID)
+		return fmt.Errorf("user not found")
+	}
+
+	if err := a.rdpGen.LaunchRemoteApp(*host, *user, app); err != nil {
+		logging.Log(true, "ERROR: Failed to launch RemoteApp:", err)
+		return err
+	}
+	logging.Log(debug, "RemoteApp launched successfully:", app.Name)
+	a.recordLaunch(*host, app.ID, user.ID)
+	return nil
+}
+
+// recordLaunch adds a launch to the history. Failures are only logged, the
+// session is already running.
+func (a *LaunchRDPApp) recordLaunch(host models.Host, appID, userID string) {
+	entry := models.NewHistoryEntry(host, appID, userID, rdp.HostBackend(host))
+	if err := a.repository().AddHistory(entry); err != nil {
+		logging.Log(true, "ERROR: Failed to record launch history:", err)
+	}
+}
+
+// SetHostMonitors sets the monitors a fullscreen session spans (indexes as returned
+// by GetMonitorWorkAreas, empty for all). spanCurrentMonitor restricts the session
+// to the monitor the launcher is on instead.
+func (a *LaunchRDPApp) SetHostMonitors(hostID string, monitors []int, spanCurrentMonitor bool) error {
+	debug := false
+	logging.Log(debug, "API: Setting monitors for host", hostID, "monitors:", monitors, "spanCurrent:", spanCurrentMonitor)
+
+	if err := rdp.ValidateMonitorSelection(monitors); err != nil {
+		return err
+	}
+	err := a.updateHost(hostID, func(host *models.Host) error {
+		if len(monitors) == 0 {
+			monitors = nil
+		}
+		host.SelectedMonitors = monitors
+		host.SpanCurrentMonitor = spanCurrentMonitor
+		return nil
+	})
+	if err != nil {
+		logging.Log(true, "ERROR: Failed to save monitor selection:", err)
+		return err
+	}
+	return nil
+}
+
+// SetHostScaling sets display scaling and smart sizing of a host (0 = local scaling)
+func (a *LaunchRDPApp) SetHostScaling(hostID string, desktopScaleFactor, deviceScaleFactor int, smartSizing bool) error {
+	debug := false
+	logging.Log(debug, "API: Setting scaling for host", hostID, "desktop:", desktopScaleFactor, "device:", deviceScaleFactor, "smart sizing:", smartSizing)
+
+	if err := rdp.ValidateScaling(desktopScaleFactor, deviceScaleFactor); err != nil {
+		return err
+	}
+	err := a.updateHost(hostID, func(host *models.Host) error {
+		host.DesktopScaleFactor = desktopScaleFactor
+		host.DeviceScaleFactor = deviceScaleFactor
+		host.SmartSizing = smartSizing
+		return nil
+	})
+	if err != nil {
+		logging.Log(true, "ERROR: Failed to save scaling settings:", err)
+		return err
+	}
+	return nil
+}
+
+// GetLauncherBackends returns the launcher backends a host can use and the platform default first
+func (a *LaunchRDPApp) GetLauncherBackends() []string {
+	backends := []string{rdp.DefaultBackend()}
+	for _, backend := range rdp.Backends() {
+		if backend != backends[0] {
+			backends = append(backends, backend)
+		}
+	}
+	return backends
+}
+
+// SetHostLauncher sets the launcher backend of a host (empty = platform default)
+func (a *LaunchRDPApp) SetHostLauncher(hostID, launcher string) error {
+	debug := false
+	logging.Log(debug, "API: Setting launcher for host", hostID, "launcher:", launcher)
+
+	if err := rdp.ValidateBackend(launcher); err != nil {
+		return err
+	}
+	err := a.updateHost(hostID, func(host *models.Host) error {
+		host.Launcher = launcher
+		if host.RedirectDrives {
+			if err := rdp.ValidateDrivesForBackend(host.DrivesToRedirect, rdp.HostBackend(*host)); err != nil {
+				return err
+			}
+		}
+		return rdp.ValidateGatewayForBackend(host.GatewayHostname, host.GatewayCredentialsSource, rdp.HostBackend(*host))
+	})
+	if err != nil {
+		logging.Log(true, "ERROR: Failed to save launcher:", err)
+		return err
+	}
+	return nil
+}
+
+// GetExperienceProfiles returns the app-wide experience profiles (built-in profiles until the user saved own ones)
+func (a *LaunchRDPApp) GetExperienceProfiles() ([]models.ExperienceProfile, error) {
+	profiles, err := a.storage.LoadExperienceProfiles()
+	if err != nil {
+		return nil, err
+	}
+	if profiles == nil {
+		return rdp.DefaultExperienceProfiles(), nil
+	}
+	return profiles, nil
+}
+
+// GetPerformanceProperties returns the property names an experience profile can set
+func (a *LaunchRDPApp) GetPerformanceProperties() []string {
+	return rdp.PerformanceProperties()
+}
+
+// SaveExperienceProfile creates (empty ID) or updates an experience profile
+func (a *LaunchRDPApp) SaveExperienceProfile(profile models.ExperienceProfile) error {
+	debug := false
+	logging.Log(debug, "API: Saving experience profile", profile.ID, profile.Name)
+
+	if err := rdp.ValidateExperienceProfile(&profile); err != nil {
+		return err
+	}
+	return a.updateExperienceProfiles(func(profiles []models.ExperienceProfile) ([]models.ExperienceProfile, error) {
+		if profile.ID == "" {
+			return append(profiles, models.NewExperienceProfile(profile.Name, profile.Properties)), nil
+		}
+		for i := range profiles {
+			if profiles[i].ID == profile.ID {
+				profiles[i].Name = profile.Name
+				profiles[i].Properties = profile.Properties
+				profiles[i].ModifiedAt = time.Now()
+				return profiles, nil
+			}
+		}
+		return nil, fmt.Errorf("experience profile not found")
+	})
+}
+
+// DeleteExperienceProfile deletes an experience profile that no host uses
+func (a *LaunchRDPApp) DeleteExperienceProfile(profileID string) error {
+	debug := false
+	logging.Log(debug, "API: Deleting experience profile", profileID)
+
+	hosts, err := a.repository().LoadHosts()
+	if err != nil {
+		return err
+	}
+	for _, host := range hosts {
+		if host.ExperienceProfile == profileID {
+			return fmt.Errorf("experience profile is used by host %q", host.Name)
+		}
+	}
+	return a.updateExperienceProfiles(func(profiles []models.ExperienceProfile) ([]models.ExperienceProfile, error) {
+		for i, profile := range profiles {
+			if profile.ID == profileID {
+				return append(profiles[:i], profiles[i+1:]...), nil
+			}
+		}
+		return nil, fmt.Errorf("experience profile not found")
+	})
+}
+
+// updateExperienceProfiles modifies the profiles under the storage lock (starting
+// from the built-in profiles until the user saved own ones) and hands the result
+// to the generator
+func (a *LaunchRDPApp) updateExperienceProfiles(fn func([]models.ExperienceProfile) ([]models.ExperienceProfile, error)) error {
+	var updated []models.ExperienceProfile
+	err := a.storage.UpdateExperienceProfiles(func(profiles []models.ExperienceProfile) ([]models.ExperienceProfile, error) {
+		if profiles == nil {
+			profiles = rdp.DefaultExperienceProfiles()
+		}
+		profiles, err := fn(profiles)
+		updated = profiles
+		return profiles, err
+	})
+	if err != nil {
+		logging.Log(true, "ERROR: Failed to save experience profiles:", err)
+		return err
+	}
+	a.rdpGen.SetExperienceProfiles(updated)
+	return nil
+}
+
+// SetHostExperienceProfile sets the experience profile of a host (empty ID = defaults)
+func (a *LaunchRDPApp) SetHostExperienceProfile(hostID, profileID string) error {
+	debug := false
+	logging.Log(debug, "API: Setting experience profile for host", hostID, "profile:", profileID)
+
+	if profileID != "" {
+		profiles, err := a.GetExperienceProfiles()
+		if err != nil {
+			return err
+		}
+		found := false
+		for _, profile := range profiles {
+			if profile.ID == profileID {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return fmt.Errorf("experience profile not found")
+		}
+	}
+	err := a.updateHost(hostID, func(host *models.Host) error {
+		host.ExperienceProfile = profileID
+		return nil
+	})
+	if err != nil {
+		logging.Log(true, "ERROR: Failed to save experience profile:", err)
+		return err
+	}
+	return nil
+}
+
+// GetSigningCertificate returns the certificate generated files are signed with, nil if unsigned
+func (a *LaunchRDPApp) GetSigningCertificate() (*rdp.SignerInfo, error) {
+	settings, err := a.storage.LoadSigningSettings()
+	if err != nil || settings == nil {
+		return nil, err
+	}
+	signer, err := a.signerFromSettings(*settings)
+	if err != nil {
+		return nil, err
+	}
+	info := signer.Info()
+	return &info, nil
+}
+
+// SetSigningCertificate configures the certificate for signing generated files:
+// a PFX/P12 file with password, or a PEM certificate with PEM key (keyPath may be
+// empty for combined PEM files). An empty certPath disables signing.
+func (a *LaunchRDPApp) SetSigningCertificate(certPath, keyPath, password string) (*rdp.SignerInfo, error) {
+	debug := false
+	logging.Log(debug, "API: Setting signing certificate", certPath, keyPath)
+
+	if certPath == "" {
+		if err := a.storage.SaveSigningSettings(nil); err != nil {
+			return nil, err
+		}
+		a.rdpGen.SetSigner(nil)
+		return nil, nil
+	}
+
+	settings := models.SigningSettings{CertificatePath: certPath, KeyPath: keyPath}
+	if password != "" {
+		encrypted, err := a.cipher.Encrypt(password)
+		if err != nil {
+			return nil, fmt.Errorf("failed to encrypt certificate password: %w", err)
+		}
+		settings.Password = encrypted
+	}
+	// Load once before saving so invalid files or passwords are reported right away
+	signer, err := a.signerFromSettings(settings)
+	if err != nil {
+		return nil, err
+	}
+	if err := a.storage.SaveSigningSettings(&settings); err != nil {
+		logging.Log(true, "ERROR: Failed to save signing settings:", err)
+		return nil, err
+	}
+	a.rdpGen.SetSigner(signer)
+	info := signer.Info()
+	return &info, nil
+}
+
+// loadSigner hands the configured signing certificate to the generator
+func (a *LaunchRDPApp) loadSigner() error {
+	settings, err := a.storage.LoadSigningSettings()
+	if err != nil || settings == nil {
+		return err
+	}
+	signer, err := a.signerFromSettings(*settings)
+	if err != nil {
+		return err
+	}
+	a.rdpGen.SetSigner(signer)
+	return nil
+}
+
+// signerFromSettings loads the certificate, PFX files are detected by extension
+func (a *LaunchRDPApp) signerFromSettings(settings models.SigningSettings) (*rdp.Signer, error) {
+	switch strings.ToLower(filepath.Ext(settings.CertificatePath)) {
+	case ".pfx", ".p12":
+		password, err := a.cipher.Decrypt(settings.Password)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decrypt certificate password: %w", err)
+		}
+		return rdp.LoadSignerPFX(settings.CertificatePath, password)
+	default:
+		return rdp.LoadSignerPEM(settings.CertificatePath, settings.KeyPath)
+	}
+}
+
+// GetStorageRecoveries lists config files that were corrupt and loaded from their
+// backup, so the frontend can tell the user that recent changes may be missing
+func (a *LaunchRDPApp) GetStorageRecoveries() []storage.Recovery {
+	return a.storage.Recoveries()
+}
+
+// repository returns the repository in use, see SetStorageBackend
+func (a *LaunchRDPApp) repository() storage.Repository {
+	a.repoMu.RLock()
+	defer a.repoMu.RUnlock()
+	return a.repo
+}
+
+// GetStorageError returns why the storage is read-only, empty while it can be
+// changed. DomReady shows it in a dialog.
+func (a *LaunchRDPApp) GetStorageError() string {
+	if readOnly, ok := a.repository().(*storage.ReadOnlyRepository); ok {
+		return readOnly.Err.Error()
+	}
+	return ""
+}
+
+// GetStorageBackend returns the backend hosts and users are stored in ("json" or "sqlite")
+func (a *LaunchRDPApp) GetStorageBackend() string {
+	return a.repository().Backend()
+}
+
+// SetStorageBackend copies all hosts, users, groups and history to the given
+// backend and continues with it. Switching back to JSON keeps the database as
+// launchrdp.db.bak.
+func (a *LaunchRDPApp) SetStorageBackend(backend string) error {
+	debug := true
+	a.repoMu.Lock()
+	defer a.repoMu.Unlock()
+
+	if readOnly, ok := a.repo.(*storage.ReadOnlyRepository); ok {
+		// Copying the outdated JSON files would overwrite the database
+		return fmt.Errorf("cannot switch the storage backend while the storage is read-only: %w", readOnly.Err)
+	}
+	if backend == a.repo.Backend() {
+		return nil
+	}
+	logging.Log(debug, "API: Switching storage backend from", a.repo.Backend(), "to", backend)
+
+	dbPath := config.GetConfigPath(storage.DatabaseFileName)
+	switch backend {
+	case storage.BackendSQLite:
+		db, err := storage.OpenSQLite(dbPath)
+		if err != nil {
+			logging.Log(true, "ERROR: Failed to open database:", err)
+			return err
+		}
+		if err := storage.CopyRepository(db, a.repo); err != nil {
+			logging.Log(true, "ERROR: Failed to migrate to SQLite:", err)
+			db.Close()
+			// Without the file the next start keeps using the JSON files
+			os.Remove(dbPath)
+			return fmt.Errorf("failed to migrate to SQLite: %w", err)
+		}
+		a.repo = db
+	case storage.BackendJSON:
+		if err := storage.CopyRepository(a.storage, a.repo); err != nil {
+			logging.Log(true, "ERROR: Failed to migrate to JSON:", err)
+			return fmt.Errorf("failed to migrate to JSON: %w", err)
+		}
+		// The database file selects the backend on startup. It is renamed before
+		// the switch, so a failed rename keeps the database in use. Windows cannot
+		// rename the open file.
+		if err := a.repo.Close(); err != nil {
+			logging.Log(true, "ERROR: Failed to close database:", err)
+		}
+		if err := os.Rename(dbPath, dbPath+storage.BackupSuffix); err != nil {
+			logging.Log(true, "ERROR: Failed to rename database:", err)
+			db, openErr := storage.OpenSQLite(dbPath)
+			if openErr != nil {
+				logging.Log(true, "ERROR: Failed to reopen database:", openErr)
+				a.repo = &storage.ReadOnlyRepository{Repository: a.storage, Err: openErr}
+			} else {
+				a.repo = db
+			}
+			return fmt.Errorf("failed to rename database: %w", err)
+		}
+		a.repo = a.storage
+	default:
+		return fmt.Errorf("unknown storage backend: %s", backend)
+	}
+
+	logging.Log(debug, "Storage backend switched to", backend)
+	return nil
+}
+
+// GetGroups returns all host groups, sorted by name
+func (a *LaunchRDPApp) GetGroups() ([]models.Group, error) {
+	groups, err := a.repository().LoadGroups()
+	if err != nil {
+		logging.Log(true, "ERROR: Failed to load groups:", err)
+		return nil, err
+	}
+	return groups, nil
+}
+
+// SaveGroup creates (empty ID) or renames a group
+func (a *LaunchRDPApp) SaveGroup(group models.Group) (models.Group, error) {
+	debug := false
+	logging.Log(debug, "API: Saving group", group.ID, group.Name)
+
+	group.Name = strings.TrimSpace(group.Name)
+	if group.Name == "" {
+		return models.Group{}, fmt.Errorf("group name is required")
+	}
+	err := a.repository().UpdateGroups(func(groups []models.Group) ([]models.Group, error) {
+		if group.ID == "" {
+			group = models.NewGroup(group.Name)
+			return append(groups, group), nil
+		}
+		for i := range groups {
+			if groups[i].ID == group.ID {
+				groups[i].Name = group.Name
+				groups[i].ModifiedAt = time.Now()
+				group = groups[i]
+				return groups, nil
+			}
+		}
+		return nil, fmt.Errorf("group not found")
+	})
+	if err != nil {
+		logging.Log(true, "ERROR: Failed to save group:", err)
+		return models.Group{}, err
+	}
+	return group, nil
+}
+
+// DeleteGroup deletes a group, its hosts are kept without group
+func (a *LaunchRDPApp) DeleteGroup(groupID string) error {
+	debug := false
+	logging.Log(debug, "API: Deleting group", groupID)
+
+	err := a.repository().UpdateHosts(func(hosts []models.Host) ([]models.Host, error) {
+		for i := range hosts {
+			if hosts[i].GroupID == groupID {
+				hosts[i].GroupID = ""
+				hosts[i].ModifiedAt = time.Now()
+			}
+		}
+		return hosts, nil
+	})
+	if err != nil {
+		logging.Log(true, "ERROR: Failed to ungroup hosts:", err)
+		return err
+	}
+	err = a.repository().UpdateGroups(func(groups []models.Group) ([]models.Group, error) {
+		for i := range groups {
+			if groups[i].ID == groupID {
+				return append(groups[:i], groups[i+1:]...), nil
+			}
+		}
+		return nil, fmt.Errorf("group not found")
+	})
+	if err != nil {
+		logging.Log(true, "ERROR: Failed to delete group:", err)
+		return err
+	}
+	return nil
+}
+
+// SetHostGroup moves a host into a group (empty groupID = no group)
+func (a *LaunchRDPApp) SetHostGroup(hostID, groupID string) error {
+	debug := false
+	logging.Log(debug, "API: Setting group of host", hostID, "to", groupID)
+
+	if groupID != "" {
+		groups, err := a.repository().LoadGroups()
+		if err != nil {
+			logging.Log(true, "ERROR: Failed to load groups:", err)
+			return err
+		}
+		found := false
+		for _, group := range groups {
+			if group.ID == groupID {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return fmt.Errorf("group not found")
+		}
+	}
+	err := a.updateHost(hostID, func(host *models.Host) error {
+		host.GroupID = groupID
+		return nil
+	})
+	if err != nil {
+		logging.Log(true, "ERROR: Failed to save host group:", err)
+		return err
+	}
+	return nil
+}
+
+// GetHistory returns the latest launches, newest first (limit <= 0 = all kept entries)
+func (a *LaunchRDPApp) GetHistory(limit int) ([]models.HistoryEntry, error) {
+	entries, err := a.repository().LoadHistory(limit)
+	if err != nil {
+		logging.Log(true, "ERROR: Failed to load history:", err)
+		return nil, err
+	}
+	return entries, nil
+}
+
+// VerifyCredentials compares the stored TERMSRV credentials with the hosts and
+// their users: missing, stale and mismatched credentials per host, and orphaned
+// credentials no host uses anymore
+func (a *LaunchRDPApp) VerifyCredentials() (credentials.HealthReport, error) {
+	debug := false
+	logging.Log(debug, "API: Verifying credentials")
+
+	report, _, err := a.verifyCredentials()
+	if err != nil {
+		logging.Log(true, "ERROR: Failed to verify credentials:", err)
+		return credentials.HealthReport{}, err
+	}
+	logging.Log(debug, "API: Credential check found", report.Issues, "issues")
+	return report, nil
+}
+
+// RepairCredentials rewrites missing, stale and mismatched credentials from the
+// encrypted passwords in users.json and returns the new report. Orphaned
+// credentials are kept.
+func (a *LaunchRDPApp) RepairCredentials() (credentials.HealthReport, error) {
+	debug := true
+	logging.Log(debug, "API: Repairing credentials")
+
+	report, users, err := a.verifyCredentials()
+	if err != nil {
+		logging.Log(true, "ERROR: Failed to verify credentials:", err)
+		return credentials.HealthReport{}, err
+	}
+	result := credentials.RepairHosts(a.credStore, a.cipher, report, users)
+	for _, failure := range result.Failed {
+		logging.Log(true, "ERROR: Failed to repair credential", failure)
+	}
+	logging.Log(debug, "Repaired", len(result.Repaired), "credentials,", len(result.Failed), "failed")
+
+	report, _, err = a.verifyCredentials()
+	if err != nil {
+		logging.Log(true, "ERROR: Failed to verify credentials:", err)
+		return credentials.HealthReport{}, err
+	}
+	return report, nil
+}
+
+// verifyCredentials builds the health report, it also returns the loaded users
+func (a *LaunchRDPApp) verifyCredentials() (credentials.HealthReport, []models.User, error) {
+	hosts, err := a.repository().LoadHosts()
+	if err != nil {
+		return credentials.HealthReport{}, nil, err
+	}
+	users, err := a.repository().LoadUsers()
+	if err != nil {
+		return credentials.HealthReport{}, nil, err
+	}
+	stored, err := a.credStore.List()
+	if err != nil {
+		return credentials.HealthReport{}, nil, fmt.Errorf("failed to list credentials: %w", err)
+	}
+	report := credentials.VerifyHosts(hosts, users, stored)
+	report.Store = a.credStore.Name()
+	return report, users, nil
+}
+
+// PurgeOrphanedCredentials deletes the credentials LaunchRDP wrote for targets
+// no host uses anymore (hosts deleted or moved before credentials were cleaned up)
+func (a *LaunchRDPApp) PurgeOrphanedCredentials() (credentials.PurgeResult, error) {
+	debug := true
+	logging.Log(debug, "API: Purging orphaned credentials")
+
+	hosts, err := a.repository().LoadHosts()
+	if err != nil {
+		logging.Log(true, "ERROR: Failed to load hosts:", err)
+		return credentials.PurgeResult{}, err
+	}
+	result, err := credentials.PurgeOrphaned(a.credStore, hosts)
+	if err != nil {
+		logging.Log(true, "ERROR: Failed to purge credentials:", err)
+		return credentials.PurgeResult{}, err
+	}
+	for _, failure := range result.Failed {
+		logging.Log(true, "ERROR: Failed to delete credential", failure)
+	}
+	logging.Log(debug, "Purged", len(result.Deleted), "orphaned credentials,", len(result.Failed), "failed")
+	return result, nil
+}
+
+// GetCredentialConflicts lists credential targets shared by hosts with different
+// users; those hosts need a credential alias
+func (a *LaunchRDPApp) GetCredentialConflicts() ([]credentials.Conflict, error) {
+	hosts, err := a.repository().LoadHosts()
+	if err != nil {
+		logging.Log(true, "ERROR: Failed to load hosts:", err)
+		return nil, err
+	}
+	users, err := a.repository().LoadUsers()
+	if err != nil {
+		logging.Log(true, "ERROR: Failed to load users:", err)
+		return nil, err
+	}
+	return credentials.FindConflicts(hosts, users), nil
+}
+
+// SetHostCredentialAlias stores the credential of a host under an alias instead
+// of its address (empty = the address) and moves the stored credential
+func (a *LaunchRDPApp) SetHostCredentialAlias(hostID, alias string) error {
+	debug := false
+	logging.Log(debug, "API: Setting credential alias of host", hostID, "to", alias)
+
+	alias = strings.TrimSpace(alias)
+	if err := credentials.ValidateAlias(alias); err != nil {
+		return err
+	}
+	var oldTarget string
+	err := a.updateHost(hostID, func(host *models.Host) error {
+		oldTarget = host.CredentialTarget()
+		if strings.EqualFold(alias, host.Address) {
+			alias = ""
+		}
+		host.CredentialAlias = alias
+		return nil
+	})
+	if err != nil {
+		logging.Log(true, "ERROR: Failed to save credential alias:", err)
+		return err
+	}
+	a.moveHostCredential(hostID, oldTarget)
+	return nil
+}
+
+// SuggestCredentialAliases proposes credential aliases no other host uses:
+// "address:port" and the DNS names and IP addresses the address resolves to
+func (a *LaunchRDPApp) SuggestCredentialAliases(hostID string) ([]string, error) {
+	hosts, err := a.repository().LoadHosts()
+	if err != nil {
+		logging.Log(true, "ERROR: Failed to load hosts:", err)
+		return nil, err
+	}
+	var host *models.Host
+	for i := range hosts {
+		if hosts[i].ID == hostID {
+			host = &hosts[i]
+			break
+		}
+	}
+	if host == nil {
+		return nil, fmt.Errorf("host not found")
+	}
+
+	port := host.Port
+	if port == 0 {
+		port = 3389
+	}
+	candidates := []string{net.JoinHostPort(host.Address, strconv.Itoa(port))}
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+	if addresses, err := net.DefaultResolver.LookupHost(ctx, host.Address); err == nil {
+		for _, address := range addresses {
+			if names, err := net.DefaultResolver.LookupAddr(ctx, address); err == nil {
+				for _, name := range names {
+					candidates = append(candidates, strings.TrimSuffix(name, "."))
+				}
+			}
+			if !strings.Contains(address, ":") { // IPv6 addresses can't be targets
+				candidates = append(candidates, address)
+			}
+		}
+	}
+
+	suggestions := []string{}
+	seen := map[string]bool{}
+	for _, candidate := range candidates {
+		key := strings.ToLower(candidate)
+		if seen[key] || strings.EqualFold(candidate, host.Address) || credentials.ValidateAlias(candidate) != nil {
+			continue
+		}
+		seen[key] = true
+		if !credentials.TargetInUse(hosts, candidate, host.ID) {
+			suggestions = append(suggestions, candidate)
+		}
+	}
+	return suggestions, nil
+}
+
+// ExportVault writes all users with their passwords, and optionally all hosts,
+// to a file encrypted with passphrase. Unlike users.json it can be imported on
+// another machine or account. Users whose password can't be decrypted are
+// exported without it and listed in the result.
+func (a *LaunchRDPApp) ExportVault(path, passphrase string, includeHosts bool) (credentials.VaultExport, error) {
+	debug := true
+	logging.Log(debug, "API: Exporting vault to", path, "with hosts:", includeHosts)
+
+	users, err := a.repository().LoadUsers()
+	if err != nil {
+		logging.Log(true, "ERROR: Failed to load users:", err)
+		return credentials.VaultExport{}, err
+	}
+	result := credentials.VaultExport{UsersWithoutPassword: []string{}}
+	vault := credentials.Vault{Users: make([]credentials.VaultUser, 0, len(users))}
+	for _, user := range users {
+		password, err := a.userPassword(user)
+		if err != nil {
+			logging.Log(true, "ERROR: Failed to decrypt password of", user.Username, "- exporting it without password:", err)
+			result.UsersWithoutPassword = append(result.UsersWithoutPassword, user.Username)
+			password = ""
+		}
+		user.EncryptedPassword = ""
+		vault.Users = append(vault.Users, credentials.VaultUser{User: user, Password: password})
+	}
+	if includeHosts {
+		hosts, err := a.repository().LoadHosts()
+		if err != nil {
+			logging.Log(true, "ERROR: Failed to load hosts:", err)
+			return credentials.VaultExport{}, err
+		}
+		for i := range hosts {
+			hosts[i].GroupID = "" // groups are not exported
+		}
+		vault.Hosts = hosts
+	}
+
+	if err := credentials.WriteVault(path, passphrase, vault); err != nil {
+		logging.Log(true, "ERROR: Failed to export vault:", err)
+		return credentials.VaultExport{}, err
+	}
+	result.Users, result.Hosts = len(vault.Users), len(vault.Hosts)
+	logging.Log(debug, "Exported", result.Users, "users and", result.Hosts, "hosts,", len(result.UsersWithoutPassword), "without password")
+	return result, nil
+}
+
+// ImportVault merges a file written by ExportVault into the local users and
+// hosts. mode decides conflicts: "preview" only reports them, "keep" keeps the
+// local entries, "replace" overwrites them. Credentials of the imported hosts
+// are stored afterwards.
+func (a *LaunchRDPApp) ImportVault(path, passphrase, mode string) (credentials.VaultImport, error) {
+	debug := true
+	logging.Log(debug, "API: Importing vault from", path, "mode:", mode)
+
+	vault, err := credentials.ReadVault(path, passphrase)
+	if err != nil {
+		logging.Log(true, "ERROR: Failed to read vault:", err)
+		return credentials.VaultImport{}, err
+	}
+
+	var result credentials.VaultImport
+	if mode == credentials.VaultPreview {
+		users, err := a.repository().LoadUsers()
+		if err != nil {
+			logging.Log(true, "ERROR: Failed to load users:", err)
+			return credentials.VaultImport{}, err
+		}
+		hosts, err := a.repository().LoadHosts()
+		if err != nil {
+			logging.Log(true, "ERROR: Failed to load hosts:", err)
+			return credentials.VaultImport{}, err
+		}
+		_, _, result, err = credentials.MergeVault(users, hosts, vault, a.cipher, mode)
+		if err != nil {
+			logging.Log(true, "ERROR: Failed to merge vault:", err)
+			return credentials.VaultImport{}, err
+		}
+		return result, nil
+	}
+
+	err = a.repository().UpdateUsersAndHosts(func(users []models.User, hosts []models.Host) ([]models.User, []models.Host, error) {
+		var err error
+		users, hosts, result, err = credentials.MergeVault(users, hosts, vault, a.cipher, mode)
+		return users, hosts, err
+	})
+	if err != nil {
+		logging.Log(true, "ERROR: Failed to import vault:", err)
+		return credentials.VaultImport{}, err
+	}
+	logging.Log(debug, "Imported vault:", result.UsersAdded, "users added,", result.UsersUpdated, "updated,",
+		result.HostsAdded, "hosts added,", result.HostsUpdated, "updated,", len(result.Conflicts), "conflicts,",
+		len(result.HostsWithoutUser), "hosts without user")
+
+	// Store the credentials of the hosts the import added or changed, directly or
+	// through their user. Other hosts are left to RepairCredentials.
+	report, users, err := a.verifyCredentials()
+	if err != nil {
+		logging.Log(true, "ERROR: Failed to verify credentials:", err)
+		return result, nil
+	}
+	report.Hosts = slices.DeleteFunc(report.Hosts, func(state credentials.HostCredential) bool {
+		return !slices.Contains(result.ChangedHostIDs, state.HostID)
+	})
+	repair := credentials.RepairHosts(a.credStore, a.cipher, report, users)
+	for _, failure := range repair.Failed {
+		logging.Log(true, "ERROR: Failed to store credential", failure)
+	}
+	return result, nil
+}
+
+// PortableStatus describes portable mode and the state of its passphrase store
+type PortableStatus struct {
+	Portable    bool `json:"portable"`
+	Initialized bool `json:"initialized"` // a passphrase has been set
+	Locked      bool `json:"locked"`      // the passphrase has to be entered before passwords can be used
+}
+
+// GetPortableStatus reports portable mode; the frontend asks for the passphrase while locked
+func (a *LaunchRDPApp) GetPortableStatus() PortableStatus {
+	store, ok := a.cipher.(credentials.LockableCipher)
+	if !ok {
+		return PortableStatus{}
+	}
+	return PortableStatus{Portable: true, Initialized: store.Initialized(), Locked: store.Locked()}
+}
+
+// UnlockCredentials enters the passphrase of the portable credential store.
+// The first call sets the passphrase.
+func (a *LaunchRDPApp) UnlockCredentials(passphrase string) error {
+	debug := false
+	logging.Log(debug, "API: Unlocking credential store")
+
+	store, ok := a.cipher.(credentials.LockableCipher)
+	if !ok {
+		return fmt.Errorf("not in portable mode, passwords are protected by DPAPI")
+	}
+	if err := store.Unlock(passphrase); err != nil {
+		logging.Log(true, "ERROR: Failed to unlock credential store:", err)
+		return err
+	}
+	// A PFX password could not be decrypted while locked
+	if err := a.loadSigner(); err != nil {
+		logging.Log(true, "Warning: Failed to load signing certificate, files stay unsigned:", err)
+	}
+	return nil
+}
+
+// ChangeCredentialsPassphrase sets a new passphrase for the portable credential
+// store and re-encrypts the user passwords and the certificate password with it.
+// Passwords that cannot be decrypted with the current passphrase are kept as they are.
+func (a *LaunchRDPApp) ChangeCredentialsPassphrase(current, passphrase string) error {
+	debug := false
+	logging.Log(debug, "API: Changing credential store passphrase")
+
+	store, ok := a.cipher.(credentials.LockableCipher)
+	if !ok {
+		return fmt.Errorf("not in portable mode, passwords are protected by DPAPI")
+	}
+	err := store.ChangePassphrase(current, passphrase, func(convert func(string) (string, error)) error {
+		settings, err := a.storage.LoadSigningSettings()
+		if err != nil {
+			return err
+		}
+		if settings != nil && settings.Password != "" {
+			if settings.Password, err = convert(settings.Password); err != nil {
+				return fmt.Errorf("failed to re-encrypt certificate password: %w", err)
+			}
+		}
+		err = a.repository().UpdateUsers(func(users []models.User) ([]models.User, error) {
+			for i := range users {
+				encrypted, err := convert(users[i].EncryptedPassword)
+				if err != nil {
+					logging.Log(true, "Warning: Keeping undecryptable password of user", users[i].Username+":", err)
+					continue
+				}
+				users[i].EncryptedPassword = encrypted
+			}
+			return users, nil
+		})
+		if err != nil {
+			return err
+		}
+		// The users are saved with the new key, the passphrase has to change now
+		if settings != nil && settings.Password != "" {
+			if err := a.storage.SaveSigningSettings(settings); err != nil {
+				logging.Log(true, "ERROR: Failed to save re-encrypted certificate password, enter it again:", err)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		logging.Log(true, "ERROR: Failed to change credential store passphrase:", err)
+		return err
+	}
+	return nil
+}
+
+// resolveMonitorSelection prepares the monitor selection of a host for one launch:
+// "span current monitor" picks the monitor containing the center of the launcher
+// window, and selected monitors that are no longer connected are dropped.
+// Monitor indexes follow EnumDisplayMonitors order, which matches the IDs of "mstsc /l"
+// on typical setups.
+func (a *LaunchRDPApp) resolveMonitorSelection(host *models.Host) {
+	debug := false
+	if len(host.SelectedMonitors) == 0 && !host.SpanCurrentMonitor {
+		return
+	}
+	monitors, err := a.GetMonitorWorkAreas()
+	if err != nil || len(monitors) == 0 {
+		logging.Log(true, "WARNING: Monitor enumeration failed, using all monitors:", err)
+		host.SelectedMonitors = nil
+		return
+	}
+
+	if host.SpanCurrentMonitor {
+		a.winStateMu.Lock()
+		positionX := a.winState.X + a.winState.Width/2
+		positionY := a.winState.Y + a.winState.Height/2
+		a.winStateMu.Unlock()
+
+		current := -1
+		for _, m := range monitors {
+			if positionX >= m.MonitorLeft && positionX < m.MonitorRight && positionY >= m.MonitorTop && positionY < m.MonitorBottom {
+				current = m.Index
+				break
+			}
+			if m.Primary && current == -1 {
+				current = m.Index // fallback if the position is outside all monitors
+			}
+		}
+		if current == -1 {
+			current = monitors[0].Index
+		}
+		logging.Log(debug, "Span current monitor: launcher center", positionX, positionY, "is on monitor", current)
+		host.SelectedMonitors = []int{current}
+		return
+	}
+
+	connected := make([]int, 0, len(host.SelectedMonitors))
+	for _, index := range host.SelectedMonitors {
+		if index < len(monitors) {
+			connected = append(connected, index)
+		} else {
+			logging.Log(true, "WARNING: Selected monitor", index, "is not connected, skipping")
+		}
+	}
+	host.SelectedMonitors = connected
+}
+
+// WindowBorderInfo represents window border information
+type WindowBorderInfo struct {
+	Left         int `json:"left"`
+	Right        int `json:"right"`
+	Top          int `json:"top"`
+	Bottom       int `json:"bottom"`
+	ClientWidth  int `json:"clientWidth"`
+	ClientHeight int `json:"clientHeight"`
+	WindowWidth  int `json:"windowWidth"`
+	WindowHeight int `json:"windowHeight"`
+}
+
+// GetWindowInfo - Replaces GET /api/window-info
+func (a *LaunchRDPApp) GetWindowInfo() (*WindowBorderInfo, error) {
+	debug := false
+	logging.Log(debug, "API: Getting window info - not implemented in Wails yet")
+
+	// For now, return a placeholder - can be implemented later if needed
+	info := &WindowBorderInfo{
+		Left:         0,
+		Right:        0,
+		Top:          30, // MyDockFinder offset
+		Bottom:       0,
+		ClientWidth:  275,
+		ClientHeight: 1349,
+		WindowWidth:  275,
+		WindowHeight: 1349,
+	}
+
+	logging.Log(debug, "Window info placeholder returned")
 	return info, nil
+} // LogMessage - For frontend logging - Replaces POST /api/log
+func (a *LaunchRDPApp) LogMessage(level, message string) {
+	isError := level == "error"
+	logging.Log(isError, "Frontend:", message)
 }
 
 // ================= Window State Persistence =================
@@ -734,19 +1956,6 @@ type WindowState struct {
 // Returns (x,y,w,h) and a bool indicating success.
 // (former captureCurrentWindow removed; proactive hooks now maintain state)
 
-// initHWND finds the window handle by title (class optional) after window is shown
-func (a *LaunchRDPApp) initHWND() {
-	if a.windowHWND != 0 {
-		return
-	}
-	user32 := syscall.NewLazyDLL("user32.dll")
-	procFindWindow := user32.NewProc("FindWindowW")
-	titlePtr, _ := syscall.UTF16PtrFromString("LaunchRDP")
-	hwnd, _, _ := procFindWindow.Call(0, uintptr(unsafe.Pointer(titlePtr)))
-	a.windowHWND = hwnd
-	logging.Log(true, fmt.Sprintf("initHWND: hwnd=%x", hwnd))
-}
-
 // startGeometryTicker periodically samples window geometry as a fallback
 func (a *LaunchRDPApp) startGeometryTicker() {
 	if a.stopTicker != nil {
@@ -784,76 +1993,6 @@ func (a *LaunchRDPApp) startGeometryTicker() {
 	}()
 }
 
-// startMoveSizeHook sets a WinEvent hook for MOVESIZEEND events to capture final geometry after user interaction
-func (a *LaunchRDPApp) startMoveSizeHook() error {
-	user32 := syscall.NewLazyDLL("user32.dll")
-	procSetWinEventHook := user32.NewProc("SetWinEventHook")
-	procUnhookWinEvent := user32.NewProc("UnhookWinEvent")
-	procGetWindowRect := user32.NewProc("GetWindowRect")
-	procGetWindowThreadProcessId := user32.NewProc("GetWindowThreadProcessId")
-
-	const EVENT_SYSTEM_MOVESIZEEND = 0x000B
-	const WINEVENT_INCONTEXT = 0x0004
-
-	if a.windowHWND == 0 {
-		a.initHWND()
-	}
-	if a.windowHWND == 0 {
-		return fmt.Errorf("startMoveSizeHook: hwnd not found")
-	}
-
-	// Get the thread ID of the window to hook events for that thread only
-	var processID uint32
-	threadID, _, _ := procGetWindowThreadProcessId.Call(a.windowHWND, uintptr(unsafe.Pointer(&processID)))
-	logging.Log(true, fmt.Sprintf("startMoveSizeHook: hwnd=%x threadId=%d processId=%d", a.windowHWND, threadID, processID))
-
-	// Load the current module handle for INCONTEXT hook
-	kernel32 := syscall.NewLazyDLL("kernel32.dll")
-	procGetModuleHandle := kernel32.NewProc("GetModuleHandleW")
-	hModule, _, _ := procGetModuleHandle.Call(0)
-
-	// Callback - INCONTEXT runs in the UI thread's message loop (compatible with Wails)
-	a.winEventCallback = syscall.NewCallback(func(hWinEventHook, event, hwnd, idObject, idChild, dwEventThread, dwmsEventTime uintptr) uintptr {
-		if event == EVENT_SYSTEM_MOVESIZEEND && hwnd == a.windowHWND {
-			type rect struct{ Left, Top, Right, Bottom int32 }
-			var r rect
-			rOk, _, _ := procGetWindowRect.Call(hwnd, uintptr(unsafe.Pointer(&r)))
-			if rOk != 0 {
-				x := int(r.Left)
-				y := int(r.Top)
-				w := int(r.Right - r.Left)
-				h := int(r.Bottom - r.Top)
-				a.winStateMu.Lock()
-				a.winState.X = x
-				a.winState.Y = y
-				a.winState.Width = w
-				a.winState.Height = h
-				_ = saveWindowState(a.winState)
-				a.winStateMu.Unlock()
-				logging.Log(true, fmt.Sprintf("Hook MOVESIZEEND: saved X=%d Y=%d W=%d H=%d", x, y, w, h))
-			}
-		}
-		return 0
-	})
-
-	hHook, _, err := procSetWinEventHook.Call(
-		uintptr(EVENT_SYSTEM_MOVESIZEEND), // eventMin
-		uintptr(EVENT_SYSTEM_MOVESIZEEND), // eventMax
-		hModule,                           // hmod - our module for INCONTEXT
-		a.winEventCallback,                // callback
-		uintptr(processID),                // idProcess - our process only
-		threadID,                          // idThread - our UI thread only
-		uintptr(WINEVENT_INCONTEXT),       // flags - INCONTEXT for message loop integration
-	)
-	if hHook == 0 {
-		return fmt.Errorf("SetWinEventHook failed: %v", err)
-	}
-	a.winEventHook = hHook
-	_ = procUnhookWinEvent // silence unused (we unhook in Shutdown)
-	logging.Log(true, fmt.Sprintf("startMoveSizeHook: hook=%x", hHook))
-	return nil
-}
-
 // WorkArea represents usable desktop area excluding taskbar
 type WorkArea struct {
 	Left   int `json:"left"`
@@ -918,75 +2057,6 @@ func saveWindowState(ws *WindowState) error {
 	return os.WriteFile(path, b, 0644)
 }
 
-// GetWorkArea retrieves primary monitor work area (excluding taskbar)
-func (a *LaunchRDPApp) GetWorkArea() (*WorkArea, error) {
-	user32 := syscall.NewLazyDLL("user32.dll")
-	procSPI := user32.NewProc("SystemParametersInfoW")
-	const SPI_GETWORKAREA = 0x0030
-	type rect struct{ Left, Top, Right, Bottom int32 }
-	var r rect
-	ret, _, err := procSPI.Call(uintptr(SPI_GETWORKAREA), 0, uintptr(unsafe.Pointer(&r)), 0)
-	if ret == 0 {
-		return nil, err
-	}
-	wa := &WorkArea{Left: int(r.Left), Top: int(r.Top), Right: int(r.Right), Bottom: int(r.Bottom)}
-	wa.Width = wa.Right - wa.Left
-	wa.Height = wa.Bottom - wa.Top
-	return wa, nil
-}
-
-// GetMonitorWorkAreas enumeriert alle Monitore (EnumDisplayMonitors) und liest deren rcMonitor & rcWork
-func (a *LaunchRDPApp) GetMonitorWorkAreas() ([]MonitorWorkArea, error) {
-	user32 := syscall.NewLazyDLL("user32.dll")
-	procEnum := user32.NewProc("EnumDisplayMonitors")
-	procGetInfo := user32.NewProc("GetMonitorInfoW")
-	debug := true
-	logging.Log(debug, "API: GetMonitorWorkAreas start")
-
-	type rect struct{ Left, Top, Right, Bottom int32 }
-	type monitorInfoEx struct {
-		cbSize    uint32
-		rcMonitor rect
-		rcWork    rect
-		dwFlags   uint32
-		szDevice  [32]uint16
-	}
-
-	monitors := make([]MonitorWorkArea, 0, 8)
-	index := 0
-
-	enumCallback := syscall.NewCallback(func(hMonitor, hdc, lprcMonitor, dwData uintptr) uintptr {
-		var mi monitorInfoEx
-		mi.cbSize = uint32(unsafe.Sizeof(mi))
-		r, _, _ := procGetInfo.Call(hMonitor, uintptr(unsafe.Pointer(&mi)))
-		if r != 0 {
-			m := MonitorWorkArea{
-				Index:         index,
-				MonitorLeft:   int(mi.rcMonitor.Left),
-				MonitorTop:    int(mi.rcMonitor.Top),
-				MonitorRight:  int(mi.rcMonitor.Right),
-				MonitorBottom: int(mi.rcMonitor.Bottom),
-				WorkLeft:      int(mi.rcWork.Left),
-				WorkTop:       int(mi.rcWork.Top),
-				WorkRight:     int(mi.rcWork.Right),
-				WorkBottom:    int(mi.rcWork.Bottom),
-				Primary:       (mi.dwFlags & 1) == 1, // MONITORINFOF_PRIMARY
-			}
-			monitors = append(monitors, m)
-			index++
-		}
-		return 1 // continue enumeration
-	})
-
-	r, _, err := procEnum.Call(0, 0, enumCallback, 0)
-	if r == 0 {
-		logging.Log(true, "API: GetMonitorWorkAreas failed", err)
-		return nil, err
-	}
-	logging.Log(debug, fmt.Sprintf("API: GetMonitorWorkAreas success monitors=%d", len(monitors)))
-	return monitors, nil
-}
-
 // GetWindowState returns current window geometry + detected offsets
 func (a *LaunchRDPApp) GetWindowState() (*WindowState, error) {
 	debug := true
@@ -1025,14 +2095,17 @@ func (a *LaunchRDPApp) Shutdown(ctx context.Context) {
 		close(a.stopTicker)
 	}
 	// Unhook win event if set
-	if a.winEventHook != 0 {
-		user32 := syscall.NewLazyDLL("user32.dll")
-		procUnhookWinEvent := user32.NewProc("UnhookWinEvent")
-		_, _, _ = procUnhookWinEvent.Call(a.winEventHook)
-		logging.Log(true, fmt.Sprintf("Shutdown: unhooked winEventHook=%x", a.winEventHook))
-	}
+	a.stopMoveSizeHook()
 	// Persist in-memory state only (no runtime calls – window may be gone)
 	_ = a.PersistWindowState()
+	if err := a.repository().Close(); err != nil {
+		logging.Log(true, "ERROR: Failed to close storage:", err)
+	}
+}
+
+// userPassword decrypts the stored password of a user for launchers that need it
+func (a *LaunchRDPApp) userPassword(user models.User) (string, error) {
+	return a.cipher.Decrypt(user.EncryptedPassword)
 }
 
 // saveUserAfterMigration - Callback for RDP generator - SAME AS BEFORE!
@@ -1041,24 +2114,16 @@ func (a *LaunchRDPApp) saveUserAfterMigration(user models.User) error {
 	logging.Log(debug, "Saving user after migration:", user.Username)
 
 	// Load users, add/update user, save back
-	users, _ := a.storage.LoadUsers()
-
-	// Check if user exists
-	userIndex := -1
-	for i, u := range users {
-		if u.ID == user.ID {
-			userIndex = i
-			break
+	return a.repository().UpdateUsers(func(users []models.User) ([]models.User, error) {
+		// Check if user exists
+		for i, u := range users {
+			if u.ID == user.ID {
+				// Update existing user
+				users[i] = user
+				return users, nil
+			}
 		}
-	}
-
-	if userIndex >= 0 {
-		// Update existing user
-		users[userIndex] = user
-	} else {
 		// Add new user
-		users = append(users, user)
-	}
-
-	return a.storage.SaveUsers(users)
+		return append(users, user), nil
+	})
 }
@@ -4,11 +4,19 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"runtime"
 )
 
 const (
 	AppName    = "Lancer"
 	SubAppName = "LaunchRDP"
+
+	// HomeEnvVar overrides the directory resolution: config and data go directly
+	// into it, logs and temporary files into its "logs" and "cache" subfolders.
+	HomeEnvVar = "LAUNCHRDP_HOME"
+
+	// PortableFlagFileName next to the executable switches to portable mode
+	PortableFlagFileName = "portable.flag"
 )
 
 var (
@@ -18,25 +26,160 @@ var (
 	TempDir   string
 )
 
+// Directories holds the resolved application directories
+type Directories struct {
+	Config string
+	Data   string
+	Logs   string
+	Temp   string
+}
+
+// portable is set by EnablePortable (--portable command line flag)
+var portable bool
+
+// EnablePortable switches to portable mode, call it before InitDirectories
+func EnablePortable() {
+	portable = true
+}
+
+// IsPortable reports portable mode: enabled by EnablePortable or by a
+// portable.flag file in the executable directory
+func IsPortable() bool {
+	if portable {
+		return true
+	}
+	dir, err := executableDir()
+	if err != nil {
+		return false
+	}
+	_, err = os.Stat(filepath.Join(dir, PortableFlagFileName))
+	return err == nil
+}
+
+// executableDir returns the directory of the running executable (symlinks resolved)
+func executableDir() (string, error) {
+	exe, err := os.Executable()
+	if err != nil {
+		return "", fmt.Errorf("executable path not found: %w", err)
+	}
+	if resolved, err := filepath.EvalSymlinks(exe); err == nil {
+		exe = resolved
+	}
+	return filepath.Dir(exe), nil
+}
+
+// ResolveDirectories returns the application directories without creating them.
+//
+//	LAUNCHRDP_HOME set: <home>, <home>/logs, <home>/cache
+//	Portable mode:      <exe dir>/data, <exe dir>/logs, <exe dir>/temp
+//	Windows:            %APPDATA%\Lancer\LaunchRDP, logs and temp in %LOCALAPPDATA%\Lancer\LaunchRDP
+//	macOS:              ~/Library/Application Support/..., logs and temp in ~/Library/Caches/...
+//	Linux and others:   $XDG_CONFIG_HOME/Lancer/LaunchRDP, logs in $XDG_STATE_HOME/...,
+//	                    temp in $XDG_CACHE_HOME/... (defaults ~/.config, ~/.local/state, ~/.cache)
+func ResolveDirectories() (Directories, error) {
+	portableDir := ""
+	if os.Getenv(HomeEnvVar) == "" && IsPortable() {
+		dir, err := executableDir()
+		if err != nil {
+			return Directories{}, err
+		}
+		portableDir = dir
+	}
+	return resolveDirectories(runtime.GOOS, os.Getenv, os.UserHomeDir, portableDir)
+}
+
+// resolveDirectories implements ResolveDirectories for the given platform and
+// environment; a non-empty portableDir selects portable mode.
+func resolveDirectories(goos string, getenv func(string) string, homeDir func() (string, error), portableDir string) (Directories, error) {
+	if home := getenv(HomeEnvVar); home != "" {
+		if !filepath.IsAbs(home) {
+			return Directories{}, fmt.Errorf("%s must be an absolute path: %s", HomeEnvVar, home)
+		}
+		return Directories{
+			Config: home,
+			Data:   home,
+			Logs:   filepath.Join(home, "logs"),
+			Temp:   filepath.Join(home, "cache"),
+		}, nil
+	}
+
+	if portableDir != "" {
+		return Directories{
+			Config: filepath.Join(portableDir, "data"),
+			Data:   filepath.Join(portableDir, "data"),
+			Logs:   filepath.Join(portableDir, "logs"),
+			Temp:   filepath.Join(portableDir, "temp"),
+		}, nil
+	}
+
+	var configBase, stateBase, cacheBase string
+	switch goos {
+	case "windows":
+		configBase = getenv("APPDATA")
+		if configBase == "" {
+			return Directories{}, fmt.Errorf("APPDATA environment variable not found")
+		}
+		cacheBase = getenv("LOCALAPPDATA")
+		if cacheBase == "" {
+			return Directories{}, fmt.Errorf("LOCALAPPDATA environment variable not found")
+		}
+		stateBase = cacheBase
+	case "darwin":
+		home, err := homeDir()
+		if err != nil {
+			return Directories{}, fmt.Errorf("home directory not found: %w", err)
+		}
+		configBase = filepath.Join(home, "Library", "Application Support")
+		cacheBase = filepath.Join(home, "Library", "Caches")
+		stateBase = cacheBase
+	default:
+		// XDG Base Directory Specification: relative paths are invalid and ignored
+		xdgDir := func(envVar string, fallback ...string) (string, error) {
+			if dir := getenv(envVar); filepath.IsAbs(dir) {
+				return dir, nil
+			}
+			home, err := homeDir()
+			if err != nil {
+				return "", fmt.Errorf("home directory not found: %w", err)
+			}
+			return filepath.Join(append([]string{home}, fallback...)...), nil
+		}
+		var err error
+		if configBase, err = xdgDir("XDG_CONFIG_HOME", ".config"); err != nil {
+			return Directories{}, err
+		}
+		if stateBase, err = xdgDir("XDG_STATE_HOME", ".local", "state"); err != nil {
+			return Directories{}, err
+		}
+		if cacheBase, err = xdgDir("XDG_CACHE_HOME", ".cache"); err != nil {
+			return Directories{}, err
+		}
+	}
+
+	configDir := filepath.Join(configBase, AppName, SubAppName)
+	return Directories{
+		Config: configDir,
+		Data:   configDir,
+		Logs:   filepath.Join(stateBase, AppName, SubAppName),
+		Temp:   filepath.Join(cacheBase, AppName, SubAppName),
+	}, nil
+}
+
 // InitDirectories creates the necessary directories for the application
 func InitDirectories() error {
-	// Get base directories
-	appData := os.Getenv("APPDATA")
-	localAppData := os.Getenv("LOCALAPPDATA")
-
-	if appData == "" || localAppData == "" {
-		return fmt.Errorf("APPDATA or LOCALAPPDATA environment variables not found")
+	dirs, err := ResolveDirectories()
+	if err != nil {
+		return err
 	}
 
 	// Set directory paths
-	ConfigDir = filepath.Join(appData, AppName, SubAppName)
-	DataDir = ConfigDir
-	TempDir = filepath.Join(localAppData, AppName, SubAppName)
-	LogsDir = TempDir
+	ConfigDir = dirs.Config
+	DataDir = dirs.Data
+	LogsDir = dirs.Logs
+	TempDir = dirs.Temp
 
 	// Create directories
-	dirs := []string{ConfigDir, DataDir, LogsDir, TempDir}
-	for _, dir := range dirs {
+	for _, dir := range []string{ConfigDir, DataDir, LogsDir, TempDir} {
 		if err := os.MkdirAll(dir, 0755); err != nil {
 			return fmt.Errorf("failed to create directory %s: %w", dir, err)
 		}
@@ -0,0 +1,214 @@
+package config
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// env returns a getenv function over a fixed environment
+func env(vars map[string]string) func(string) string {
+	return func(name string) string { return vars[name] }
+}
+
+func homeDir(dir string) func() (string, error) {
+	return func() (string, error) { return dir, nil }
+}
+
+func noHomeDir() (string, error) {
+	return "", errors.New("no home")
+}
+
+func TestResolveDirectories(t *testing.T) {
+	// Absolute in the notation of the test platform
+	root := filepath.Join(string(filepath.Separator), "root")
+	home := filepath.Join(root, "home", "alice")
+	launchRDP := filepath.Join(AppName, SubAppName)
+
+	tests := []struct {
+		name     string
+		goos     string
+		env      map[string]string
+		home     func() (string, error)
+		portable string
+		want     Directories
+	}{
+		{
+			name: "windows",
+			goos: "windows",
+			env:  map[string]string{"APPDATA": filepath.Join(root, "Roaming"), "LOCALAPPDATA": filepath.Join(root, "Local")},
+			home: noHomeDir,
+			want: Directories{
+				Config: filepath.Join(root, "Roaming", launchRDP),
+				Data:   filepath.Join(root, "Roaming", launchRDP),
+				Logs:   filepath.Join(root, "Local", launchRDP),
+				Temp:   filepath.Join(root, "Local", launchRDP),
+			},
+		},
+		{
+			name: "darwin",
+			goos: "darwin",
+			home: homeDir(home),
+			want: Directories{
+				Config: filepath.Join(home, "Library", "Application Support", launchRDP),
+				Data:   filepath.Join(home, "Library", "Application Support", launchRDP),
+				Logs:   filepath.Join(home, "Library", "Caches", launchRDP),
+				Temp:   filepath.Join(home, "Library", "Caches", launchRDP),
+			},
+		},
+		{
+			name: "xdg variables",
+			goos: "linux",
+			env: map[string]string{
+				"XDG_CONFIG_HOME": filepath.Join(root, "cfg"),
+				"XDG_STATE_HOME":  filepath.Join(root, "state"),
+				"XDG_CACHE_HOME":  filepath.Join(root, "cache"),
+			},
+			home: noHomeDir,
+			want: Directories{
+				Config: filepath.Join(root, "cfg", launchRDP),
+				Data:   filepath.Join(root, "cfg", launchRDP),
+				Logs:   filepath.Join(root, "state", launchRDP),
+				Temp:   filepath.Join(root, "cache", launchRDP),
+			},
+		},
+		{
+			name: "xdg fallbacks",
+			goos: "linux",
+			home: homeDir(home),
+			want: Directories{
+				Config: filepath.Join(home, ".config", launchRDP),
+				Data:   filepath.Join(home, ".config", launchRDP),
+				Logs:   filepath.Join(home, ".local", "state", launchRDP),
+				Temp:   filepath.Join(home, ".cache", launchRDP),
+			},
+		},
+		{
+			// Relative XDG paths are invalid and fall back to the defaults
+			name: "xdg relative paths ignored",
+			goos: "freebsd",
+			env:  map[string]string{"XDG_CONFIG_HOME": "cfg", "XDG_CACHE_HOME": filepath.Join(root, "cache")},
+			home: homeDir(home),
+			want: Directories{
+				Config: filepath.Join(home, ".config", launchRDP),
+				Data:   filepath.Join(home, ".config", launchRDP),
+				Logs:   filepath.Join(home, ".local", "state", launchRDP),
+				Temp:   filepath.Join(root, "cache", launchRDP),
+			},
+		},
+		{
+			name: "launchrdp home wins over platform and portable mode",
+			goos: "windows",
+			env:  map[string]string{HomeEnvVar: filepath.Join(root, "lrdp"), "APPDATA": filepath.Join(root, "Roaming")},
+			home: noHomeDir,
+			// ResolveDirectories only passes a portable dir without LAUNCHRDP_HOME,
+			// resolveDirectories must still prefer the variable
+			portable: filepath.Join(root, "usb"),
+			want: Directories{
+				Config: filepath.Join(root, "lrdp"),
+				Data:   filepath.Join(root, "lrdp"),
+				Logs:   filepath.Join(root, "lrdp", "logs"),
+				Temp:   filepath.Join(root, "lrdp", "cache"),
+			},
+		},
+		{
+			name:     "portable",
+			goos:     "windows",
+			env:      map[string]string{"APPDATA": filepath.Join(root, "Roaming"), "LOCALAPPDATA": filepath.Join(root, "Local")},
+			home:     noHomeDir,
+			portable: filepath.Join(root, "usb", "LaunchRDP"),
+			want: Directories{
+				Config: filepath.Join(root, "usb", "LaunchRDP", "data"),
+				Data:   filepath.Join(root, "usb", "LaunchRDP", "data"),
+				Logs:   filepath.Join(root, "usb", "LaunchRDP", "logs"),
+				Temp:   filepath.Join(root, "usb", "LaunchRDP", "temp"),
+			},
+		},
+		{
+			name:     "portable on linux",
+			goos:     "linux",
+			home:     noHomeDir,
+			portable: filepath.Join(root, "opt", "launchrdp"),
+			want: Directories{
+				Config: filepath.Join(root, "opt", "launchrdp", "data"),
+				Data:   filepath.Join(root, "opt", "launchrdp", "data"),
+				Logs:   filepath.Join(root, "opt", "launchrdp", "logs"),
+				Temp:   filepath.Join(root, "opt", "launchrdp", "temp"),
+			},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got, err := resolveDirectories(test.goos, env(test.env), test.home, test.portable)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != test.want {
+				t.Errorf("resolveDirectories() =\n%+v\nwant\n%+v", got, test.want)
+			}
+		})
+	}
+}
+
+func TestResolveDirectoriesErrors(t *testing.T) {
+	root := filepath.Join(string(filepath.Separator), "root")
+	tests := []struct {
+		name string
+		goos string
+		env  map[string]string
+		home func() (string, error)
+	}{
+		{"windows without APPDATA", "windows", map[string]string{"LOCALAPPDATA": root}, noHomeDir},
+		{"windows without LOCALAPPDATA", "windows", map[string]string{"APPDATA": root}, noHomeDir},
+		{"darwin without home", "darwin", nil, noHomeDir},
+		{"linux without home for a fallback", "linux", map[string]string{"XDG_CONFIG_HOME": root}, noHomeDir},
+		{"relative launchrdp home", "linux", map[string]string{HomeEnvVar: "relative"}, homeDir(root)},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if dirs, err := resolveDirectories(test.goos, env(test.env), test.home, ""); err == nil {
+				t.Errorf("resolveDirectories() = %+v, want an error", dirs)
+			}
+		})
+	}
+}
+
+func TestResolveDirectoriesHomeEnv(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv(HomeEnvVar, home)
+	dirs, err := ResolveDirectories()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if dirs.Config != home || dirs.Logs != filepath.Join(home, "logs") || dirs.Temp != filepath.Join(home, "cache") {
+		t.Errorf("ResolveDirectories() = %+v", dirs)
+	}
+}
+
+func TestInitDirectoriesCreatesDirectories(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv(HomeEnvVar, home)
+	previous := Directories{Config: ConfigDir, Data: DataDir, Logs: LogsDir, Temp: TempDir}
+	t.Cleanup(func() {
+		ConfigDir, DataDir, LogsDir, TempDir = previous.Config, previous.Data, previous.Logs, previous.Temp
+	})
+
+	if err := InitDirectories(); err != nil {
+		t.Fatal(err)
+	}
+	if GetConfigPath("hosts.json") != filepath.Join(home, "hosts.json") {
+		t.Errorf("GetConfigPath() = %s", GetConfigPath("hosts.json"))
+	}
+	if GetTempPath("a.rdp") != filepath.Join(home, "cache", "a.rdp") {
+		t.Errorf("GetTempPath() = %s", GetTempPath("a.rdp"))
+	}
+	if GetLogPath("log.txt") != filepath.Join(home, "logs", "log.txt") {
+		t.Errorf("GetLogPath() = %s", GetLogPath("log.txt"))
+	}
+	for _, dir := range []string{ConfigDir, LogsDir, TempDir} {
+		if info, err := os.Stat(dir); err != nil || !info.IsDir() {
+			t.Errorf("%s was not created: %v", dir, err)
+		}
+	}
+}
@@ -0,0 +1,138 @@
+package credentials
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/chrilep/LaunchRDP/app/models"
+)
+
+// Conflict is a credential target shared by hosts with different users. Only
+// one credential can be stored per target, so all but one host would log in
+// with the wrong account.
+type Conflict struct {
+	Target    string   `json:"target"`
+	HostIDs   []string `json:"hostIds"`
+	HostNames []string `json:"hostNames"`
+	Usernames []string `json:"usernames"` // distinct usernames, as stored in the credential
+}
+
+// ConflictError is returned for changes that would overwrite the credential of
+// another host
+type ConflictError struct {
+	Target   string
+	HostName string // the other host
+	Username string // the other host's user
+}
+
+func (e *ConflictError) Error() string {
+	return fmt.Sprintf("host %q stores its credential for %s with user %s - set a credential alias for one of the hosts",
+		e.HostName, e.Target, e.Username)
+}
+
+// hostUsername returns the username the credential of a host is (or would be)
+// stored with, empty for hosts without a known user. Users without a password
+// count as well: mstsc would log them in with the credential of another user.
+func hostUsername(host models.Host, usersByID map[string]models.User) string {
+	user, ok := usersByID[host.UserID]
+	if !ok {
+		return ""
+	}
+	return QualifiedUsername(host.CredentialTarget(), user.Username)
+}
+
+func indexUsers(users []models.User) map[string]models.User {
+	usersByID := make(map[string]models.User, len(users))
+	for _, user := range users {
+		usersByID[user.ID] = user
+	}
+	return usersByID
+}
+
+// FindConflicts returns the targets used by hosts with different users, sorted by target
+func FindConflicts(hosts []models.Host, users []models.User) []Conflict {
+	usersByID := indexUsers(users)
+	byTarget := map[string][]models.Host{}
+	for _, host := range hosts {
+		if hostUsername(host, usersByID) == "" {
+			continue
+		}
+		key := strings.ToLower(host.CredentialTarget())
+		byTarget[key] = append(byTarget[key], host)
+	}
+
+	conflicts := []Conflict{}
+	for _, shared := range byTarget {
+		conflict := Conflict{Target: shared[0].CredentialTarget()}
+		seen := map[string]bool{}
+		for _, host := range shared {
+			conflict.HostIDs = append(conflict.HostIDs, host.ID)
+			conflict.HostNames = append(conflict.HostNames, host.Name)
+			username := hostUsername(host, usersByID)
+			if !seen[strings.ToLower(username)] {
+				seen[strings.ToLower(username)] = true
+				conflict.Usernames = append(conflict.Usernames, username)
+			}
+		}
+		if len(conflict.Usernames) > 1 {
+			conflicts = append(conflicts, conflict)
+		}
+	}
+	sort.Slice(conflicts, func(i, j int) bool { return conflicts[i].Target < conflicts[j].Target })
+	return conflicts
+}
+
+// CheckHost returns a *ConflictError if storing the credential of host (with its
+// new target and user) would overwrite the credential of another host
+func CheckHost(hosts []models.Host, users []models.User, host models.Host) error {
+	usersByID := indexUsers(users)
+	username := hostUsername(host, usersByID)
+	if username == "" {
+		return nil
+	}
+	for _, other := range hosts {
+		if other.ID == host.ID || !strings.EqualFold(other.CredentialTarget(), host.CredentialTarget()) {
+			continue
+		}
+		if otherUsername := hostUsername(other, usersByID); otherUsername != "" && !strings.EqualFold(otherUsername, username) {
+			return &ConflictError{Target: host.CredentialTarget(), HostName: other.Name, Username: otherUsername}
+		}
+	}
+	return nil
+}
+
+// TargetInUse reports whether a host other than the excluded ones still needs
+// the credential of target
+func TargetInUse(hosts []models.Host, target string, excludeHostIDs ...string) bool {
+	for _, host := range hosts {
+		excluded := false
+		for _, id := range excludeHostIDs {
+			if host.ID == id {
+				excluded = true
+				break
+			}
+		}
+		if !excluded && strings.EqualFold(host.CredentialTarget(), target) {
+			return true
+		}
+	}
+	return false
+}
+
+// ValidateAlias checks a credential alias: a host name or "host:port", without
+// the TERMSRV/ prefix
+func ValidateAlias(alias string) error {
+	if alias == "" {
+		return nil
+	}
+	if strings.ContainsAny(alias, " \t/\\") {
+		return fmt.Errorf("invalid credential alias %q: use a host name or host:port", alias)
+	}
+	if name, port, found := strings.Cut(alias, ":"); found {
+		if name == "" || port == "" || strings.Trim(port, "0123456789") != "" {
+			return fmt.Errorf("invalid credential alias %q: use a host name or host:port", alias)
+		}
+	}
+	return nil
+}
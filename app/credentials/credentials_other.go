@@ -0,0 +1,28 @@
+//go:build !windows
+
+package credentials
+
+import (
+	"github.com/chrilep/LaunchRDP/app/config"
+	"github.com/chrilep/LaunchRDP/app/logging"
+)
+
+// NewPlatformCipher returns the cipher for users.json: the file keyring, as
+// there is no DPAPI off Windows
+func NewPlatformCipher() SecretCipher {
+	return NewKeyringCipher(config.GetConfigPath(KeyringFileName))
+}
+
+// NewPlatformStore returns the system keyring (Secret Service on Linux) if it is
+// available, the encrypted-file keyring otherwise. FreeRDP receives the password
+// on stdin at launch, the store only keeps the credentials in sync with users.json.
+func NewPlatformStore(cipher SecretCipher) CredentialStore {
+	debug := false
+	store, err := newSystemStore()
+	if err == nil {
+		logging.Log(debug, "Using system keyring", store.Name(), "for credentials")
+		return store
+	}
+	logging.Log(debug, "No system keyring, using the encrypted credentials file:", err)
+	return NewFileStore(config.GetConfigPath(CredentialsFileName), cipher)
+}
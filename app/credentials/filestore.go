@@ -0,0 +1,153 @@
+package credentials
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/chrilep/LaunchRDP/app/fileutil"
+	"github.com/chrilep/LaunchRDP/app/logging"
+)
+
+// CredentialsFileName is the file of the encrypted-file keyring (FileStore)
+const CredentialsFileName = "credentials.json"
+
+// FileStore keeps TERMSRV credentials in a JSON file, the passwords encrypted
+// with a SecretCipher. It is the store of platforms without a system keyring.
+type FileStore struct {
+	path   string
+	cipher SecretCipher
+	mu     sync.Mutex
+}
+
+// fileCredential is an entry of CredentialsFileName
+type fileCredential struct {
+	Target            string    `json:"target"`
+	Username          string    `json:"username"`
+	EncryptedPassword string    `json:"encrypted_password"`
+	Written           time.Time `json:"written"`
+}
+
+// NewFileStore creates a store in the file at path
+func NewFileStore(path string, cipher SecretCipher) *FileStore {
+	return &FileStore{path: path, cipher: cipher}
+}
+
+// Name returns "file"
+func (f *FileStore) Name() string {
+	return "file"
+}
+
+// Store creates or replaces the credential of a target
+func (f *FileStore) Store(target, username, password string) error {
+	if err := validateCredential(target, username, password); err != nil {
+		return err
+	}
+	encrypted, err := f.cipher.Encrypt(password)
+	if err != nil {
+		return fmt.Errorf("failed to encrypt password: %w", err)
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	entries, err := f.read()
+	if err != nil {
+		return err
+	}
+	entries[target] = fileCredential{
+		Target:            target,
+		Username:          QualifiedUsername(target, username),
+		EncryptedPassword: encrypted,
+		Written:           time.Now(),
+	}
+	return f.write(entries)
+}
+
+// Delete removes the credential of a target
+func (f *FileStore) Delete(target string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	entries, err := f.read()
+	if err != nil {
+		return err
+	}
+	if _, ok := entries[target]; !ok {
+		return fmt.Errorf("failed to delete credential: %s not found", target)
+	}
+	delete(entries, target)
+	return f.write(entries)
+}
+
+// List returns all credentials, sorted by target
+func (f *FileStore) List() ([]Credential, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	entries, err := f.read()
+	if err != nil {
+		return nil, err
+	}
+	list := make([]Credential, 0, len(entries))
+	for _, entry := range entries {
+		list = append(list, Credential{Target: entry.Target, Username: entry.Username, Written: entry.Written, Managed: true})
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Target < list[j].Target })
+	return list, nil
+}
+
+// Password decrypts the stored password of a target, for launchers that pass
+// it to the RDP client themselves
+func (f *FileStore) Password(target string) (string, error) {
+	f.mu.Lock()
+	entries, err := f.read()
+	f.mu.Unlock()
+	if err != nil {
+		return "", err
+	}
+	entry, ok := entries[target]
+	if !ok {
+		return "", fmt.Errorf("no credential for %s", target)
+	}
+	return f.cipher.Decrypt(entry.EncryptedPassword)
+}
+
+func (f *FileStore) read() (map[string]fileCredential, error) {
+	entries := map[string]fileCredential{}
+	data, err := os.ReadFile(f.path)
+	if os.IsNotExist(err) {
+		return entries, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read credentials file: %w", err)
+	}
+	var list []fileCredential
+	if err := json.Unmarshal(data, &list); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal credentials file: %w", err)
+	}
+	for _, entry := range list {
+		entries[entry.Target] = entry
+	}
+	return entries, nil
+}
+
+// write replaces the file atomically, so a crash never leaves it half written
+func (f *FileStore) write(entries map[string]fileCredential) error {
+	debug := false
+	list := make([]fileCredential, 0, len(entries))
+	for _, entry := range entries {
+		list = append(list, entry)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Target < list[j].Target })
+
+	data, err := json.MarshalIndent(list, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal credentials: %w", err)
+	}
+	if err := fileutil.WriteFileAtomic(f.path, data, 0600); err != nil {
+		return fmt.Errorf("failed to write credentials file: %w", err)
+	}
+	logging.Log(debug, "Saved", len(list), "credentials to", f.path)
+	return nil
+}
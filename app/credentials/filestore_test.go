@@ -0,0 +1,58 @@
+package credentials
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+func TestFileStore(t *testing.T) {
+	cipher, err := NewMemoryCipher()
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(t.TempDir(), CredentialsFileName)
+	store := NewFileStore(path, cipher)
+
+	if err := store.Store("srv.example.com", `CORP\alice`, "s3cret"); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.Store("db.example.com", "bob", "pw"); err != nil {
+		t.Fatal(err)
+	}
+	if password, err := store.Password("srv.example.com"); err != nil || password != "s3cret" {
+		t.Errorf("Password() = %q, %v", password, err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(data), "s3cret") {
+		t.Error("password stored in plain text")
+	}
+	if runtime.GOOS != "windows" {
+		if info, _ := os.Stat(path); info.Mode().Perm() != 0600 {
+			t.Errorf("permissions %v, want 0600", info.Mode().Perm())
+		}
+	}
+
+	if err := store.Delete("db.example.com"); err != nil {
+		t.Fatal(err)
+	}
+	list, err := store.List()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(list) != 1 || list[0].Target != "srv.example.com" || !list[0].Managed {
+		t.Errorf("List() = %+v", list)
+	}
+	if err := store.Delete("db.example.com"); err == nil {
+		t.Error("deleted a missing credential")
+	}
+	// Only the credentials file, no temp files
+	if entries, _ := os.ReadDir(filepath.Dir(path)); len(entries) != 1 {
+		t.Errorf("files left: %v", entries)
+	}
+}
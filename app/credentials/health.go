@@ -0,0 +1,174 @@
+package credentials
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/chrilep/LaunchRDP/app/models"
+)
+
+// Credential states of a host, see VerifyHosts
+const (
+	StatusOK         = "ok"
+	StatusMissing    = "missing"    // the store has no credential for the host
+	StatusStale      = "stale"      // written before the user's password last changed
+	StatusMismatch   = "mismatched" // the credential belongs to another user
+	StatusNoPassword = "no_password"
+	StatusNoUser     = "no_user"  // the host's user doesn't exist
+	StatusConflict   = "conflict" // hosts with different users share the target, see FindConflicts
+)
+
+// HostCredential is the credential state of one host
+type HostCredential struct {
+	HostID   string    `json:"hostId"`
+	HostName string    `json:"hostName"`
+	Target   string    `json:"target"`
+	UserID   string    `json:"userId"`
+	Expected string    `json:"expected"` // username the credential should have
+	Stored   string    `json:"stored"`   // username of the stored credential, empty if missing
+	Written  time.Time `json:"written"`
+	Status   string    `json:"status"`
+}
+
+// Repairable reports whether RepairHosts can fix the state
+func (h HostCredential) Repairable() bool {
+	return h.Status == StatusMissing || h.Status == StatusStale || h.Status == StatusMismatch
+}
+
+// HealthReport compares a CredentialStore with hosts.json and users.json
+type HealthReport struct {
+	Store    string           `json:"store"`
+	Hosts    []HostCredential `json:"hosts"`
+	Orphaned []Credential     `json:"orphaned"` // written by LaunchRDP, no host uses the target
+	Issues   int              `json:"issues"`   // hosts that are not ok, plus orphaned credentials
+}
+
+// VerifyHosts computes the credential state of every host from the stored
+// credentials. It only reads, see RepairHosts.
+func VerifyHosts(hosts []models.Host, users []models.User, stored []Credential) HealthReport {
+	byTarget := make(map[string]Credential, len(stored))
+	for _, credential := range stored {
+		byTarget[strings.ToLower(credential.Target)] = credential
+	}
+	usersByID := indexUsers(users)
+
+	conflicting := map[string]bool{}
+	for _, conflict := range FindConflicts(hosts, users) {
+		for _, id := range conflict.HostIDs {
+			conflicting[id] = true
+		}
+	}
+
+	report := HealthReport{Hosts: make([]HostCredential, 0, len(hosts)), Orphaned: []Credential{}}
+	used := map[string]bool{}
+	for _, host := range hosts {
+		target := host.CredentialTarget()
+		used[strings.ToLower(target)] = true
+		state := HostCredential{HostID: host.ID, HostName: host.Name, Target: target, UserID: host.UserID}
+
+		credential, found := byTarget[strings.ToLower(target)]
+		if found {
+			state.Stored = credential.Username
+			state.Written = credential.Written
+		}
+		user, userFound := usersByID[host.UserID]
+		switch {
+		case !userFound:
+			state.Status = StatusNoUser
+		case conflicting[host.ID]:
+			state.Expected = QualifiedUsername(target, user.Username)
+			state.Status = StatusConflict
+		case user.EncryptedPassword == "":
+			state.Status = StatusNoPassword
+		case !found:
+			state.Expected = QualifiedUsername(target, user.Username)
+			state.Status = StatusMissing
+		default:
+			state.Expected = QualifiedUsername(target, user.Username)
+			switch {
+			case !strings.EqualFold(credential.Username, state.Expected):
+				state.Status = StatusMismatch
+			case !credential.Written.IsZero() && credential.Written.Before(user.PasswordChangedAt):
+				state.Status = StatusStale
+			default:
+				state.Status = StatusOK
+			}
+		}
+		if state.Status != StatusOK && state.Status != StatusNoPassword {
+			report.Issues++
+		}
+		report.Hosts = append(report.Hosts, state)
+	}
+
+	for _, credential := range stored {
+		if credential.Managed && !used[strings.ToLower(credential.Target)] {
+			report.Orphaned = append(report.Orphaned, credential)
+			report.Issues++
+		}
+	}
+	sort.Slice(report.Orphaned, func(i, j int) bool { return report.Orphaned[i].Target < report.Orphaned[j].Target })
+	return report
+}
+
+// RepairResult lists what RepairHosts did
+type RepairResult struct {
+	Repaired []string `json:"repaired"` // targets written
+	Failed   []string `json:"failed"`   // "target: error"
+}
+
+// RepairHosts rewrites the missing, stale and mismatched credentials of a report
+// from the encrypted passwords in users.json. Every target is written once.
+func RepairHosts(store CredentialStore, cipher SecretCipher, report HealthReport, users []models.User) RepairResult {
+	usersByID := indexUsers(users)
+
+	result := RepairResult{Repaired: []string{}, Failed: []string{}}
+	done := map[string]bool{}
+	for _, state := range report.Hosts {
+		key := strings.ToLower(state.Target)
+		if !state.Repairable() || done[key] {
+			continue
+		}
+		done[key] = true
+		user := usersByID[state.UserID]
+		password, err := cipher.Decrypt(user.EncryptedPassword)
+		if err == nil {
+			err = store.Store(state.Target, user.Username, password)
+		}
+		password = ""
+		if err != nil {
+			result.Failed = append(result.Failed, state.Target+": "+err.Error())
+			continue
+		}
+		result.Repaired = append(result.Repaired, state.Target)
+	}
+	return result
+}
+
+// PurgeResult lists what PurgeOrphaned did
+type PurgeResult struct {
+	Deleted []string `json:"deleted"` // targets deleted
+	Failed  []string `json:"failed"`  // "target: error"
+}
+
+// PurgeOrphaned deletes the credentials written by LaunchRDP whose target no
+// host uses anymore. Credentials mstsc saved itself are kept.
+func PurgeOrphaned(store CredentialStore, hosts []models.Host) (PurgeResult, error) {
+	stored, err := store.List()
+	if err != nil {
+		return PurgeResult{}, fmt.Errorf("failed to list credentials: %w", err)
+	}
+	result := PurgeResult{Deleted: []string{}, Failed: []string{}}
+	for _, credential := range stored {
+		if !credential.Managed || TargetInUse(hosts, credential.Target) {
+			continue
+		}
+		if err := store.Delete(credential.Target); err != nil {
+			result.Failed = append(result.Failed, credential.Target+": "+err.Error())
+			continue
+		}
+		result.Deleted = append(result.Deleted, credential.Target)
+	}
+	return result, nil
+}
@@ -0,0 +1,270 @@
+package credentials
+
+import (
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/chrilep/LaunchRDP/app/models"
+)
+
+func targets(t *testing.T, store CredentialStore) []string {
+	t.Helper()
+	list, err := store.List()
+	if err != nil {
+		t.Fatal(err)
+	}
+	names := make([]string, len(list))
+	for i, credential := range list {
+		names[i] = credential.Target
+	}
+	return names
+}
+
+func TestPurgeOrphaned(t *testing.T) {
+	store := NewMemoryStore()
+	for _, target := range []string{"used.example.com", "alias", "gone.example.com"} {
+		if err := store.Store(target, "alice", "pw"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	store.StoreUnmanaged("mstsc.example.com", "bob")
+
+	used := models.NewHost("Used", "USED.example.com", 3389, "")
+	aliased := models.NewHost("Aliased", "aliased.example.com", 3389, "")
+	aliased.CredentialAlias = "alias"
+
+	result, err := PurgeOrphaned(store, []models.Host{used, aliased})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !slices.Equal(result.Deleted, []string{"gone.example.com"}) || len(result.Failed) != 0 {
+		t.Errorf("result = %+v, want only gone.example.com deleted", result)
+	}
+	want := []string{"alias", "mstsc.example.com", "used.example.com"}
+	if got := targets(t, store); !slices.Equal(got, want) {
+		t.Errorf("left %v, want %v", got, want)
+	}
+
+	// Without hosts only the credentials LaunchRDP wrote are removed
+	if _, err := PurgeOrphaned(store, nil); err != nil {
+		t.Fatal(err)
+	}
+	if got := targets(t, store); !slices.Equal(got, []string{"mstsc.example.com"}) {
+		t.Errorf("left %v, want the mstsc credential", got)
+	}
+}
+
+func TestVerifyHostsOrphaned(t *testing.T) {
+	store := NewMemoryStore()
+	if err := store.Store("gone.example.com", "alice", "pw"); err != nil {
+		t.Fatal(err)
+	}
+	store.StoreUnmanaged("mstsc.example.com", "bob")
+	stored, _ := store.List()
+
+	report := VerifyHosts(nil, nil, stored)
+	if len(report.Orphaned) != 1 || report.Orphaned[0].Target != "gone.example.com" || report.Issues != 1 {
+		t.Errorf("report = %+v, want only the managed credential orphaned", report)
+	}
+}
+
+// healthFixture is a store with hosts in every credential state
+type healthFixture struct {
+	store  *MemoryStore
+	cipher *MemoryCipher
+	hosts  []models.Host
+	users  []models.User
+}
+
+func newHealthFixture(t *testing.T) healthFixture {
+	t.Helper()
+	f := healthFixture{store: NewMemoryStore(), cipher: newTestCipher(t)}
+	user := func(name, password string) models.User {
+		user := models.NewUser(name, name)
+		user.ID = name
+		if password != "" {
+			encrypted, err := f.cipher.Encrypt(password)
+			if err != nil {
+				t.Fatal(err)
+			}
+			user.EncryptedPassword = encrypted
+			user.PasswordChangedAt = time.Now().Add(-time.Hour)
+		}
+		return user
+	}
+	host := func(name, address, userID string) {
+		host := models.NewHost(name, address, 3389, userID)
+		host.ID = name
+		f.hosts = append(f.hosts, host)
+	}
+	store := func(target, username string) {
+		if err := f.store.Store(target, username, "old-pw"); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	alice, bob, carol := user("alice", "alice-pw"), user("bob", "bob-pw"), user("carol", "carol-pw")
+	dave, eve, mallory := user("dave", "dave-pw"), user("eve", ""), user("mallory", "mallory-pw")
+	// Not decryptable, e.g. from another machine
+	mallory.EncryptedPassword = "AAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAA"
+	f.users = []models.User{alice, bob, carol, dave, eve, mallory}
+
+	host("ok", "ok.example.com", "alice")
+	store("ok.example.com", "alice")
+	host("missing", "missing.example.com", "bob")
+	// Two hosts of one user on a target are repaired once
+	host("missing-too", "MISSING.example.com", "bob")
+	host("stale", "stale.example.com", "carol")
+	store("stale.example.com", "carol")
+	// Changed right after the credential was written
+	stored, err := f.store.List()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, credential := range stored {
+		if credential.Target == "stale.example.com" {
+			f.users[2].PasswordChangedAt = credential.Written.Add(time.Nanosecond)
+		}
+	}
+	host("mismatched", "mismatched.example.com", "dave")
+	store("mismatched.example.com", "alice")
+	host("no-password", "eve.example.com", "eve")
+	host("no-user", "deleted.example.com", "deleted")
+	host("undecryptable", "mallory.example.com", "mallory")
+	host("conflict-a", "shared.example.com", "alice")
+	host("conflict-b", "shared.example.com", "bob")
+	// A user without password would log in with the other user's credential
+	host("conflict-c", "shared2.example.com", "alice")
+	host("conflict-d", "shared2.example.com", "eve")
+	return f
+}
+
+func (f healthFixture) verify(t *testing.T) HealthReport {
+	t.Helper()
+	stored, err := f.store.List()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return VerifyHosts(f.hosts, f.users, stored)
+}
+
+func statuses(report HealthReport) map[string]string {
+	byHost := make(map[string]string, len(report.Hosts))
+	for _, state := range report.Hosts {
+		byHost[state.HostID] = state.Status
+	}
+	return byHost
+}
+
+func TestVerifyHosts(t *testing.T) {
+	f := newHealthFixture(t)
+	report := f.verify(t)
+
+	want := map[string]string{
+		"ok":            StatusOK,
+		"missing":       StatusMissing,
+		"missing-too":   StatusMissing,
+		"stale":         StatusStale,
+		"mismatched":    StatusMismatch,
+		"no-password":   StatusNoPassword,
+		"no-user":       StatusNoUser,
+		"undecryptable": StatusMissing,
+		"conflict-a":    StatusConflict,
+		"conflict-b":    StatusConflict,
+		"conflict-c":    StatusConflict,
+		"conflict-d":    StatusConflict,
+	}
+	got := statuses(report)
+	for hostID, status := range want {
+		if got[hostID] != status {
+			t.Errorf("host %s: status %q, want %q", hostID, got[hostID], status)
+		}
+	}
+	// Every state but ok and no password is an issue
+	if report.Issues != 10 {
+		t.Errorf("%d issues, want 10", report.Issues)
+	}
+	for _, state := range report.Hosts {
+		switch state.HostID {
+		case "mismatched":
+			if state.Expected != `mismatched.example.com\dave` || state.Stored != `mismatched.example.com\alice` {
+				t.Errorf("mismatched expected %q, stored %q", state.Expected, state.Stored)
+			}
+		case "missing":
+			if state.Stored != "" || !state.Written.IsZero() {
+				t.Errorf("missing host has stored %q written %v", state.Stored, state.Written)
+			}
+		}
+	}
+}
+
+func TestRepairHosts(t *testing.T) {
+	f := newHealthFixture(t)
+	result := RepairHosts(f.store, f.cipher, f.verify(t), f.users)
+
+	repaired := slices.Clone(result.Repaired)
+	slices.Sort(repaired)
+	if want := []string{"mismatched.example.com", "missing.example.com", "stale.example.com"}; !slices.Equal(repaired, want) {
+		t.Errorf("repaired %v, want %v", repaired, want)
+	}
+	if len(result.Failed) != 1 || !strings.HasPrefix(result.Failed[0], "mallory.example.com: ") {
+		t.Errorf("failed %v, want the undecryptable password", result.Failed)
+	}
+	for target, want := range map[string]string{
+		"missing.example.com":    "bob-pw",
+		"stale.example.com":      "carol-pw",
+		"mismatched.example.com": "dave-pw",
+		"ok.example.com":         "old-pw", // left alone
+	} {
+		if password, _ := f.store.Password(target); password != want {
+			t.Errorf("password of %s = %q, want %q", target, password, want)
+		}
+	}
+	// Conflicts need a decision of the user, they are not repaired
+	if _, ok := f.store.Password("shared.example.com"); ok {
+		t.Error("conflicting target written")
+	}
+
+	// Afterwards only the states RepairHosts cannot fix remain
+	got := statuses(f.verify(t))
+	for _, hostID := range []string{"ok", "missing", "missing-too", "stale", "mismatched"} {
+		if got[hostID] != StatusOK {
+			t.Errorf("host %s after repair: %q", hostID, got[hostID])
+		}
+	}
+	if got["undecryptable"] != StatusMissing || got["conflict-a"] != StatusConflict || got["no-user"] != StatusNoUser {
+		t.Errorf("statuses after repair %v", got)
+	}
+}
+
+func TestTargetInUse(t *testing.T) {
+	a := models.NewHost("A", "srv.example.com", 3389, "")
+	b := models.NewHost("B", "srv.example.com", 3389, "")
+	c := models.NewHost("C", "other.example.com", 3389, "")
+	c.CredentialAlias = "srv.example.com"
+	hosts := []models.Host{a, b, c}
+
+	tests := []struct {
+		name    string
+		target  string
+		exclude []string
+		want    bool
+	}{
+		{"used", "srv.example.com", nil, true},
+		{"case insensitive", "SRV.example.com", nil, true},
+		{"one of two excluded", "srv.example.com", []string{a.ID}, true},
+		{"alias still uses it", "srv.example.com", []string{a.ID, b.ID}, true},
+		{"all excluded", "srv.example.com", []string{a.ID, b.ID, c.ID}, false},
+		// C connects with the alias, its address is not a target
+		{"address of an aliased host", "other.example.com", nil, false},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := TargetInUse(hosts, test.target, test.exclude...); got != test.want {
+				t.Errorf("TargetInUse() = %v, want %v", got, test.want)
+			}
+		})
+	}
+}
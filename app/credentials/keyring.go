@@ -0,0 +1,110 @@
+package credentials
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"fmt"
+	"os"
+	"sync"
+
+	"github.com/chrilep/LaunchRDP/app/logging"
+)
+
+// KeyringFileName holds the key of the file keyring. Like DPAPI it ties stored
+// passwords to the current user: the file is only readable by its owner.
+const KeyringFileName = "keyring.key"
+
+// KeyringCipher encrypts passwords with AES-256-GCM and a random key kept in a
+// file. It replaces DPAPI on platforms without it.
+type KeyringCipher struct {
+	path string
+	mu   sync.Mutex
+	gcm  cipher.AEAD
+}
+
+// NewKeyringCipher creates the cipher, the key file at path is created on first use
+func NewKeyringCipher(path string) *KeyringCipher {
+	return &KeyringCipher{path: path}
+}
+
+// Name returns "keyring"
+func (k *KeyringCipher) Name() string {
+	return "keyring"
+}
+
+// Encrypt encrypts a password, the result is base64 of nonce + ciphertext
+func (k *KeyringCipher) Encrypt(password string) (string, error) {
+	if password == "" {
+		return "", nil
+	}
+	gcm, err := k.cipher()
+	if err != nil {
+		return "", err
+	}
+	return seal(gcm, password)
+}
+
+// Decrypt decrypts a password encrypted by Encrypt
+func (k *KeyringCipher) Decrypt(encryptedPassword string) (string, error) {
+	if encryptedPassword == "" {
+		return "", nil
+	}
+	gcm, err := k.cipher()
+	if err != nil {
+		return "", err
+	}
+	plain, err := openSealed(gcm, encryptedPassword)
+	if err != nil {
+		return "", fmt.Errorf("failed to decrypt password (keyring changed or data from another machine): %w", err)
+	}
+	return plain, nil
+}
+
+// cipher loads the keyring key, creating it on first use
+func (k *KeyringCipher) cipher() (cipher.AEAD, error) {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	if k.gcm == nil {
+		gcm, err := loadKeyring(k.path)
+		if err != nil {
+			return nil, err
+		}
+		k.gcm = gcm
+	}
+	return k.gcm, nil
+}
+
+func loadKeyring(path string) (cipher.AEAD, error) {
+	key, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		key = make([]byte, 32)
+		if _, err := rand.Read(key); err != nil {
+			return nil, fmt.Errorf("failed to create keyring key: %w", err)
+		}
+		// O_EXCL: never overwrite a key that another instance just created
+		file, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0600)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create keyring file: %w", err)
+		}
+		_, writeErr := file.Write(key)
+		if err := file.Close(); writeErr == nil {
+			writeErr = err
+		}
+		if writeErr != nil {
+			return nil, fmt.Errorf("failed to write keyring file: %w", writeErr)
+		}
+		logging.Log(true, "Created keyring file", path)
+	} else if err != nil {
+		return nil, fmt.Errorf("failed to read keyring file: %w", err)
+	}
+	if len(key) != 32 {
+		return nil, fmt.Errorf("invalid keyring file %s", path)
+	}
+
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create cipher: %w", err)
+	}
+	return cipher.NewGCM(block)
+}
@@ -0,0 +1,132 @@
+package credentials
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+)
+
+// MemoryCipher encrypts with a random key that only lives in memory. Data
+// encrypted by it is lost with the process, it is meant for tests.
+type MemoryCipher struct {
+	gcm cipher.AEAD
+}
+
+// NewMemoryCipher creates a cipher with a new random key
+func NewMemoryCipher() (*MemoryCipher, error) {
+	key := make([]byte, 32)
+	if _, err := rand.Read(key); err != nil {
+		return nil, fmt.Errorf("failed to create key: %w", err)
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create cipher: %w", err)
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create cipher: %w", err)
+	}
+	return &MemoryCipher{gcm: gcm}, nil
+}
+
+// Name returns "memory"
+func (m *MemoryCipher) Name() string {
+	return "memory"
+}
+
+// Encrypt encrypts a password, the result is base64 of nonce + ciphertext
+func (m *MemoryCipher) Encrypt(password string) (string, error) {
+	if password == "" {
+		return "", nil
+	}
+	return seal(m.gcm, password)
+}
+
+// Decrypt decrypts a password encrypted by Encrypt
+func (m *MemoryCipher) Decrypt(encryptedPassword string) (string, error) {
+	if encryptedPassword == "" {
+		return "", nil
+	}
+	return openSealed(m.gcm, encryptedPassword)
+}
+
+// MemoryStore is a CredentialStore in memory, a fake for tests. Unlike the
+// real stores it keeps the passwords readable, see Password.
+type MemoryStore struct {
+	mu          sync.Mutex
+	credentials map[string]memoryCredential
+}
+
+type memoryCredential struct {
+	Credential
+	password string
+}
+
+// NewMemoryStore creates an empty store
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{credentials: map[string]memoryCredential{}}
+}
+
+// Name returns "memory"
+func (m *MemoryStore) Name() string {
+	return "memory"
+}
+
+// Store creates or replaces the credential of a target
+func (m *MemoryStore) Store(target, username, password string) error {
+	if err := validateCredential(target, username, password); err != nil {
+		return err
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.credentials[target] = memoryCredential{
+		Credential: Credential{Target: target, Username: QualifiedUsername(target, username), Written: time.Now(), Managed: true},
+		password:   password,
+	}
+	return nil
+}
+
+// StoreUnmanaged adds a credential that LaunchRDP didn't write, like one saved
+// by mstsc itself
+func (m *MemoryStore) StoreUnmanaged(target, username string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.credentials[target] = memoryCredential{
+		Credential: Credential{Target: target, Username: username, Written: time.Now()},
+	}
+}
+
+// Delete removes the credential of a target
+func (m *MemoryStore) Delete(target string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if _, ok := m.credentials[target]; !ok {
+		return fmt.Errorf("failed to delete credential: %s not found", target)
+	}
+	delete(m.credentials, target)
+	return nil
+}
+
+// List returns all credentials, sorted by target
+func (m *MemoryStore) List() ([]Credential, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	list := make([]Credential, 0, len(m.credentials))
+	for _, credential := range m.credentials {
+		list = append(list, credential.Credential)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Target < list[j].Target })
+	return list, nil
+}
+
+// Password returns the stored password of a target, false if there is none
+func (m *MemoryStore) Password(target string) (string, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	credential, ok := m.credentials[target]
+	return credential.password, ok
+}
@@ -0,0 +1,298 @@
+package credentials
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+
+	"golang.org/x/crypto/scrypt"
+
+	"github.com/chrilep/LaunchRDP/app/fileutil"
+	"github.com/chrilep/LaunchRDP/app/logging"
+)
+
+// PassphraseFileName holds salt and check value of the passphrase store
+const PassphraseFileName = "passphrase.json"
+
+// ErrLocked is returned while the passphrase store has not been unlocked
+var ErrLocked = errors.New("credential store is locked, enter the passphrase first")
+
+// ErrWrongPassphrase is returned by Unlock for a passphrase that does not match
+var ErrWrongPassphrase = errors.New("wrong passphrase")
+
+// scrypt parameters (N=2^15, r=8, p=1 as recommended for interactive logins)
+const (
+	scryptN      = 32768
+	scryptR      = 8
+	scryptP      = 1
+	scryptKeyLen = 32
+)
+
+// passphraseCheck is encrypted with the derived key to detect wrong passphrases
+const passphraseCheck = "LaunchRDP passphrase check"
+
+// passphraseFile is the JSON content of PassphraseFileName
+type passphraseFile struct {
+	Salt  string `json:"salt"`
+	Check string `json:"check"`
+}
+
+// PassphraseStore encrypts passwords with a key derived from a passphrase
+// (scrypt + AES-256-GCM) instead of machine-bound DPAPI. It is used in portable
+// mode, where config files move between machines. The key only lives in memory.
+type PassphraseStore struct {
+	path string
+	mu   sync.RWMutex
+	gcm  cipher.AEAD
+}
+
+// NewPassphraseStore creates a locked store with its salt file at path
+func NewPassphraseStore(path string) *PassphraseStore {
+	return &PassphraseStore{path: path}
+}
+
+// Name returns "passphrase"
+func (s *PassphraseStore) Name() string {
+	return "passphrase"
+}
+
+// Initialized reports whether a passphrase has been set
+func (s *PassphraseStore) Initialized() bool {
+	_, err := os.Stat(s.path)
+	return err == nil
+}
+
+// Locked reports whether the passphrase still has to be entered
+func (s *PassphraseStore) Locked() bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.gcm == nil
+}
+
+// Unlock derives the key from the passphrase. The first call sets the passphrase.
+func (s *PassphraseStore) Unlock(passphrase string) error {
+	debug := false
+	if passphrase == "" {
+		return fmt.Errorf("passphrase must not be empty")
+	}
+
+	gcm, err := s.readCipher(passphrase)
+	if errors.Is(err, os.ErrNotExist) {
+		return s.create(passphrase)
+	}
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	s.gcm = gcm
+	s.mu.Unlock()
+	logging.Log(debug, "Passphrase store unlocked")
+	return nil
+}
+
+// readCipher derives the cipher of passphrase with the salt of the passphrase
+// file and checks it against the stored check value
+func (s *PassphraseStore) readCipher(passphrase string) (cipher.AEAD, error) {
+	data, err := os.ReadFile(s.path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read passphrase file: %w", err)
+	}
+
+	var file passphraseFile
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal passphrase file: %w", err)
+	}
+	salt, err := base64.StdEncoding.DecodeString(file.Salt)
+	if err != nil {
+		return nil, fmt.Errorf("invalid salt in passphrase file: %w", err)
+	}
+	gcm, err := passphraseCipher(passphrase, salt)
+	if err != nil {
+		return nil, err
+	}
+	check, err := openSealed(gcm, file.Check)
+	if err != nil || check != passphraseCheck {
+		return nil, ErrWrongPassphrase
+	}
+	return gcm, nil
+}
+
+// create sets the passphrase: it writes a new salt and the check value
+func (s *PassphraseStore) create(passphrase string) error {
+	data, gcm, err := newPassphraseFile(passphrase)
+	if err != nil {
+		return err
+	}
+	if err := fileutil.WriteFileAtomic(s.path, data, 0600); err != nil {
+		return fmt.Errorf("failed to write passphrase file: %w", err)
+	}
+
+	s.mu.Lock()
+	s.gcm = gcm
+	s.mu.Unlock()
+	logging.Log(true, "Passphrase store created at", s.path)
+	return nil
+}
+
+// newPassphraseFile returns the passphrase file content of a new salt and the
+// cipher derived from it
+func newPassphraseFile(passphrase string) ([]byte, cipher.AEAD, error) {
+	salt := make([]byte, 16)
+	if _, err := rand.Read(salt); err != nil {
+		return nil, nil, fmt.Errorf("failed to create salt: %w", err)
+	}
+	gcm, err := passphraseCipher(passphrase, salt)
+	if err != nil {
+		return nil, nil, err
+	}
+	check, err := seal(gcm, passphraseCheck)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	data, err := json.MarshalIndent(passphraseFile{
+		Salt:  base64.StdEncoding.EncodeToString(salt),
+		Check: check,
+	}, "", "  ")
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to marshal passphrase file: %w", err)
+	}
+	return data, gcm, nil
+}
+
+// ChangePassphrase replaces the passphrase. reencrypt gets a function converting
+// a secret encrypted with the current passphrase to the new one and must save
+// the converted secrets. The new passphrase only takes effect (and unlocks the
+// store) when reencrypt succeeds. The store is locked meanwhile, so no secret is
+// encrypted with the old key while the stored ones are converted.
+func (s *PassphraseStore) ChangePassphrase(current, passphrase string, reencrypt func(convert func(string) (string, error)) error) error {
+	if passphrase == "" {
+		return fmt.Errorf("passphrase must not be empty")
+	}
+	oldGCM, err := s.readCipher(current)
+	if err != nil {
+		return err
+	}
+	data, newGCM, err := newPassphraseFile(passphrase)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	previous := s.gcm
+	s.gcm = nil
+	s.mu.Unlock()
+	restore := func() {
+		s.mu.Lock()
+		s.gcm = previous
+		s.mu.Unlock()
+	}
+
+	convert := func(encrypted string) (string, error) {
+		if encrypted == "" {
+			return "", nil
+		}
+		plain, err := openSealed(oldGCM, encrypted)
+		if err != nil {
+			return "", fmt.Errorf("failed to decrypt password: %w", err)
+		}
+		return seal(newGCM, plain)
+	}
+	if err := reencrypt(convert); err != nil {
+		restore()
+		return err
+	}
+	if err := fileutil.WriteFileAtomic(s.path, data, 0600); err != nil {
+		restore()
+		return fmt.Errorf("failed to write passphrase file: %w", err)
+	}
+
+	s.mu.Lock()
+	s.gcm = newGCM
+	s.mu.Unlock()
+	logging.Log(true, "Passphrase of", s.path, "changed")
+	return nil
+}
+
+// Lock forgets the derived key
+func (s *PassphraseStore) Lock() {
+	s.mu.Lock()
+	s.gcm = nil
+	s.mu.Unlock()
+}
+
+// Encrypt encrypts a password, the result is base64 of nonce + ciphertext
+func (s *PassphraseStore) Encrypt(password string) (string, error) {
+	if password == "" {
+		return "", nil
+	}
+	s.mu.RLock()
+	gcm := s.gcm
+	s.mu.RUnlock()
+	if gcm == nil {
+		return "", ErrLocked
+	}
+	return seal(gcm, password)
+}
+
+// Decrypt decrypts a password encrypted by Encrypt
+func (s *PassphraseStore) Decrypt(encryptedPassword string) (string, error) {
+	if encryptedPassword == "" {
+		return "", nil
+	}
+	s.mu.RLock()
+	gcm := s.gcm
+	s.mu.RUnlock()
+	if gcm == nil {
+		return "", ErrLocked
+	}
+	plain, err := openSealed(gcm, encryptedPassword)
+	if err != nil {
+		return "", fmt.Errorf("failed to decrypt password (DPAPI data from a non-portable setup?): %w", err)
+	}
+	return plain, nil
+}
+
+// passphraseCipher derives the AES-256-GCM cipher of a passphrase
+func passphraseCipher(passphrase string, salt []byte) (cipher.AEAD, error) {
+	key, err := scrypt.Key([]byte(passphrase), salt, scryptN, scryptR, scryptP, scryptKeyLen)
+	if err != nil {
+		return nil, fmt.Errorf("failed to derive key: %w", err)
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create cipher: %w", err)
+	}
+	return cipher.NewGCM(block)
+}
+
+func seal(gcm cipher.AEAD, plain string) (string, error) {
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", fmt.Errorf("failed to create nonce: %w", err)
+	}
+	sealed := gcm.Seal(nonce, nonce, []byte(plain), nil)
+	return base64.StdEncoding.EncodeToString(sealed), nil
+}
+
+func openSealed(gcm cipher.AEAD, encrypted string) (string, error) {
+	sealed, err := base64.StdEncoding.DecodeString(encrypted)
+	if err != nil {
+		return "", fmt.Errorf("failed to decode base64: %v", err)
+	}
+	if len(sealed) < gcm.NonceSize() {
+		return "", fmt.Errorf("encrypted data too short")
+	}
+	plain, err := gcm.Open(nil, sealed[:gcm.NonceSize()], sealed[gcm.NonceSize():], nil)
+	if err != nil {
+		return "", err
+	}
+	return string(plain), nil
+}
@@ -0,0 +1,231 @@
+package credentials
+
+import (
+	"bytes"
+	"encoding/base64"
+	"errors"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+)
+
+// newTestPassphraseStore returns a store unlocked with passphrase
+func newTestPassphraseStore(t *testing.T, passphrase string) *PassphraseStore {
+	t.Helper()
+	store := NewPassphraseStore(filepath.Join(t.TempDir(), PassphraseFileName))
+	if err := store.Unlock(passphrase); err != nil {
+		t.Fatal(err)
+	}
+	return store
+}
+
+func TestPassphraseCipherDerivation(t *testing.T) {
+	salt := []byte("0123456789abcdef")
+	first, err := passphraseCipher("correct horse", salt)
+	if err != nil {
+		t.Fatal(err)
+	}
+	same, err := passphraseCipher("correct horse", salt)
+	if err != nil {
+		t.Fatal(err)
+	}
+	otherSalt, err := passphraseCipher("correct horse", []byte("fedcba9876543210"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// AES-256-GCM with the standard nonce
+	if first.NonceSize() != 12 || first.Overhead() != 16 {
+		t.Errorf("nonce size %d, overhead %d", first.NonceSize(), first.Overhead())
+	}
+	sealed, err := seal(first, "s3cret")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The key only depends on passphrase and salt
+	if plain, err := openSealed(same, sealed); err != nil || plain != "s3cret" {
+		t.Errorf("openSealed() with the same derivation = %q, %v", plain, err)
+	}
+	if _, err := openSealed(otherSalt, sealed); err == nil {
+		t.Error("opened with a key of another salt")
+	}
+	// Every seal uses a fresh nonce
+	if again, _ := seal(first, "s3cret"); again == sealed {
+		t.Error("sealing twice gave the same ciphertext")
+	}
+}
+
+func TestPassphraseStoreCreateAndUnlock(t *testing.T) {
+	path := filepath.Join(t.TempDir(), PassphraseFileName)
+	store := NewPassphraseStore(path)
+	if store.Initialized() || !store.Locked() {
+		t.Fatalf("new store: initialized %v, locked %v", store.Initialized(), store.Locked())
+	}
+	if err := store.Unlock(""); err == nil {
+		t.Error("empty passphrase accepted")
+	}
+
+	// The first unlock sets the passphrase
+	if err := store.Unlock("correct horse"); err != nil {
+		t.Fatal(err)
+	}
+	if !store.Initialized() || store.Locked() {
+		t.Errorf("after create: initialized %v, locked %v", store.Initialized(), store.Locked())
+	}
+	if runtime.GOOS != "windows" {
+		if info, _ := os.Stat(path); info.Mode().Perm() != 0600 {
+			t.Errorf("permissions %v, want 0600", info.Mode().Perm())
+		}
+	}
+	encrypted, err := store.Encrypt("s3cret")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bytes.Contains([]byte(encrypted), []byte("s3cret")) {
+		t.Error("password stored in plain text")
+	}
+
+	// A second store on the same file (next start) needs the same passphrase
+	reopened := NewPassphraseStore(path)
+	if err := reopened.Unlock("wrong horse"); !errors.Is(err, ErrWrongPassphrase) {
+		t.Errorf("Unlock(wrong) = %v, want ErrWrongPassphrase", err)
+	}
+	if !reopened.Locked() {
+		t.Error("unlocked by a wrong passphrase")
+	}
+	if err := reopened.Unlock("correct horse"); err != nil {
+		t.Fatal(err)
+	}
+	if plain, err := reopened.Decrypt(encrypted); err != nil || plain != "s3cret" {
+		t.Errorf("Decrypt() = %q, %v", plain, err)
+	}
+}
+
+func TestPassphraseStoreLocked(t *testing.T) {
+	store := newTestPassphraseStore(t, "correct horse")
+	encrypted, err := store.Encrypt("s3cret")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	store.Lock()
+	if !store.Locked() {
+		t.Fatal("Lock() kept the key")
+	}
+	if _, err := store.Encrypt("s3cret"); !errors.Is(err, ErrLocked) {
+		t.Errorf("Encrypt() while locked = %v, want ErrLocked", err)
+	}
+	if _, err := store.Decrypt(encrypted); !errors.Is(err, ErrLocked) {
+		t.Errorf("Decrypt() while locked = %v, want ErrLocked", err)
+	}
+	// Empty passwords need no key
+	if encrypted, err := store.Encrypt(""); err != nil || encrypted != "" {
+		t.Errorf("Encrypt(\"\") = %q, %v", encrypted, err)
+	}
+	if plain, err := store.Decrypt(""); err != nil || plain != "" {
+		t.Errorf("Decrypt(\"\") = %q, %v", plain, err)
+	}
+}
+
+func TestPassphraseStoreTamperedCiphertext(t *testing.T) {
+	store := newTestPassphraseStore(t, "correct horse")
+	encrypted, err := store.Encrypt("s3cret")
+	if err != nil {
+		t.Fatal(err)
+	}
+	sealed, err := base64.StdEncoding.DecodeString(encrypted)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	flip := func(i int) string {
+		tampered := bytes.Clone(sealed)
+		tampered[i] ^= 0x01
+		return base64.StdEncoding.EncodeToString(tampered)
+	}
+	tests := map[string]string{
+		"nonce":      flip(0),
+		"ciphertext": flip(12),
+		"tag":        flip(len(sealed) - 1),
+		"truncated":  base64.StdEncoding.EncodeToString(sealed[:8]),
+		"not base64": "%%%",
+	}
+	for name, value := range tests {
+		if plain, err := store.Decrypt(value); err == nil {
+			t.Errorf("%s: Decrypt() = %q, want an error", name, plain)
+		}
+	}
+}
+
+func TestPassphraseStoreChangePassphrase(t *testing.T) {
+	store := newTestPassphraseStore(t, "correct horse")
+	alice, _ := store.Encrypt("alice-pw")
+	bob, _ := store.Encrypt("bob-pw")
+	stored := []string{alice, "", bob}
+
+	// A wrong current passphrase changes nothing
+	called := false
+	err := store.ChangePassphrase("wrong horse", "battery staple", func(func(string) (string, error)) error {
+		called = true
+		return nil
+	})
+	if !errors.Is(err, ErrWrongPassphrase) || called {
+		t.Errorf("ChangePassphrase(wrong) = %v, reencrypt called %v", err, called)
+	}
+
+	// A failed re-encryption keeps the old passphrase and key
+	saveErr := errors.New("disk full")
+	err = store.ChangePassphrase("correct horse", "battery staple", func(func(string) (string, error)) error {
+		if !store.Locked() {
+			t.Error("store unlocked while re-encrypting")
+		}
+		return saveErr
+	})
+	if !errors.Is(err, saveErr) {
+		t.Errorf("ChangePassphrase() = %v, want %v", err, saveErr)
+	}
+	if plain, err := store.Decrypt(alice); err != nil || plain != "alice-pw" {
+		t.Errorf("Decrypt() after a failed change = %q, %v", plain, err)
+	}
+
+	err = store.ChangePassphrase("correct horse", "battery staple", func(convert func(string) (string, error)) error {
+		for i, encrypted := range stored {
+			converted, err := convert(encrypted)
+			if err != nil {
+				return err
+			}
+			stored[i] = converted
+		}
+		if _, err := convert("bm90IGVuY3J5cHRlZA=="); err == nil {
+			t.Error("converted a secret of another key")
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stored[0] == alice || stored[1] != "" || stored[2] == bob {
+		t.Errorf("re-encrypted secrets %q", stored)
+	}
+
+	// The store is unlocked with the new key, old ciphertexts no longer open
+	for i, want := range []string{"alice-pw", "", "bob-pw"} {
+		if plain, err := store.Decrypt(stored[i]); err != nil || plain != want {
+			t.Errorf("Decrypt(stored[%d]) = %q, %v, want %q", i, plain, err, want)
+		}
+	}
+	if _, err := store.Decrypt(alice); err == nil {
+		t.Error("old ciphertext opened with the new key")
+	}
+	reopened := NewPassphraseStore(store.path)
+	if err := reopened.Unlock("correct horse"); !errors.Is(err, ErrWrongPassphrase) {
+		t.Errorf("Unlock(old passphrase) = %v, want ErrWrongPassphrase", err)
+	}
+	if err := reopened.Unlock("battery staple"); err != nil {
+		t.Fatal(err)
+	}
+	if plain, err := reopened.Decrypt(stored[2]); err != nil || plain != "bob-pw" {
+		t.Errorf("Decrypt() after reopening = %q, %v", plain, err)
+	}
+}
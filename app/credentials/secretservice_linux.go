@@ -0,0 +1,222 @@
+//go:build linux
+
+package credentials
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/godbus/dbus/v5"
+
+	"github.com/chrilep/LaunchRDP/app/logging"
+)
+
+// Secret Service API (org.freedesktop.secrets), implemented by GNOME Keyring and KWallet
+const (
+	secretServiceName       = "org.freedesktop.secrets"
+	secretServicePath       = dbus.ObjectPath("/org/freedesktop/secrets")
+	secretDefaultCollection = dbus.ObjectPath("/org/freedesktop/secrets/aliases/default")
+	secretServiceInterface  = "org.freedesktop.Secret.Service"
+	secretItemInterface     = "org.freedesktop.Secret.Item"
+	secretPromptInterface   = "org.freedesktop.Secret.Prompt"
+	secretNoPrompt          = dbus.ObjectPath("/")
+	secretApplication       = "LaunchRDP" // "application" attribute of our items
+	secretCallTimeout       = 5 * time.Second
+	secretPromptTimeout     = 2 * time.Minute // the user may have to enter the keyring password
+)
+
+var _ CredentialStore = (*SecretServiceStore)(nil)
+
+// secret is the Secret struct of the Secret Service API (oayays)
+type secret struct {
+	Session     dbus.ObjectPath
+	Parameters  []byte
+	Value       []byte
+	ContentType string
+}
+
+// SecretServiceStore keeps TERMSRV credentials in the desktop keyring over D-Bus.
+// Items carry the attributes application=LaunchRDP, target and username.
+type SecretServiceStore struct {
+	conn    *dbus.Conn
+	session dbus.ObjectPath
+}
+
+// newSystemStore returns the Secret Service store
+func newSystemStore() (CredentialStore, error) {
+	return NewSecretServiceStore()
+}
+
+// NewSecretServiceStore connects to the Secret Service of the session bus
+func NewSecretServiceStore() (*SecretServiceStore, error) {
+	conn, err := dbus.SessionBus()
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to the session bus: %w", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), secretCallTimeout)
+	defer cancel()
+	var output dbus.Variant
+	var session dbus.ObjectPath
+	// The secret is protected by the bus connection itself, no transport encryption
+	err = conn.Object(secretServiceName, secretServicePath).CallWithContext(ctx,
+		secretServiceInterface+".OpenSession", 0, "plain", dbus.MakeVariant("")).Store(&output, &session)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open Secret Service session: %w", err)
+	}
+	return &SecretServiceStore{conn: conn, session: session}, nil
+}
+
+// Name returns "secret-service"
+func (s *SecretServiceStore) Name() string {
+	return "secret-service"
+}
+
+// Store creates or replaces the credential of a target
+func (s *SecretServiceStore) Store(target, username, password string) error {
+	debug := false
+	if err := validateCredential(target, username, password); err != nil {
+		return err
+	}
+	if err := s.unlock(secretDefaultCollection); err != nil {
+		return err
+	}
+
+	qualified := QualifiedUsername(target, username)
+	properties := map[string]dbus.Variant{
+		secretItemInterface + ".Label": dbus.MakeVariant("LaunchRDP " + TargetPrefix + target),
+		secretItemInterface + ".Attributes": dbus.MakeVariant(map[string]string{
+			"application": secretApplication,
+			"target":      target,
+			"username":    qualified,
+		}),
+	}
+	value := secret{Session: s.session, Parameters: []byte{}, Value: []byte(password), ContentType: "text/plain"}
+
+	var item, prompt dbus.ObjectPath
+	// replace=true: the item with the same attributes is updated
+	err := s.call(secretDefaultCollection, "org.freedesktop.Secret.Collection.CreateItem", properties, value, true).Store(&item, &prompt)
+	if err != nil {
+		return fmt.Errorf("failed to store credential for %s: %w", target, err)
+	}
+	if err := s.prompt(prompt); err != nil {
+		return fmt.Errorf("failed to store credential for %s: %w", target, err)
+	}
+	logging.Log(debug, "Stored credential for", target, "in the Secret Service")
+	return nil
+}
+
+// Delete removes the credential of a target
+func (s *SecretServiceStore) Delete(target string) error {
+	items, err := s.search(map[string]string{"application": secretApplication, "target": target})
+	if err != nil {
+		return err
+	}
+	if len(items) == 0 {
+		return fmt.Errorf("failed to delete credential: %s not found", target)
+	}
+	for _, item := range items {
+		var prompt dbus.ObjectPath
+		if err := s.call(item, secretItemInterface+".Delete").Store(&prompt); err != nil {
+			return fmt.Errorf("failed to delete credential: %w", err)
+		}
+		if err := s.prompt(prompt); err != nil {
+			return fmt.Errorf("failed to delete credential: %w", err)
+		}
+	}
+	return nil
+}
+
+// List returns the credentials stored by LaunchRDP. Attributes are readable
+// without unlocking the keyring.
+func (s *SecretServiceStore) List() ([]Credential, error) {
+	items, err := s.search(map[string]string{"application": secretApplication})
+	if err != nil {
+		return nil, err
+	}
+	list := make([]Credential, 0, len(items))
+	for _, item := range items {
+		object := s.conn.Object(secretServiceName, item)
+		attributesValue, err := object.GetProperty(secretItemInterface + ".Attributes")
+		if err != nil {
+			return nil, fmt.Errorf("failed to read credential attributes: %w", err)
+		}
+		attributes, _ := attributesValue.Value().(map[string]string)
+		credential := Credential{Target: attributes["target"], Username: attributes["username"], Managed: true}
+		if modified, err := object.GetProperty(secretItemInterface + ".Modified"); err == nil {
+			if seconds, ok := modified.Value().(uint64); ok {
+				credential.Written = time.Unix(int64(seconds), 0)
+			}
+		}
+		list = append(list, credential)
+	}
+	return list, nil
+}
+
+// search returns the locked and unlocked items with the given attributes
+func (s *SecretServiceStore) search(attributes map[string]string) ([]dbus.ObjectPath, error) {
+	var unlocked, locked []dbus.ObjectPath
+	if err := s.call(secretServicePath, secretServiceInterface+".SearchItems", attributes).Store(&unlocked, &locked); err != nil {
+		return nil, fmt.Errorf("failed to search credentials: %w", err)
+	}
+	return append(unlocked, locked...), nil
+}
+
+// unlock unlocks a collection, the keyring may ask the user for its password
+func (s *SecretServiceStore) unlock(path dbus.ObjectPath) error {
+	var unlocked []dbus.ObjectPath
+	var prompt dbus.ObjectPath
+	if err := s.call(secretServicePath, secretServiceInterface+".Unlock", []dbus.ObjectPath{path}).Store(&unlocked, &prompt); err != nil {
+		return fmt.Errorf("failed to unlock keyring: %w", err)
+	}
+	if err := s.prompt(prompt); err != nil {
+		return fmt.Errorf("failed to unlock keyring: %w", err)
+	}
+	return nil
+}
+
+// prompt shows a prompt of the Secret Service and waits until it completes
+func (s *SecretServiceStore) prompt(path dbus.ObjectPath) error {
+	if path == secretNoPrompt || path == "" {
+		return nil
+	}
+	options := []dbus.MatchOption{
+		dbus.WithMatchObjectPath(path),
+		dbus.WithMatchInterface(secretPromptInterface),
+		dbus.WithMatchMember("Completed"),
+	}
+	if err := s.conn.AddMatchSignal(options...); err != nil {
+		return fmt.Errorf("failed to watch prompt: %w", err)
+	}
+	defer s.conn.RemoveMatchSignal(options...)
+	signals := make(chan *dbus.Signal, 4)
+	s.conn.Signal(signals)
+	defer s.conn.RemoveSignal(signals)
+
+	if err := s.call(path, secretPromptInterface+".Prompt", "").Err; err != nil {
+		return fmt.Errorf("failed to show prompt: %w", err)
+	}
+	timeout := time.After(secretPromptTimeout)
+	for {
+		select {
+		case signal := <-signals:
+			if signal.Path != path || signal.Name != secretPromptInterface+".Completed" {
+				continue
+			}
+			if len(signal.Body) > 0 && signal.Body[0] == true {
+				return fmt.Errorf("prompt dismissed")
+			}
+			return nil
+		case <-timeout:
+			return fmt.Errorf("prompt timed out")
+		}
+	}
+}
+
+// call calls a method of a Secret Service object with the default timeout
+func (s *SecretServiceStore) call(path dbus.ObjectPath, method string, args ...any) *dbus.Call {
+	ctx, cancel := context.WithTimeout(context.Background(), secretCallTimeout)
+	defer cancel()
+	return s.conn.Object(secretServiceName, path).CallWithContext(ctx, method, 0, args...)
+}
@@ -0,0 +1,10 @@
+//go:build !windows && !linux
+
+package credentials
+
+import "fmt"
+
+// newSystemStore reports that there is no supported system keyring
+func newSystemStore() (CredentialStore, error) {
+	return nil, fmt.Errorf("no system keyring on this platform")
+}
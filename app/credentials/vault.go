@@ -0,0 +1,336 @@
+package credentials
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/chrilep/LaunchRDP/app/models"
+)
+
+// Vault file format, see WriteVault
+const (
+	vaultFormat  = "launchrdp-vault"
+	vaultVersion = 1
+	// VaultMinPassphraseLength protects exported passwords against guessing;
+	// the file may end up anywhere
+	VaultMinPassphraseLength = 8
+)
+
+// Conflict resolutions of MergeVault
+const (
+	VaultPreview = "preview" // report what an import would do, change nothing
+	VaultKeep    = "keep"    // keep the local user or host
+	VaultReplace = "replace" // overwrite the local user or host with the imported one
+)
+
+// VaultUser is a user with its password in plain text. It only exists inside
+// the encrypted payload of a vault file.
+type VaultUser struct {
+	models.User
+	Password string `json:"password"`
+}
+
+// Vault is the content of a vault file: users and optionally hosts
+type Vault struct {
+	Users []VaultUser   `json:"users"`
+	Hosts []models.Host `json:"hosts,omitempty"`
+}
+
+// vaultFile is the JSON of a vault file. Everything but the key derivation
+// parameters is encrypted, usernames and host names included.
+type vaultFile struct {
+	Format    string    `json:"format"`
+	Version   int       `json:"version"`
+	KDF       string    `json:"kdf"`
+	Salt      string    `json:"salt"`
+	N         int       `json:"n"`
+	R         int       `json:"r"`
+	P         int       `json:"p"`
+	CreatedAt time.Time `json:"created_at"`
+	Data      string    `json:"data"` // base64 of nonce + AES-256-GCM ciphertext of the Vault JSON
+}
+
+// WriteVault encrypts a vault with a key derived from passphrase (scrypt +
+// AES-256-GCM) and writes it to path. Unlike DPAPI data the file can be
+// imported on any machine.
+func WriteVault(path, passphrase string, vault Vault) error {
+	if len(passphrase) < VaultMinPassphraseLength {
+		return fmt.Errorf("passphrase must have at least %d characters", VaultMinPassphraseLength)
+	}
+	salt := make([]byte, 16)
+	if _, err := rand.Read(salt); err != nil {
+		return fmt.Errorf("failed to create salt: %w", err)
+	}
+	gcm, err := passphraseCipher(passphrase, salt)
+	if err != nil {
+		return err
+	}
+	payload, err := json.Marshal(vault)
+	if err != nil {
+		return fmt.Errorf("failed to marshal vault: %w", err)
+	}
+	data, err := seal(gcm, string(payload))
+	if err != nil {
+		return err
+	}
+
+	file, err := json.MarshalIndent(vaultFile{
+		Format:    vaultFormat,
+		Version:   vaultVersion,
+		KDF:       "scrypt",
+		Salt:      base64.StdEncoding.EncodeToString(salt),
+		N:         scryptN,
+		R:         scryptR,
+		P:         scryptP,
+		CreatedAt: time.Now(),
+		Data:      data,
+	}, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal vault file: %w", err)
+	}
+	if err := os.WriteFile(path, file, 0600); err != nil {
+		return fmt.Errorf("failed to write vault file: %w", err)
+	}
+	return nil
+}
+
+// ReadVault decrypts a vault file written by WriteVault. A wrong passphrase
+// returns ErrWrongPassphrase.
+func ReadVault(path, passphrase string) (Vault, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return Vault{}, fmt.Errorf("failed to read vault file: %w", err)
+	}
+	var file vaultFile
+	if err := json.Unmarshal(data, &file); err != nil || file.Format != vaultFormat {
+		return Vault{}, fmt.Errorf("%s is not a LaunchRDP vault file", path)
+	}
+	if file.Version > vaultVersion {
+		return Vault{}, fmt.Errorf("vault file version %d is not supported, please update LaunchRDP", file.Version)
+	}
+	// Only the parameters this version writes are accepted, a crafted file must
+	// not make the key derivation arbitrarily expensive
+	if file.KDF != "scrypt" || file.N != scryptN || file.R != scryptR || file.P != scryptP {
+		return Vault{}, fmt.Errorf("unsupported key derivation in vault file")
+	}
+	salt, err := base64.StdEncoding.DecodeString(file.Salt)
+	if err != nil {
+		return Vault{}, fmt.Errorf("invalid salt in vault file: %w", err)
+	}
+
+	gcm, err := passphraseCipher(passphrase, salt)
+	if err != nil {
+		return Vault{}, err
+	}
+	payload, err := openSealed(gcm, file.Data)
+	if err != nil {
+		return Vault{}, ErrWrongPassphrase
+	}
+	var vault Vault
+	if err := json.Unmarshal([]byte(payload), &vault); err != nil {
+		return Vault{}, fmt.Errorf("failed to unmarshal vault: %w", err)
+	}
+	return vault, nil
+}
+
+// VaultConflict is a user or host that exists locally and differs from the imported one
+type VaultConflict struct {
+	Kind       string `json:"kind"` // "user" or "host"
+	Name       string `json:"name"`
+	Resolution string `json:"resolution"` // VaultKeep or VaultReplace
+}
+
+// VaultExport summarizes an export
+type VaultExport struct {
+	Users int `json:"users"`
+	Hosts int `json:"hosts"`
+	// Users exported without password, because it couldn't be decrypted here
+	UsersWithoutPassword []string `json:"usersWithoutPassword"`
+}
+
+// VaultImport summarizes a MergeVault run
+type VaultImport struct {
+	UsersAdded   int             `json:"usersAdded"`
+	UsersUpdated int             `json:"usersUpdated"`
+	HostsAdded   int             `json:"hostsAdded"`
+	HostsUpdated int             `json:"hostsUpdated"`
+	Unchanged    int             `json:"unchanged"`
+	Conflicts    []VaultConflict `json:"conflicts"`
+	// Imported hosts whose user is neither in the vault nor local, imported without user
+	HostsWithoutUser []string `json:"hostsWithoutUser"`
+	// Local IDs of the hosts whose credential the import changes: hosts added or
+	// updated, and hosts of users added or updated
+	ChangedHostIDs []string `json:"changedHostIds"`
+}
+
+// MergeVault merges a vault into the local users and hosts. Users match by ID,
+// then by username; hosts by ID, then by name and address. Users and hosts that
+// differ are conflicts, resolved by mode (VaultKeep or VaultReplace; VaultPreview
+// reports like VaultKeep). Passwords are encrypted with cipher, imported hosts
+// refer to the matching local users. Vault entries are only matched against the
+// local users and hosts, never against each other: entries with the same ID are
+// added with a new ID.
+func MergeVault(users []models.User, hosts []models.Host, vault Vault, cipher SecretCipher, mode string) ([]models.User, []models.Host, VaultImport, error) {
+	if mode != VaultPreview && mode != VaultKeep && mode != VaultReplace {
+		return nil, nil, VaultImport{}, fmt.Errorf("unknown conflict mode: %s", mode)
+	}
+	result := VaultImport{Conflicts: []VaultConflict{}, HostsWithoutUser: []string{}, ChangedHostIDs: []string{}}
+	resolution := VaultKeep
+	if mode == VaultReplace {
+		resolution = VaultReplace
+	}
+	now := time.Now()
+
+	// userIDs maps the IDs in the vault to the local IDs
+	userIDs := map[string]string{}
+	// Local IDs of the users and hosts added or updated
+	changedUsers, changedHosts := map[string]bool{}, map[string]bool{}
+	localUsers := len(users)
+	for _, imported := range vault.Users {
+		index := -1
+		for i := range users[:localUsers] {
+			if users[i].ID == imported.ID {
+				index = i
+				break
+			}
+		}
+		if index == -1 {
+			for i := range users[:localUsers] {
+				if strings.EqualFold(users[i].Username, imported.Username) {
+					index = i
+					break
+				}
+			}
+		}
+
+		if index == -1 {
+			user := imported.User
+			if user.ID == "" || slices.ContainsFunc(users, func(u models.User) bool { return u.ID == user.ID }) {
+				user.ID = models.NewUser(user.Name, user.Username).ID
+			}
+			encrypted, err := cipher.Encrypt(imported.Password)
+			if err != nil {
+				return nil, nil, VaultImport{}, fmt.Errorf("failed to encrypt password of %s: %w", user.Username, err)
+			}
+			user.EncryptedPassword = encrypted
+			if imported.Password != "" {
+				user.PasswordChangedAt = now
+			}
+			userIDs[imported.ID] = user.ID
+			users = append(users, user)
+			changedUsers[user.ID] = true
+			result.UsersAdded++
+			continue
+		}
+
+		local := &users[index]
+		userIDs[imported.ID] = local.ID
+		password, err := cipher.Decrypt(local.EncryptedPassword)
+		if err != nil {
+			// Unreadable local password (other machine's DPAPI data): the import fixes it
+			password = ""
+		}
+		if local.Username == imported.Username && local.Login == imported.Login &&
+			local.Domain == imported.Domain && local.Name == imported.Name && password == imported.Password {
+			result.Unchanged++
+			continue
+		}
+		result.Conflicts = append(result.Conflicts, VaultConflict{Kind: "user", Name: local.Username, Resolution: resolution})
+		if resolution != VaultReplace {
+			continue
+		}
+		encrypted, err := cipher.Encrypt(imported.Password)
+		if err != nil {
+			return nil, nil, VaultImport{}, fmt.Errorf("failed to encrypt password of %s: %w", imported.Username, err)
+		}
+		local.Name = imported.Name
+		local.Username = imported.Username
+		local.Login = imported.Login
+		local.Domain = imported.Domain
+		if password != imported.Password {
+			local.EncryptedPassword = encrypted
+			local.PasswordChangedAt = now
+		}
+		local.ModifiedAt = now
+		changedUsers[local.ID] = true
+		result.UsersUpdated++
+	}
+
+	localHosts := len(hosts)
+	for _, imported := range vault.Hosts {
+		host := imported
+		if id, ok := userIDs[host.UserID]; ok {
+			host.UserID = id
+		} else if host.UserID != "" && !slices.ContainsFunc(users, func(u models.User) bool { return u.ID == host.UserID }) {
+			// A user of the exporting machine that the vault doesn't contain
+			host.UserID = ""
+			result.HostsWithoutUser = append(result.HostsWithoutUser, host.Name)
+		}
+		index := -1
+		for i := range hosts[:localHosts] {
+			if hosts[i].ID == host.ID {
+				index = i
+				break
+			}
+		}
+		if index == -1 {
+			for i := range hosts[:localHosts] {
+				if strings.EqualFold(hosts[i].Name, host.Name) && strings.EqualFold(hosts[i].Address, host.Address) {
+					index = i
+					break
+				}
+			}
+		}
+
+		if index == -1 {
+			// Another host of the vault may have the same ID
+			if host.ID == "" || slices.ContainsFunc(hosts, func(h models.Host) bool { return h.ID == host.ID }) {
+				host.ID = models.NewHost(host.Name, host.Address, host.Port, host.UserID).ID
+			}
+			hosts = append(hosts, host)
+			changedHosts[host.ID] = true
+			result.HostsAdded++
+			continue
+		}
+
+		local := &hosts[index]
+		host.ID = local.ID
+		host.CreatedAt = local.CreatedAt
+		host.ModifiedAt = local.ModifiedAt
+		if host.GroupID == "" {
+			host.GroupID = local.GroupID
+		}
+		if sameHost(*local, host) {
+			result.Unchanged++
+			continue
+		}
+		result.Conflicts = append(result.Conflicts, VaultConflict{Kind: "host", Name: local.Name, Resolution: resolution})
+		if resolution != VaultReplace {
+			continue
+		}
+		host.ModifiedAt = now
+		*local = host
+		changedHosts[host.ID] = true
+		result.HostsUpdated++
+	}
+
+	for _, host := range hosts {
+		if changedHosts[host.ID] || changedUsers[host.UserID] {
+			result.ChangedHostIDs = append(result.ChangedHostIDs, host.ID)
+		}
+	}
+	return users, hosts, result, nil
+}
+
+// sameHost compares two hosts by their JSON, which covers every setting
+func sameHost(a, b models.Host) bool {
+	first, errA := json.Marshal(a)
+	second, errB := json.Marshal(b)
+	return errA == nil && errB == nil && string(first) == string(second)
+}
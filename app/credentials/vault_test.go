@@ -0,0 +1,179 @@
+package credentials
+
+import (
+	"errors"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"github.com/chrilep/LaunchRDP/app/models"
+)
+
+func newTestCipher(t *testing.T) *MemoryCipher {
+	t.Helper()
+	cipher, err := NewMemoryCipher()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return cipher
+}
+
+func TestVaultRoundTrip(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "export.lrdpvault")
+	user := models.NewUser("Alice", `CORP\alice`)
+	vault := Vault{
+		Users: []VaultUser{{User: user, Password: "s3cret"}},
+		Hosts: []models.Host{models.NewHost("Server", "srv.example.com", 3389, user.ID)},
+	}
+	if err := WriteVault(path, "short", vault); err == nil {
+		t.Error("short passphrase accepted")
+	}
+	if err := WriteVault(path, "correct horse", vault); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := ReadVault(path, "wrong horse"); !errors.Is(err, ErrWrongPassphrase) {
+		t.Errorf("ReadVault() with a wrong passphrase = %v", err)
+	}
+	read, err := ReadVault(path, "correct horse")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(read.Users) != 1 || read.Users[0].Password != "s3cret" || read.Users[0].ID != user.ID {
+		t.Errorf("users = %+v", read.Users)
+	}
+	if len(read.Hosts) != 1 || read.Hosts[0].UserID != user.ID {
+		t.Errorf("hosts = %+v", read.Hosts)
+	}
+}
+
+func TestMergeVaultDuplicateIDs(t *testing.T) {
+	cipher := newTestCipher(t)
+	local := models.NewHost("Local", "local.example.com", 3389, "")
+	first := models.NewHost("First", "first.example.com", 3389, "")
+	second := first
+	second.Name, second.Address = "Second", "second.example.com"
+	// An imported host may also reuse the ID of a local one
+	third := models.NewHost("Third", "third.example.com", 3389, "")
+	third.ID = local.ID
+	userA := VaultUser{User: models.NewUser("A", "a")}
+	userB := VaultUser{User: models.NewUser("B", "b")}
+	userB.ID = userA.ID
+
+	users, hosts, result, err := MergeVault(nil, []models.Host{local},
+		Vault{Users: []VaultUser{userA, userB}, Hosts: []models.Host{first, second, third}}, cipher, VaultKeep)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// third matches local by its ID and differs, a conflict
+	if result.HostsAdded != 2 || result.UsersAdded != 2 || len(result.Conflicts) != 1 {
+		t.Errorf("result = %+v, want 2 hosts and 2 users added and 1 conflict", result)
+	}
+	if len(users) != 2 || users[0].ID == users[1].ID {
+		t.Errorf("users = %+v, want two with distinct IDs", users)
+	}
+	ids := map[string]bool{}
+	for _, host := range hosts {
+		if ids[host.ID] {
+			t.Errorf("duplicate host ID %s", host.ID)
+		}
+		ids[host.ID] = true
+	}
+	names := make([]string, len(hosts))
+	for i, host := range hosts {
+		names[i] = host.Name
+	}
+	if !slices.Equal(names, []string{"Local", "First", "Second"}) {
+		t.Errorf("hosts = %v", names)
+	}
+}
+
+func TestMergeVaultForeignUserID(t *testing.T) {
+	cipher := newTestCipher(t)
+	localUser := models.NewUser("Local", "local")
+	vaultUser := VaultUser{User: models.NewUser("Alice", "alice"), Password: "pw"}
+	ofVaultUser := models.NewHost("Alice's", "a.example.com", 3389, vaultUser.ID)
+	ofLocalUser := models.NewHost("Local's", "l.example.com", 3389, localUser.ID)
+	foreign := models.NewHost("Foreign", "f.example.com", 3389, "user-of-another-machine")
+
+	for _, mode := range []string{VaultPreview, VaultKeep} {
+		t.Run(mode, func(t *testing.T) {
+			users, hosts, result, err := MergeVault([]models.User{localUser}, nil,
+				Vault{Users: []VaultUser{vaultUser}, Hosts: []models.Host{ofVaultUser, ofLocalUser, foreign}}, cipher, mode)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !slices.Equal(result.HostsWithoutUser, []string{"Foreign"}) {
+				t.Errorf("hosts without user = %v, want [Foreign]", result.HostsWithoutUser)
+			}
+			want := map[string]string{"Alice's": users[1].ID, "Local's": localUser.ID, "Foreign": ""}
+			for _, host := range hosts {
+				if host.UserID != want[host.Name] {
+					t.Errorf("%s: user %q, want %q", host.Name, host.UserID, want[host.Name])
+				}
+			}
+		})
+	}
+}
+
+func TestMergeVaultConflicts(t *testing.T) {
+	cipher := newTestCipher(t)
+	local := models.NewUser("Alice", "alice")
+	local.EncryptedPassword, _ = cipher.Encrypt("old")
+	imported := VaultUser{User: local, Password: "new"}
+	imported.ID = "other-id" // matched by username
+
+	for _, test := range []struct {
+		mode     string
+		updated  int
+		password string
+	}{
+		{VaultPreview, 0, "old"},
+		{VaultKeep, 0, "old"},
+		{VaultReplace, 1, "new"},
+	} {
+		t.Run(test.mode, func(t *testing.T) {
+			users, _, result, err := MergeVault([]models.User{local}, nil, Vault{Users: []VaultUser{imported}}, cipher, test.mode)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(users) != 1 || result.UsersUpdated != test.updated || len(result.Conflicts) != 1 {
+				t.Fatalf("users = %d, result = %+v", len(users), result)
+			}
+			if password, _ := cipher.Decrypt(users[0].EncryptedPassword); password != test.password {
+				t.Errorf("password = %q, want %q", password, test.password)
+			}
+		})
+	}
+	if _, _, _, err := MergeVault(nil, nil, Vault{}, cipher, "merge"); err == nil {
+		t.Error("unknown mode accepted")
+	}
+}
+
+func TestMergeVaultChangedHosts(t *testing.T) {
+	cipher := newTestCipher(t)
+	alice := models.NewUser("Alice", "alice")
+	alice.EncryptedPassword, _ = cipher.Encrypt("old")
+	bob := models.NewUser("Bob", "bob")
+	ofAlice := models.NewHost("Alice's", "a.example.com", 3389, alice.ID)
+	ofBob := models.NewHost("Bob's", "b.example.com", 3389, bob.ID)
+	renamed := models.NewHost("Renamed", "r.example.com", 3389, bob.ID)
+	local := []models.Host{ofAlice, ofBob, renamed}
+
+	// Alice's password and the renamed host change, Bob's host does not
+	changedRenamed := renamed
+	changedRenamed.Name = "Renamed again"
+	added := models.NewHost("Added", "n.example.com", 3389, "")
+	vault := Vault{
+		Users: []VaultUser{{User: alice, Password: "new"}, {User: bob}},
+		Hosts: []models.Host{ofBob, changedRenamed, added},
+	}
+	_, hosts, result, err := MergeVault([]models.User{alice, bob}, local, vault, cipher, VaultReplace)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{ofAlice.ID, renamed.ID, hosts[3].ID}
+	if !slices.Equal(result.ChangedHostIDs, want) {
+		t.Errorf("changed hosts = %v, want %v", result.ChangedHostIDs, want)
+	}
+}
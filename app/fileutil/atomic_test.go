@@ -0,0 +1,69 @@
+package fileutil
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+)
+
+func TestWriteFileAtomic(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "hosts.json")
+	for _, content := range []string{"first", "second"} {
+		if err := WriteFileAtomic(path, []byte(content), 0600); err != nil {
+			t.Fatal(err)
+		}
+		if data, err := os.ReadFile(path); err != nil || string(data) != content {
+			t.Errorf("content = %q, %v, want %q", data, err, content)
+		}
+	}
+
+	entries, _ := os.ReadDir(dir)
+	if len(entries) != 1 {
+		t.Errorf("files left: %v", entries)
+	}
+	if runtime.GOOS != "windows" {
+		if info, _ := os.Stat(path); info.Mode().Perm() != 0600 {
+			t.Errorf("permissions %v, want 0600", info.Mode().Perm())
+		}
+	}
+}
+
+func TestWriteFileAtomicFailureKeepsFile(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "hosts.json")
+	if err := WriteFileAtomic(path, []byte("first"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	// Renaming a file over a directory fails
+	target := filepath.Join(dir, "target")
+	if err := os.Mkdir(target, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(target, "child"), nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := WriteFileAtomic(target, []byte("second"), 0644); err == nil {
+		t.Fatal("replaced a directory")
+	}
+	entries, _ := os.ReadDir(dir)
+	if len(entries) != 2 {
+		t.Errorf("temp file left: %v", entries)
+	}
+	if data, _ := os.ReadFile(path); string(data) != "first" {
+		t.Errorf("other file changed to %q", data)
+	}
+}
+
+func TestWriteFileSynced(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "hosts.json.bak")
+	for _, content := range []string{"a longer first version", "second"} {
+		if err := WriteFileSynced(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+		if data, _ := os.ReadFile(path); string(data) != content {
+			t.Errorf("content = %q, want %q", data, content)
+		}
+	}
+}
@@ -1,7 +1,7 @@
 /*
 Lancer's simple logging module
 	This module provides a simple logging mechanism that writes debug messages to both the console and a log file.
-	The log file is created in the user's application data directory, and it is purged at the start of the application.
+	The log file is created in the logs directory of the config package (see config.ResolveDirectories), and it is purged at the start of the application.
 	It is designed to be used for debugging purposes, allowing developers to track the flow of the application.
 
 	It needs three global variables to work:
@@ -18,14 +18,17 @@ package logging
 import (
 	"fmt"
 	"os"
+	"path/filepath"
 	"runtime"
 	"strings"
 	"time"
+
+	"github.com/chrilep/LaunchRDP/app/config"
 )
 
 var strLogFilePath string // eg. <dataFolder>\Lancer\<Product>\log.txt
 var fileLog *os.File
-var strAppTempDir string // like %LOCALAPPDATA%\Lancer\<Product>\
+var strAppTempDir string // config.LogsDir, like %LOCALAPPDATA%\Lancer\<Product>\
 
 // Global variables for application info
 var strPublisherName = "Lancer"
@@ -74,28 +77,18 @@ func Log(debug bool, arrMessageParts ...any) {
 func activateLogging() error {
 	// Can have no logging since logger not ready yet!
 	debug := true // set to true to enable debug logging
-	switch runtime.GOOS {
-	case `windows`:
-		strTempDir := os.Getenv("LOCALAPPDATA")
-		if strTempDir == `` {
-			strTempDir = os.Getenv("TMP")
-			if strTempDir == `` {
-				strTempDir = os.Getenv("TEMP")
-			}
-		}
-		strAppTempDir = strTempDir + `\` + strPublisherName + `\` + strProductName
-		strLogFilePath = strAppTempDir + `\log.txt`
-	case `linux`:
-		strHomeDir, err := os.UserHomeDir()
+	// Same directory layout as the rest of the app (XDG on Linux, LAUNCHRDP_HOME override).
+	// Resolved here because the first log message may come before config.InitDirectories.
+	strAppTempDir = config.LogsDir
+	if strAppTempDir == `` {
+		dirs, err := config.ResolveDirectories()
 		if err != nil {
-			strAppTempDir = ``
-		} else {
-			strAppTempDir = strHomeDir + `/.local/` + strProductName
-			strLogFilePath = strAppTempDir + `/log.txt`
+			fmt.Println("Unable to resolve log directory:", err)
+			return err
 		}
-	default:
-		strAppTempDir = ``
+		strAppTempDir = dirs.Logs
 	}
+	strLogFilePath = filepath.Join(strAppTempDir, `log.txt`)
 	// Check if directory exists.
 	if _, err := os.Stat(strAppTempDir); os.IsNotExist(err) {
 		// If not, create the directory.
@@ -2,6 +2,7 @@ package models
 
 import (
 	"fmt"
+	"sync/atomic"
 	"time"
 )
 
@@ -15,6 +16,7 @@ type User struct {
 	EncryptedPassword string    `json:"encrypted_password"` // AES encrypted password
 	CreatedAt         time.Time `json:"created_at"`
 	ModifiedAt        time.Time `json:"modified_at"`
+	PasswordChangedAt time.Time `json:"password_changed_at"` // zero for passwords set before it was recorded
 }
 
 // Host represents a remote host configuration
@@ -25,14 +27,31 @@ type Host struct {
 	Port    int    `json:"port"`    // default 3389
 	UserID  string `json:"user_id"` // reference to User.ID
 
+	// Group (Group.ID) the host is sorted into, empty = ungrouped
+	GroupID string `json:"group_id,omitempty"`
+
+	// Credential alias: stored as TERMSRV/<alias> and connected to instead of the
+	// address, so hosts sharing an address with different users each get their own
+	// credential. A DNS alias of the host or "address:port", empty = the address.
+	CredentialAlias string `json:"credential_alias,omitempty"`
+
 	// RDP Settings
 	RedirectClipboard bool   `json:"redirect_clipboard"`
 	RedirectDrives    bool   `json:"redirect_drives"`
-	DrivesToRedirect  string `json:"drives_to_redirect"` // "*" for all drives
+	DrivesToRedirect  string `json:"drives_to_redirect"` // "*" for all, or "C:;D:;DynamicDrives;Share=C:\\Path"
 	DisplayMode       string `json:"display_mode"`       // "fullscreen" or "window"
 	DynamicResolution bool   `json:"dynamic_resolution"`
 	ScreenMode        int    `json:"screen_mode"` // 1 = windowed, 2 = fullscreen
 
+	// Monitor selection for fullscreen sessions (indexes as returned by GetMonitorWorkAreas)
+	SelectedMonitors   []int `json:"selected_monitors,omitempty"` // empty = all monitors
+	SpanCurrentMonitor bool  `json:"span_current_monitor"`        // only the monitor the launcher is on
+
+	// Display scaling (0 = let mstsc use the local scaling)
+	DesktopScaleFactor int  `json:"desktop_scale_factor"` // 100, 125, 150, 175, 200, 250, 300, 400, 500
+	DeviceScaleFactor  int  `json:"device_scale_factor"`  // 100, 140, 180
+	SmartSizing        bool `json:"smart_sizing"`         // scale the session to the window size
+
 	// User-entered window size (what user actually wants)
 	WindowWidth  int `json:"window_width"`
 	WindowHeight int `json:"window_height"`
@@ -46,18 +65,101 @@ type Host struct {
 	PositionY int    `json:"position_y"`
 	WinPosStr string `json:"win_pos_str"` // calculated window position string
 
+	// Remote Desktop Gateway (empty hostname = direct connection)
+	GatewayHostname          string `json:"gateway_hostname"`
+	GatewayUsageMethod       int    `json:"gateway_usage_method"`       // 0 = never, 1 = always, 3 = default settings, 4 = auto detect
+	GatewayCredentialsSource int    `json:"gateway_credentials_source"` // 0 = password, 1 = smart card, 2 = logged-on user, 4 = select later
+	GatewayBypassLocal       bool   `json:"gateway_bypass_local"`       // skip the gateway for local addresses
+
+	// Launcher backend (mstsc, xfreerdp, wlfreerdp), empty = platform default
+	Launcher string `json:"launcher,omitempty"`
+
+	// Experience profile (ExperienceProfile.ID) setting the performance properties, empty = defaults
+	ExperienceProfile string `json:"experience_profile,omitempty"`
+
+	// Published programs that can be launched as RemoteApp instead of the full desktop
+	RemoteApps []RemoteApp `json:"remote_apps,omitempty"`
+
+	// Per-host overrides of generated RDP properties, keyed by property name (e.g. "audiomode": "2")
+	CustomProperties map[string]string `json:"custom_properties,omitempty"`
+
+	// Properties from an imported .rdp file that have no dedicated field.
+	// Keyed by lower-case property name, value in "type:value" form (e.g. "i:0").
+	ImportedProperties map[string]string `json:"imported_properties,omitempty"`
+
+	CreatedAt  time.Time `json:"created_at"`
+	ModifiedAt time.Time `json:"modified_at"`
+}
+
+// RemoteApp is a single program of a host that is launched in RemoteApp mode
+type RemoteApp struct {
+	ID               string `json:"id"`
+	Name             string `json:"name"`              // display name (remoteapplicationname)
+	Program          string `json:"program"`           // "||alias" of a published app or full path on the server
+	CommandLine      string `json:"command_line"`      // optional arguments
+	WorkingDirectory string `json:"working_directory"` // optional working directory on the server
+}
+
+// Group is a named folder hosts can be sorted into (Host.GroupID)
+type Group struct {
+	ID         string    `json:"id"`
+	Name       string    `json:"name"`
 	CreatedAt  time.Time `json:"created_at"`
 	ModifiedAt time.Time `json:"modified_at"`
 }
 
+// HistoryEntry records a single launch of a host or one of its RemoteApps
+type HistoryEntry struct {
+	ID         string    `json:"id"`
+	HostID     string    `json:"host_id"`
+	HostName   string    `json:"host_name"` // kept for entries of deleted hosts
+	AppID      string    `json:"app_id,omitempty"`
+	UserID     string    `json:"user_id"`
+	Backend    string    `json:"backend"` // launcher backend, see rdp.Backends
+	LaunchedAt time.Time `json:"launched_at"`
+}
+
+// ExperienceProfile is a named set of performance related RDP properties
+// (wallpaper, font smoothing, connection type, ...) that hosts can refer to
+type ExperienceProfile struct {
+	ID         string            `json:"id"`
+	Name       string            `json:"name"`
+	Properties map[string]string `json:"properties"` // RDP property name -> value
+	ModifiedAt time.Time         `json:"modified_at"`
+}
+
+// SigningSettings configures the certificate generated .rdp files are signed with
+type SigningSettings struct {
+	CertificatePath string `json:"certificate_path"`   // PFX/P12 or PEM file
+	KeyPath         string `json:"key_path,omitempty"` // PEM private key, empty for PFX or combined PEM files
+	Password        string `json:"password,omitempty"` // PFX password, DPAPI encrypted
+}
+
 // Users represents a collection of users
 type Users struct {
-	Users []User `json:"users"`
+	SchemaVersion int    `json:"schema_version"` // see storage.UsersSchemaVersion
+	Users         []User `json:"users"`
 }
 
 // Hosts represents a collection of hosts
 type Hosts struct {
-	Hosts []Host `json:"hosts"`
+	SchemaVersion int    `json:"schema_version"` // see storage.HostsSchemaVersion
+	Hosts         []Host `json:"hosts"`
+}
+
+// Groups represents a collection of groups
+type Groups struct {
+	Groups []Group `json:"groups"`
+}
+
+// History represents the launch history, oldest entry first
+type History struct {
+	Entries []HistoryEntry `json:"entries"`
+}
+
+// ExperienceProfiles represents a collection of experience profiles
+type ExperienceProfiles struct {
+	Profiles []ExperienceProfile `json:"profiles"`
 }
 
 // NewUser creates a new user with generated ID and timestamps
@@ -76,30 +178,121 @@ func NewUser(name, username string) User {
 func NewHost(name, address string, port int, userID string) Host {
 	now := time.Now()
 	return Host{
-		ID:                generateID(),
-		Name:              name,
-		Address:           address,
-		Port:              port,
-		UserID:            userID,
-		RedirectClipboard: true,
-		RedirectDrives:    false,
-		DrivesToRedirect:  "*",
-		DisplayMode:       "window",
-		DynamicResolution: true,
-		ScreenMode:        1,    // windowed by default
-		WindowWidth:       1200, // User-desired window size
-		WindowHeight:      800,
-		DesktopWidth:      1184, // Calculated client area (approx)
-		DesktopHeight:     761,
-		PositionX:         100,
-		PositionY:         100,
-		WinPosStr:         "0,1,100,100,1300,900", // Will be calculated based on position/size
-		CreatedAt:         now,
-		ModifiedAt:        now,
+		ID:                       generateID(),
+		Name:                     name,
+		Address:                  address,
+		Port:                     port,
+		UserID:                   userID,
+		RedirectClipboard:        true,
+		RedirectDrives:           false,
+		DrivesToRedirect:         "*",
+		DisplayMode:              "window",
+		DynamicResolution:        true,
+		ScreenMode:               1,    // windowed by default
+		WindowWidth:              1200, // User-desired window size
+		WindowHeight:             800,
+		DesktopWidth:             1184, // Calculated client area (approx)
+		DesktopHeight:            761,
+		PositionX:                100,
+		PositionY:                100,
+		WinPosStr:                "0,1,100,100,1300,900", // Will be calculated based on position/size
+		GatewayUsageMethod:       1,                      // always, once a gateway host is set
+		GatewayCredentialsSource: 4,                      // let mstsc select later
+		CreatedAt:                now,
+		ModifiedAt:               now,
+	}
+}
+
+// CredentialTarget returns the name the credential of the host is stored under
+// (TERMSRV/<target>) and mstsc connects to: the alias if set, the address otherwise
+func (h Host) CredentialTarget() string {
+	if h.CredentialAlias != "" {
+		return h.CredentialAlias
+	}
+	return h.Address
+}
+
+// Estimated mstsc window frame, used to convert between window size and desktop (client area) size
+const (
+	WindowFrameWidth  = 16 // left and right border
+	WindowFrameHeight = 59 // title bar and borders
+)
+
+// SetWindowSize sets the outer window size and the desktop size approximated from it
+func (h *Host) SetWindowSize(width, height int) {
+	h.WindowWidth = width
+	h.WindowHeight = height
+	h.DesktopWidth = width - WindowFrameWidth
+	h.DesktopHeight = height - WindowFrameHeight
+}
+
+// EffectiveWindowSize returns the outer window size. Hosts without a stored
+// window size (legacy data) get it estimated from the desktop size; the storage
+// migration of legacy hosts stores this estimate.
+func (h Host) EffectiveWindowSize() (width, height int) {
+	if h.WindowWidth == 0 || h.WindowHeight == 0 {
+		return h.DesktopWidth + WindowFrameWidth, h.DesktopHeight + WindowFrameHeight
+	}
+	return h.WindowWidth, h.WindowHeight
+}
+
+// NewRemoteApp creates a new RemoteApp entry with generated ID
+func NewRemoteApp(name, program string) RemoteApp {
+	return RemoteApp{
+		ID:      generateID(),
+		Name:    name,
+		Program: program,
+	}
+}
+
+// NewGroup creates a new group with generated ID and timestamps
+func NewGroup(name string) Group {
+	now := time.Now()
+	return Group{
+		ID:         generateID(),
+		Name:       name,
+		CreatedAt:  now,
+		ModifiedAt: now,
+	}
+}
+
+// NewHistoryEntry creates a history entry for a launch happening now
+func NewHistoryEntry(host Host, appID, userID, backend string) HistoryEntry {
+	return HistoryEntry{
+		ID:         generateID(),
+		HostID:     host.ID,
+		HostName:   host.Name,
+		AppID:      appID,
+		UserID:     userID,
+		Backend:    backend,
+		LaunchedAt: time.Now(),
 	}
 }
 
-// generateID generates a simple ID (you might want to use UUID in production)
+// NewExperienceProfile creates a new experience profile with generated ID
+func NewExperienceProfile(name string, properties map[string]string) ExperienceProfile {
+	return ExperienceProfile{
+		ID:         generateID(),
+		Name:       name,
+		Properties: properties,
+		ModifiedAt: time.Now(),
+	}
+}
+
+// lastID holds the most recently issued ID so IDs stay unique for bulk creation
+var lastID atomic.Int64
+
+// generateID generates a simple ID (you might want to use UUID in production).
+// IDs are strictly increasing even when called faster than the clock resolution.
 func generateID() string {
-	return fmt.Sprintf("%d", time.Now().UnixNano())
+	for {
+		now := time.Now().UnixNano()
+		last := lastID.Load()
+		if now <= last {
+			now = last + 1
+		}
+		if lastID.CompareAndSwap(last, now) {
+			return fmt.Sprintf("%d", now)
+		}
+	}
 }
@@ -0,0 +1,47 @@
+package models
+
+import "testing"
+
+func TestSetWindowSize(t *testing.T) {
+	var host Host
+	host.SetWindowSize(1200, 800)
+	if host.WindowWidth != 1200 || host.WindowHeight != 800 {
+		t.Errorf("window size = %dx%d, want 1200x800", host.WindowWidth, host.WindowHeight)
+	}
+	// The desktop is the window without the mstsc frame (16 x 59)
+	if host.DesktopWidth != 1184 || host.DesktopHeight != 741 {
+		t.Errorf("desktop size = %dx%d, want 1184x741", host.DesktopWidth, host.DesktopHeight)
+	}
+}
+
+func TestEffectiveWindowSize(t *testing.T) {
+	tests := []struct {
+		name          string
+		host          Host
+		width, height int
+	}{
+		{"stored", Host{WindowWidth: 1280, WindowHeight: 800, DesktopWidth: 1, DesktopHeight: 1}, 1280, 800},
+		{"legacy", Host{DesktopWidth: 1024, DesktopHeight: 768}, 1040, 827},
+		{"legacy width only", Host{WindowWidth: 1280, DesktopWidth: 1024, DesktopHeight: 768}, 1040, 827},
+		{"empty", Host{}, 16, 59},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			width, height := test.host.EffectiveWindowSize()
+			if width != test.width || height != test.height {
+				t.Errorf("EffectiveWindowSize() = %dx%d, want %dx%d", width, height, test.width, test.height)
+			}
+		})
+	}
+}
+
+// TestWindowSizeRoundTrip checks that the estimate of a legacy host matches the
+// size SetWindowSize started from
+func TestWindowSizeRoundTrip(t *testing.T) {
+	var host Host
+	host.SetWindowSize(1600, 900)
+	legacy := Host{DesktopWidth: host.DesktopWidth, DesktopHeight: host.DesktopHeight}
+	if width, height := legacy.EffectiveWindowSize(); width != 1600 || height != 900 {
+		t.Errorf("EffectiveWindowSize() = %dx%d, want 1600x900", width, height)
+	}
+}
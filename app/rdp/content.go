@@ -0,0 +1,152 @@
+package rdp
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/chrilep/LaunchRDP/app/logging"
+	"github.com/chrilep/LaunchRDP/app/models"
+)
+
+// buildRDPContent creates the RDP file content based on host and user settings.
+//
+// The content is pure string generation without Windows dependencies: lines
+// follow the registry order (grouped by setting group) and map based values are
+// sorted, so the same host always produces byte-identical output.
+func (g *Generator) buildRDPContent(host models.Host, user models.User, app *models.RemoteApp) string {
+	values := g.resolveValues(host, user, app)
+
+	var builder strings.Builder
+	renderProperties(&builder, values)
+
+	// Pass through properties kept from an imported .rdp file that the registry does not cover
+	writeImportedProperties(&builder, host.ImportedProperties)
+
+	return builder.String()
+}
+
+// resolveValues returns the value of every registered property for a connection.
+// Every property starts from its registry default (see properties.go); only the
+// host and user specific values are set here. A non-nil app starts that program
+// in RemoteApp mode instead of the full desktop.
+func (g *Generator) resolveValues(host models.Host, user models.User, app *models.RemoteApp) map[string]string {
+	debug := false
+	values := DefaultValues()
+
+	// Core connection settings
+	// mstsc looks up the credential by the address it connects to
+	values["full address"] = host.CredentialTarget()
+	values["server port"] = strconv.Itoa(host.Port)
+
+	// Username
+	values["username"] = user.Username
+
+	// Redirection & display base
+	values["redirectclipboard"] = boolValue(host.RedirectClipboard)
+	values["dynamic resolution"] = boolValue(host.DynamicResolution)
+	// Screen mode: 2 = fullscreen, 1 = windowed
+	modeID := 1
+	if strings.ToLower(host.DisplayMode) == "fullscreen" || host.ScreenMode == 2 {
+		modeID = 2
+	}
+	values["screen mode id"] = strconv.Itoa(modeID)
+
+	if modeID == 2 {
+		// Fullscreen: use current desktop metrics approximated by large size if none stored
+		w := host.DesktopWidth
+		h := host.DesktopHeight
+		if w <= 0 || h <= 0 {
+			w = 1920
+			h = 1080
+		}
+		values["desktopwidth"] = strconv.Itoa(w)
+		values["desktopheight"] = strconv.Itoa(h)
+	} else {
+		values["desktopwidth"] = strconv.Itoa(host.DesktopWidth)
+		values["desktopheight"] = strconv.Itoa(host.DesktopHeight)
+	}
+
+	// Window positioning - calculate winposstr from current values
+	// Format: "0,1,<x>,<y>,<right>,<bottom>"
+	// DEBUG: Log all host values
+	logging.Log(debug, "RDP Generator Debug - Host Values:")
+	logging.Log(debug, "  WindowWidth:", host.WindowWidth)
+	logging.Log(debug, "  WindowHeight:", host.WindowHeight)
+	logging.Log(debug, "  DesktopWidth:", host.DesktopWidth)
+	logging.Log(debug, "  DesktopHeight:", host.DesktopHeight)
+	logging.Log(debug, "  PositionX:", host.PositionX)
+	logging.Log(debug, "  PositionY:", host.PositionY)
+
+	// Legacy hosts without window size are migrated on load (see storage
+	// migrations); hosts from other sources get it estimated from the desktop size
+	windowWidth, windowHeight := host.EffectiveWindowSize()
+	windowRight := host.PositionX + windowWidth
+	windowBottom := host.PositionY + windowHeight
+	winPosStr := fmt.Sprintf("0,1,%d,%d,%d,%d", host.PositionX, host.PositionY, windowRight, windowBottom)
+
+	logging.Log(debug, "  Final winPosStr:", winPosStr)
+	values["winposstr"] = winPosStr
+
+	// Multi-monitor support: enable when fullscreen, disable for windowed mode
+	values["use multimon"] = boolValue(modeID == 2)
+	if modeID == 2 && len(host.SelectedMonitors) > 0 {
+		// Restrict the session to the selected subset of monitors
+		values["selectedmonitors"] = selectedMonitorsValue(host.SelectedMonitors)
+		logging.Log(debug, "  Selected monitors:", values["selectedmonitors"])
+	}
+
+	// Display scaling (not written unless set, mstsc then follows the local scaling)
+	applyScaling(values, host)
+
+	// Drive redirection (configurable)
+	if host.RedirectDrives {
+		values["drivestoredirect"] = drivesToRedirectValue(host.DrivesToRedirect)
+	} else {
+		values["drivestoredirect"] = ""
+	}
+
+	// Performance settings of the experience profile
+	g.applyExperienceProfile(values, host)
+
+	// Remote Desktop Gateway
+	applyGatewaySettings(values, host)
+
+	// Per-host overrides win over defaults and the values derived above
+	applyCustomProperties(values, host.CustomProperties)
+
+	// RemoteApp launch replaces the desktop shell
+	if app != nil {
+		applyRemoteApp(values, *app)
+	}
+
+	return values
+}
+
+// boolValue converts a flag to the "0"/"1" form of integer properties
+func boolValue(b bool) string {
+	if b {
+		return "1"
+	}
+	return "0"
+}
+
+// writeImportedProperties appends imported properties (sorted by name) that are
+// not in the property registry, so registered values always take precedence.
+// Dropped properties (see importer.go) are never written.
+func writeImportedProperties(builder *strings.Builder, imported map[string]string) {
+	names := make([]string, 0, len(imported))
+	for name := range imported {
+		if droppedProperties[strings.ToLower(name)] {
+			continue
+		}
+		if _, registered := LookupProperty(name); !registered {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		builder.WriteString(fmt.Sprintf("%s:%s\n", name, imported[name]))
+	}
+}
@@ -0,0 +1,197 @@
+package rdp
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/chrilep/LaunchRDP/app/models"
+)
+
+var update = flag.Bool("update", false, "rewrite the golden files in testdata")
+
+// goldenHost returns a windowed host with fixed values, the base of the golden cases
+func goldenHost() models.Host {
+	host := models.NewHost("Server", "srv.example.com", 3389, "user-1")
+	host.ID = "host-1"
+	host.PositionX = 100
+	host.PositionY = 50
+	host.SetWindowSize(1280, 800)
+	return host
+}
+
+func goldenUser() models.User {
+	return models.User{ID: "user-1", Username: `CORP\alice`}
+}
+
+// checkGolden compares content with testdata/name.rdp, or rewrites the file with -update
+func checkGolden(t *testing.T, name, content string) {
+	t.Helper()
+	path := filepath.Join("testdata", name+".rdp")
+	if *update {
+		if err := os.MkdirAll("testdata", 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+		return
+	}
+	want, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("missing golden file, run go test -update: %v", err)
+	}
+	if content != string(want) {
+		t.Errorf("content differs from %s (run go test -update after checking the change):\n%s", path, diffLines(string(want), content))
+	}
+}
+
+// diffLines lists the lines that are only in want (-) or only in got (+)
+func diffLines(want, got string) string {
+	count := map[string]int{}
+	for _, line := range strings.Split(got, "\n") {
+		count[line]++
+	}
+	var diff []string
+	for _, line := range strings.Split(want, "\n") {
+		if count[line] > 0 {
+			count[line]--
+			continue
+		}
+		diff = append(diff, "- "+line)
+	}
+	for _, line := range strings.Split(got, "\n") {
+		if count[line] > 0 {
+			count[line]--
+			diff = append(diff, "+ "+line)
+		}
+	}
+	return strings.Join(diff, "\n")
+}
+
+func TestBuildRDPContentGolden(t *testing.T) {
+	tests := []struct {
+		name   string
+		modify func(host *models.Host)
+	}{
+		{"windowed", func(host *models.Host) {}},
+		{"fullscreen", func(host *models.Host) {
+			host.DisplayMode = "fullscreen"
+			host.ScreenMode = 2
+			host.DesktopWidth = 2560
+			host.DesktopHeight = 1440
+		}},
+		{"fullscreen_no_desktop_size", func(host *models.Host) {
+			host.DisplayMode = "fullscreen"
+			host.ScreenMode = 2
+			host.DesktopWidth = 0
+			host.DesktopHeight = 0
+		}},
+		{"fullscreen_screen_mode_only", func(host *models.Host) {
+			host.DisplayMode = ""
+			host.ScreenMode = 2
+		}},
+		// Hosts of old versions only stored the desktop size
+		{"legacy_zero_window_size", func(host *models.Host) {
+			host.WindowWidth = 0
+			host.WindowHeight = 0
+			host.DesktopWidth = 1024
+			host.DesktopHeight = 768
+		}},
+		{"drives_selected", func(host *models.Host) {
+			host.RedirectDrives = true
+			host.DrivesToRedirect = "c:;D;DynamicDrives"
+		}},
+		{"drives_empty_list", func(host *models.Host) {
+			host.RedirectDrives = true
+			host.DrivesToRedirect = ""
+		}},
+		{"drives_folder_skipped", func(host *models.Host) {
+			host.RedirectDrives = true
+			host.DrivesToRedirect = `E:;Projects=C:\Projects`
+		}},
+		{"credential_alias", func(host *models.Host) {
+			host.CredentialAlias = "srv-alias.example.com"
+			host.Port = 3390
+		}},
+	}
+	for _, clipboard := range []bool{false, true} {
+		for _, drives := range []bool{false, true} {
+			for _, dynamic := range []bool{false, true} {
+				tests = append(tests, struct {
+					name   string
+					modify func(host *models.Host)
+				}{
+					fmt.Sprintf("redirect_clipboard%d_drives%d_dynamic%d", btoi(clipboard), btoi(drives), btoi(dynamic)),
+					func(host *models.Host) {
+						host.RedirectClipboard = clipboard
+						host.RedirectDrives = drives
+						host.DynamicResolution = dynamic
+					},
+				})
+			}
+		}
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			host := goldenHost()
+			test.modify(&host)
+			content := NewGenerator().buildRDPContent(host, goldenUser(), nil)
+			checkGolden(t, test.name, content)
+		})
+	}
+}
+
+func TestBuildRDPContentRemoteAppGolden(t *testing.T) {
+	app := models.RemoteApp{ID: "app-1", Name: "Notepad", Program: "||notepad", CommandLine: "/A"}
+	content := NewGenerator().buildRDPContent(goldenHost(), goldenUser(), &app)
+	checkGolden(t, "remoteapp", content)
+}
+
+func TestBuildRDPContentDeterministic(t *testing.T) {
+	host := goldenHost()
+	host.CustomProperties = map[string]string{"audiomode": "2", "keyboardhook": "1"}
+	host.ImportedProperties = map[string]string{"zz unknown": "s:z", "aa unknown": "s:a"}
+	first := NewGenerator().buildRDPContent(host, goldenUser(), nil)
+	for i := 0; i < 20; i++ {
+		if again := NewGenerator().buildRDPContent(host, goldenUser(), nil); again != first {
+			t.Fatalf("output changed between runs:\n%s", diffLines(first, again))
+		}
+	}
+	if strings.Index(first, "aa unknown:") > strings.Index(first, "zz unknown:") {
+		t.Error("imported properties are not sorted")
+	}
+}
+
+// TestWindowPosition checks winposstr, the window size fallback of legacy hosts
+// included (models.EffectiveWindowSize)
+func TestWindowPosition(t *testing.T) {
+	host := goldenHost()
+	values := NewGenerator().resolveValues(host, goldenUser(), nil)
+	if got, want := values["winposstr"], "0,1,100,50,1380,850"; got != want {
+		t.Errorf("winposstr = %q, want %q", got, want)
+	}
+	if got, want := values["desktopwidth"]+"x"+values["desktopheight"], "1264x741"; got != want {
+		t.Errorf("desktop size = %s, want %s", got, want)
+	}
+
+	host.WindowWidth = 0
+	host.WindowHeight = 0
+	host.DesktopWidth = 1024
+	host.DesktopHeight = 768
+	values = NewGenerator().resolveValues(host, goldenUser(), nil)
+	if got, want := values["winposstr"], "0,1,100,50,1140,877"; got != want {
+		t.Errorf("legacy winposstr = %q, want %q", got, want)
+	}
+}
+
+func btoi(b bool) int {
+	if b {
+		return 1
+	}
+	return 0
+}
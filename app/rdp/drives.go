@@ -0,0 +1,113 @@
+package rdp
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/chrilep/LaunchRDP/app/logging"
+)
+
+// DynamicDrives redirects drives that are connected after the session started
+const DynamicDrives = "DynamicDrives"
+
+// DriveSpec is one entry of models.Host.DrivesToRedirect.
+//
+// The stored value is a semicolon separated list:
+//
+//	"*"                 all drives (also used for empty values of legacy hosts)
+//	"C:" or "D"         a single drive letter
+//	"DynamicDrives"     drives connected later
+//	"Share=C:\Projects" a named local folder
+type DriveSpec struct {
+	Letter  string // upper-case drive letter without colon
+	Dynamic bool
+	Name    string // share name of a folder entry
+	Path    string // local path of a folder entry
+}
+
+// ParseDrivesToRedirect parses the stored drive list. all is true for "*".
+func ParseDrivesToRedirect(value string) (specs []DriveSpec, all bool, err error) {
+	value = strings.TrimSpace(value)
+	if value == "" || value == "*" {
+		return nil, true, nil
+	}
+	for _, entry := range strings.Split(value, ";") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		if entry == "*" {
+			return nil, true, nil
+		}
+		if strings.EqualFold(entry, DynamicDrives) {
+			specs = append(specs, DriveSpec{Dynamic: true})
+			continue
+		}
+		if name, path, ok := strings.Cut(entry, "="); ok {
+			name = strings.TrimSpace(name)
+			path = strings.TrimSpace(path)
+			if name == "" || path == "" {
+				return nil, false, fmt.Errorf("invalid folder entry %q, expected Name=Path", entry)
+			}
+			specs = append(specs, DriveSpec{Name: name, Path: path})
+			continue
+		}
+		letter := strings.ToUpper(strings.TrimRight(entry, `:\/`))
+		if len(letter) != 1 || letter[0] < 'A' || letter[0] > 'Z' {
+			return nil, false, fmt.Errorf("invalid drive %q, expected a drive letter like C:", entry)
+		}
+		specs = append(specs, DriveSpec{Letter: letter})
+	}
+	return specs, false, nil
+}
+
+// ValidateDrivesToRedirect checks the syntax of a stored drive list
+func ValidateDrivesToRedirect(value string) error {
+	_, _, err := ParseDrivesToRedirect(value)
+	return err
+}
+
+// ValidateDrivesForBackend checks a stored drive list and that the launcher
+// backend can redirect its entries: mstsc only drive letters, FreeRDP only folders
+func ValidateDrivesForBackend(value, backend string) error {
+	specs, _, err := ParseDrivesToRedirect(value)
+	if err != nil {
+		return err
+	}
+	for _, spec := range specs {
+		switch {
+		case backend == BackendMSTSC && spec.Name != "":
+			return fmt.Errorf("mstsc cannot redirect folder %s (%s), use a drive letter or a FreeRDP launcher", spec.Name, spec.Path)
+		case backend != BackendMSTSC && spec.Letter != "":
+			return fmt.Errorf("%s cannot redirect drive letter %s:, use a Name=Path folder entry", backend, spec.Letter)
+		}
+	}
+	return nil
+}
+
+// drivesToRedirectValue converts the stored drive list to the mstsc
+// "drivestoredirect" format ("*" or "C:;D:;DynamicDrives"). mstsc cannot
+// redirect single folders, ValidateDrivesForBackend rejects them when the host
+// is saved; folder entries of older hosts are skipped here.
+func drivesToRedirectValue(value string) string {
+	specs, all, err := ParseDrivesToRedirect(value)
+	if err != nil {
+		logging.Log(true, "Warning: invalid drives to redirect, redirecting no drives:", err)
+		return ""
+	}
+	if all {
+		return "*"
+	}
+	var parts []string
+	for _, spec := range specs {
+		switch {
+		case spec.Letter != "":
+			parts = append(parts, spec.Letter+":")
+		case spec.Dynamic:
+			parts = append(parts, DynamicDrives)
+		default:
+			logging.Log(true, "Warning: mstsc cannot redirect folder", spec.Name, "("+spec.Path+"), skipping")
+		}
+	}
+	return strings.Join(parts, ";")
+}
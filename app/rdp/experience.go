@@ -0,0 +1,128 @@
+package rdp
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/chrilep/LaunchRDP/app/logging"
+	"github.com/chrilep/LaunchRDP/app/models"
+)
+
+// IDs of the built-in experience profiles
+const (
+	ProfileLAN       = "lan"
+	ProfileBroadband = "broadband"
+	ProfileLowSpeed  = "lowspeed"
+)
+
+// DefaultExperienceProfiles returns the built-in profiles, used until the user saves own profiles.
+// Each profile sets every property of the performance group.
+func DefaultExperienceProfiles() []models.ExperienceProfile {
+	return []models.ExperienceProfile{
+		{ID: ProfileLAN, Name: "LAN", Properties: map[string]string{
+			"compression":               "1",
+			"videoplaybackmode":         "1",
+			"connection type":           "6",
+			"networkautodetect":         "0",
+			"bandwidthautodetect":       "1",
+			"disable wallpaper":         "0",
+			"allow font smoothing":      "1",
+			"allow desktop composition": "1",
+			"disable full window drag":  "0",
+			"disable menu anims":        "0",
+			"disable themes":            "0",
+			"disable cursor setting":    "0",
+			"bitmapcachepersistenable":  "1",
+		}},
+		{ID: ProfileBroadband, Name: "Broadband", Properties: map[string]string{
+			"compression":               "1",
+			"videoplaybackmode":         "1",
+			"connection type":           "4",
+			"networkautodetect":         "0",
+			"bandwidthautodetect":       "1",
+			"disable wallpaper":         "1",
+			"allow font smoothing":      "1",
+			"allow desktop composition": "1",
+			"disable full window drag":  "1",
+			"disable menu anims":        "1",
+			"disable themes":            "0",
+			"disable cursor setting":    "0",
+			"bitmapcachepersistenable":  "1",
+		}},
+		{ID: ProfileLowSpeed, Name: "Low-speed", Properties: map[string]string{
+			"compression":               "1",
+			"videoplaybackmode":         "1",
+			"connection type":           "2",
+			"networkautodetect":         "0",
+			"bandwidthautodetect":       "1",
+			"disable wallpaper":         "1",
+			"allow font smoothing":      "0",
+			"allow desktop composition": "0",
+			"disable full window drag":  "1",
+			"disable menu anims":        "1",
+			"disable themes":            "1",
+			"disable cursor setting":    "1",
+			"bitmapcachepersistenable":  "1",
+		}},
+	}
+}
+
+// PerformanceProperties returns the names of all properties an experience profile may set
+func PerformanceProperties() []string {
+	var names []string
+	for _, def := range properties {
+		if def.Group == GroupPerformance {
+			names = append(names, def.Name)
+		}
+	}
+	return names
+}
+
+// ValidateExperienceProfile checks the name and that every property belongs to the
+// performance group and has a valid value. Property names are normalized in place.
+func ValidateExperienceProfile(profile *models.ExperienceProfile) error {
+	if strings.TrimSpace(profile.Name) == "" {
+		return fmt.Errorf("experience profile needs a name")
+	}
+	normalized := make(map[string]string, len(profile.Properties))
+	for name, value := range profile.Properties {
+		def, ok := LookupProperty(name)
+		if !ok {
+			return fmt.Errorf("unknown RDP property %q", name)
+		}
+		if def.Group != GroupPerformance {
+			return fmt.Errorf("RDP property %q is not a performance setting", def.Name)
+		}
+		if err := def.Validate(value); err != nil {
+			return err
+		}
+		normalized[def.Name] = value
+	}
+	profile.Properties = normalized
+	return nil
+}
+
+// SetExperienceProfiles sets the profiles hosts can refer to by ID
+func (g *Generator) SetExperienceProfiles(profiles []models.ExperienceProfile) {
+	g.profiles = make(map[string]models.ExperienceProfile, len(profiles))
+	for _, profile := range profiles {
+		g.profiles[profile.ID] = profile
+	}
+}
+
+// applyExperienceProfile writes the performance properties of the host's profile
+func (g *Generator) applyExperienceProfile(values map[string]string, host models.Host) {
+	if host.ExperienceProfile == "" {
+		return
+	}
+	profile, ok := g.profiles[host.ExperienceProfile]
+	if !ok {
+		logging.Log(true, "Warning: experience profile", host.ExperienceProfile, "of host", host.Name, "not found, using defaults")
+		return
+	}
+	for name, value := range profile.Properties {
+		if def, ok := LookupProperty(name); ok && def.Group == GroupPerformance && def.Validate(value) == nil {
+			values[def.Name] = value
+		}
+	}
+}
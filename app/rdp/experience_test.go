@@ -0,0 +1,131 @@
+package rdp_test
+
+import (
+	"maps"
+	"slices"
+	"testing"
+
+	"github.com/chrilep/LaunchRDP/app/models"
+	"github.com/chrilep/LaunchRDP/app/rdp"
+)
+
+func TestDefaultExperienceProfiles(t *testing.T) {
+	performance := rdp.PerformanceProperties()
+	slices.Sort(performance)
+	var ids []string
+	for _, profile := range rdp.DefaultExperienceProfiles() {
+		ids = append(ids, profile.ID)
+		// Every built-in profile sets every performance property to a valid value
+		names := slices.Sorted(maps.Keys(profile.Properties))
+		if !slices.Equal(names, performance) {
+			t.Errorf("profile %s sets %v, want %v", profile.ID, names, performance)
+		}
+		copied := profile
+		copied.Properties = maps.Clone(profile.Properties)
+		if err := rdp.ValidateExperienceProfile(&copied); err != nil {
+			t.Errorf("profile %s: %v", profile.ID, err)
+		}
+	}
+	if want := []string{rdp.ProfileLAN, rdp.ProfileBroadband, rdp.ProfileLowSpeed}; !slices.Equal(ids, want) {
+		t.Errorf("built-in profile IDs %v, want %v", ids, want)
+	}
+}
+
+func TestValidateExperienceProfile(t *testing.T) {
+	tests := []struct {
+		name       string
+		profile    models.ExperienceProfile
+		properties map[string]string
+		wantErr    bool
+	}{
+		{
+			name:       "normalized names",
+			profile:    models.ExperienceProfile{Name: "Office", Properties: map[string]string{"Disable Wallpaper": "1", " compression ": "0"}},
+			properties: map[string]string{"disable wallpaper": "1", "compression": "0"},
+		},
+		{
+			name:       "no properties",
+			profile:    models.ExperienceProfile{Name: "Empty"},
+			properties: map[string]string{},
+		},
+		{name: "missing name", profile: models.ExperienceProfile{Name: "  ", Properties: map[string]string{"compression": "1"}}, wantErr: true},
+		{name: "unknown property", profile: models.ExperienceProfile{Name: "X", Properties: map[string]string{"turbo": "1"}}, wantErr: true},
+		{name: "not a performance property", profile: models.ExperienceProfile{Name: "X", Properties: map[string]string{"audiomode": "1"}}, wantErr: true},
+		{name: "reserved property", profile: models.ExperienceProfile{Name: "X", Properties: map[string]string{"full address": "srv"}}, wantErr: true},
+		{name: "value not allowed", profile: models.ExperienceProfile{Name: "X", Properties: map[string]string{"connection type": "8"}}, wantErr: true},
+		{name: "integer expected", profile: models.ExperienceProfile{Name: "X", Properties: map[string]string{"disable themes": "yes"}}, wantErr: true},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			profile := test.profile
+			err := rdp.ValidateExperienceProfile(&profile)
+			if test.wantErr {
+				if err == nil {
+					t.Error("ValidateExperienceProfile() = nil, want an error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !maps.Equal(profile.Properties, test.properties) {
+				t.Errorf("properties = %v, want %v", profile.Properties, test.properties)
+			}
+		})
+	}
+}
+
+func TestExperienceProfileProperties(t *testing.T) {
+	generator, _, _ := newTestGenerator(t)
+	custom := models.ExperienceProfile{ID: "custom", Name: "Custom", Properties: map[string]string{
+		"connection type":   "5",
+		"disable wallpaper": "1",
+		"audiomode":         "2", // not a performance property, ignored
+		"disable themes":    "yes",
+	}}
+	generator.SetExperienceProfiles(append(rdp.DefaultExperienceProfiles(), custom))
+	builtIn := make(map[string]map[string]string)
+	for _, profile := range rdp.DefaultExperienceProfiles() {
+		builtIn[profile.ID] = profile.Properties
+	}
+
+	tests := []struct {
+		name     string
+		profile  string
+		override map[string]string
+		want     map[string]string
+	}{
+		{name: "defaults", profile: "", want: map[string]string{"connection type": "7", "disable wallpaper": "0", "networkautodetect": "1"}},
+		{name: "lan", profile: rdp.ProfileLAN, want: builtIn[rdp.ProfileLAN]},
+		{name: "broadband", profile: rdp.ProfileBroadband, want: builtIn[rdp.ProfileBroadband]},
+		{name: "low-speed", profile: rdp.ProfileLowSpeed, want: builtIn[rdp.ProfileLowSpeed]},
+		{name: "unknown profile uses defaults", profile: "deleted", want: map[string]string{"connection type": "7", "disable wallpaper": "0"}},
+		{
+			name:    "invalid entries skipped",
+			profile: "custom",
+			want:    map[string]string{"connection type": "5", "disable wallpaper": "1", "audiomode": "0", "disable themes": "0"},
+		},
+		{
+			name:     "host overrides win",
+			profile:  rdp.ProfileLowSpeed,
+			override: map[string]string{"connection type": "6", "disable themes": "0"},
+			want:     map[string]string{"connection type": "6", "disable themes": "0", "disable wallpaper": "1", "allow font smoothing": "0"},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			host := testHost(rdp.BackendMSTSC)
+			host.ExperienceProfile = test.profile
+			host.CustomProperties = test.override
+			file, err := rdp.Parse([]byte(rdp.BuildRDPContent(generator, host, testUser(), nil)))
+			if err != nil {
+				t.Fatal(err)
+			}
+			for name, want := range test.want {
+				if got, _ := file.GetString(name); got != want {
+					t.Errorf("%s = %q, want %q", name, got, want)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,4 @@
+package rdp
+
+// BuildRDPContent exposes the content generation to the external tests
+var BuildRDPContent = (*Generator).buildRDPContent
@@ -0,0 +1,316 @@
+package rdp
+
+import (
+	"fmt"
+	"runtime"
+	"strings"
+
+	"github.com/chrilep/LaunchRDP/app/logging"
+	"github.com/chrilep/LaunchRDP/app/models"
+)
+
+// Launcher backends, stored in models.Host.Launcher
+const (
+	BackendMSTSC     = "mstsc"     // Windows Remote Desktop Connection with a generated .rdp file
+	BackendXFreeRDP  = "xfreerdp"  // FreeRDP X11 client
+	BackendWLFreeRDP = "wlfreerdp" // FreeRDP Wayland client
+)
+
+var backends = []string{BackendMSTSC, BackendXFreeRDP, BackendWLFreeRDP}
+
+// Backends returns the names of all launcher backends
+func Backends() []string {
+	return append([]string{}, backends...)
+}
+
+// ValidateBackend checks a per-host launcher backend (empty = platform default)
+func ValidateBackend(name string) error {
+	if name == "" {
+		return nil
+	}
+	for _, backend := range backends {
+		if name == backend {
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown launcher backend %q (allowed: %s)", name, strings.Join(backends, ", "))
+}
+
+// DefaultBackend returns the launcher backend of the current platform
+func DefaultBackend() string {
+	if runtime.GOOS == "windows" {
+		return BackendMSTSC
+	}
+	return BackendXFreeRDP
+}
+
+// HostBackend returns the launcher backend used for a host
+func HostBackend(host models.Host) string {
+	if host.Launcher == "" {
+		return DefaultBackend()
+	}
+	return host.Launcher
+}
+
+// SetPasswordCallback sets the function returning the plain password of a user.
+// FreeRDP backends need it, mstsc reads the password from the Credential Manager.
+func (g *Generator) SetPasswordCallback(callback func(user models.User) (string, error)) {
+	g.PasswordCallback = callback
+}
+
+// FreeRDPArgs translates host and user settings to FreeRDP command-line arguments.
+// The arguments are derived from the same property values as the .rdp file, so
+// experience profiles and custom properties apply to both backends. The password
+// is never part of the arguments; with a stored password "/from-stdin" makes
+// FreeRDP read it from stdin.
+func (g *Generator) FreeRDPArgs(host models.Host, user models.User, app *models.RemoteApp) []string {
+	values := g.resolveValues(host, user, app)
+	return freeRDPArgs(values, host, user)
+}
+
+// freeRDPArgs builds the FreeRDP 3 arguments from resolved property values
+func freeRDPArgs(values map[string]string, host models.Host, user models.User) []string {
+	// The credential alias only matters to mstsc, FreeRDP receives the password itself
+	args := []string{"/v:" + host.Address, "/port:" + values["server port"]}
+
+	// Credentials: login and domain as arguments, the password via stdin
+	login, domain := freeRDPLogin(user)
+	if login != "" {
+		args = append(args, "/u:"+login)
+	}
+	if domain != "" {
+		args = append(args, "/d:"+domain)
+	}
+	gateway := freeRDPGatewayArg(values)
+	if user.EncryptedPassword != "" {
+		if gateway != "" {
+			// Read the password before connecting, the gateway signs in with it first
+			args = append(args, "/from-stdin:force")
+		} else {
+			args = append(args, "/from-stdin")
+		}
+	}
+
+	// Display
+	if values["screen mode id"] == "2" {
+		args = append(args, "/f")
+		if values["use multimon"] == "1" {
+			if values["selectedmonitors"] != "" {
+				args = append(args, "/monitors:"+values["selectedmonitors"])
+			}
+			args = append(args, "/multimon")
+		}
+	} else {
+		args = append(args, "/size:"+values["desktopwidth"]+"x"+values["desktopheight"])
+		if x, y, ok := winPosOrigin(values["winposstr"]); ok {
+			args = append(args, fmt.Sprintf("/window-position:%dx%d", x, y))
+		}
+	}
+	// FreeRDP rejects dynamic resolution together with smart sizing
+	if values["dynamic resolution"] == "1" {
+		args = append(args, "/dynamic-resolution")
+	} else if values["smart sizing"] == "1" {
+		args = append(args, "/smart-sizing")
+	}
+	if values["desktopscalefactor"] != "" {
+		args = append(args, "/scale-desktop:"+values["desktopscalefactor"])
+	}
+	if values["devicescalefactor"] != "" {
+		args = append(args, "/scale:"+values["devicescalefactor"])
+	}
+
+	// Performance (after /network, which sets its own defaults)
+	args = append(args, "/network:"+freeRDPNetwork(values))
+	args = append(args,
+		toggleArg("wallpaper", values["disable wallpaper"] != "1"),
+		toggleArg("fonts", values["allow font smoothing"] == "1"),
+		toggleArg("aero", values["allow desktop composition"] == "1"),
+		toggleArg("window-drag", values["disable full window drag"] != "1"),
+		toggleArg("menu-anims", values["disable menu anims"] != "1"),
+		toggleArg("themes", values["disable themes"] != "1"),
+		toggleArg("compression", values["compression"] == "1"),
+	)
+
+	// Redirection
+	args = append(args, toggleArg("clipboard", values["redirectclipboard"] == "1"))
+	if host.RedirectDrives {
+		args = append(args, freeRDPDriveArgs(host.DrivesToRedirect)...)
+	}
+	switch values["audiomode"] {
+	case "0":
+		args = append(args, "/sound")
+	case "1":
+		args = append(args, "/audio-mode:1")
+	case "2":
+		args = append(args, "/audio-mode:2")
+	}
+	if values["audiocapturemode"] == "1" {
+		args = append(args, "/microphone")
+	}
+	if values["redirectprinters"] == "1" {
+		args = append(args, "/printer")
+	}
+	if values["redirectsmartcards"] == "1" {
+		args = append(args, "/smartcard")
+	}
+	if values["autoreconnection enabled"] == "1" {
+		args = append(args, "/auto-reconnect")
+	}
+	// Authentication level 0 connects without certificate warning, like mstsc
+	if values["authentication level"] == "0" {
+		args = append(args, "/cert:ignore")
+	}
+
+	// Remote Desktop Gateway
+	if gateway != "" {
+		args = append(args, gateway)
+	}
+
+	// RemoteApp
+	if values["remoteapplicationmode"] == "1" {
+		args = append(args, freeRDPAppArg(values))
+	}
+
+	return args
+}
+
+// freeRDPLogin returns login name and domain, falling back to a DOMAIN\user username
+func freeRDPLogin(user models.User) (login, domain string) {
+	if user.Login != "" {
+		return user.Login, user.Domain
+	}
+	if domain, login, ok := strings.Cut(user.Username, `\`); ok {
+		return login, domain
+	}
+	return user.Username, user.Domain
+}
+
+// winPosOrigin returns the left/top corner of a "0,1,left,top,right,bottom" winposstr
+func winPosOrigin(winPosStr string) (x, y int, ok bool) {
+	var mode, show, right, bottom int
+	if _, err := fmt.Sscanf(winPosStr, "%d,%d,%d,%d,%d,%d", &mode, &show, &x, &y, &right, &bottom); err != nil {
+		return 0, 0, false
+	}
+	return x, y, true
+}
+
+// freeRDPNetworks maps "connection type" to the FreeRDP /network values
+var freeRDPNetworks = map[string]string{
+	"1": "modem",
+	"2": "broadband-low",
+	"3": "broadband",
+	"4": "broadband-high",
+	"5": "wan",
+	"6": "lan",
+	"7": "auto",
+}
+
+func freeRDPNetwork(values map[string]string) string {
+	if network, ok := freeRDPNetworks[values["connection type"]]; ok && values["networkautodetect"] != "1" {
+		return network
+	}
+	return "auto"
+}
+
+// freeRDPGatewayMethods maps "gatewayusagemethod" to FreeRDP usage methods (0 = no gateway)
+var freeRDPGatewayMethods = map[string]string{
+	"1": "direct",
+	"2": "detect",
+	"3": "default",
+	"4": "detect",
+}
+
+// freeRDPGatewayArg builds the /gateway argument, empty without a gateway. It has
+// no u:, d: or p: options, so FreeRDP signs in to the gateway with the session
+// credentials (see ValidateGatewayForBackend).
+func freeRDPGatewayArg(values map[string]string) string {
+	gateway := values["gatewayhostname"]
+	if gateway == "" {
+		return ""
+	}
+	method, ok := freeRDPGatewayMethods[values["gatewayusagemethod"]]
+	if !ok {
+		return ""
+	}
+	return "/gateway:g:" + gateway + ",usage-method:" + method
+}
+
+// freeRDPDriveArgs converts the stored drive list. Drive letters do not exist off
+// Windows, so "*" shares all mount points and folders are shared by name.
+func freeRDPDriveArgs(value string) []string {
+	specs, all, err := ParseDrivesToRedirect(value)
+	if err != nil {
+		logging.Log(true, "Warning: invalid drives to redirect, redirecting no drives:", err)
+		return nil
+	}
+	if all {
+		return []string{"/drives"}
+	}
+	var args []string
+	for _, spec := range specs {
+		switch {
+		case spec.Dynamic:
+			args = append(args, "/drive:hotplug,*")
+		case spec.Path != "":
+			args = append(args, "/drive:"+spec.Name+","+spec.Path)
+		default:
+			logging.Log(true, "Warning: FreeRDP cannot redirect drive letter", spec.Letter+":", "- use a Name=Path folder entry")
+		}
+	}
+	return args
+}
+
+// freeRDPAppArg builds the /app argument of a RemoteApp launch
+func freeRDPAppArg(values map[string]string) string {
+	options := []string{"program:" + values["remoteapplicationprogram"]}
+	for _, option := range []struct{ key, property string }{
+		{"name", "remoteapplicationname"},
+		{"cmd", "remoteapplicationcmdline"},
+		{"workdir", "shell working directory"},
+	} {
+		if value := values[option.property]; value != "" {
+			if strings.Contains(value, ",") {
+				logging.Log(true, "Warning: FreeRDP splits /app options at commas, check", option.property, "value", value)
+			}
+			options = append(options, option.key+":"+value)
+		}
+	}
+	return "/app:" + strings.Join(options, ",")
+}
+
+func toggleArg(name string, enabled bool) string {
+	if enabled {
+		return "+" + name
+	}
+	return "-" + name
+}
+
+// launchFreeRDP starts a FreeRDP client. The password is written to stdin so it
+// never shows up in the process list. Existing FreeRDP windows are not reused.
+func (g *Generator) launchFreeRDP(backend string, host models.Host, user models.User, app *models.RemoteApp) error {
+	debug := false
+	if err := ValidateGatewayForBackend(host.GatewayHostname, host.GatewayCredentialsSource, backend); err != nil {
+		return err
+	}
+	args := g.FreeRDPArgs(host, user, app)
+	logging.Log(debug, "Launching", backend, "with arguments:", strings.Join(args, " "))
+
+	stdin := ""
+	if user.EncryptedPassword != "" {
+		if g.PasswordCallback == nil {
+			return fmt.Errorf("no password source configured for %s", backend)
+		}
+		password, err := g.PasswordCallback(user)
+		if err != nil {
+			return fmt.Errorf("failed to read password of user %s: %w", user.Username, err)
+		}
+		stdin = password + "\n"
+	}
+
+	if err := g.launcher.Start(backend, args, stdin); err != nil {
+		logging.Log(true, "ERROR: Failed to launch", backend+":", err)
+		return fmt.Errorf("failed to launch %s: %w", backend, err)
+	}
+	logging.Log(debug, backend, "process started successfully")
+	return nil
+}
@@ -0,0 +1,188 @@
+package rdp_test
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/chrilep/LaunchRDP/app/models"
+	"github.com/chrilep/LaunchRDP/app/rdp"
+)
+
+func TestFreeRDPArgs(t *testing.T) {
+	// Argument groups in the order FreeRDPArgs writes them, for the defaults of testHost
+	connect := []string{"/v:srv.example.com", "/port:3389", "/u:alice", "/d:CORP", "/from-stdin"}
+	window := []string{"/size:1184x761", "/window-position:100x100", "/dynamic-resolution"}
+	performance := []string{"/network:auto", "+wallpaper", "-fonts", "-aero", "-window-drag", "-menu-anims", "+themes", "+compression"}
+	devices := []string{"/sound", "/microphone", "/printer", "/smartcard", "/auto-reconnect"}
+
+	tests := []struct {
+		name string
+		host func(*models.Host)
+		user func(*models.User)
+		app  *models.RemoteApp
+		want []string
+	}{
+		{
+			name: "defaults",
+			want: slices.Concat(connect, window, performance, []string{"+clipboard"}, devices),
+		},
+		{
+			name: "port and user without domain or password",
+			host: func(h *models.Host) { h.Port = 3390 },
+			user: func(u *models.User) { u.Username, u.Login, u.Domain, u.EncryptedPassword = "bob", "", "", "" },
+			want: slices.Concat([]string{"/v:srv.example.com", "/port:3390", "/u:bob"}, window, performance, []string{"+clipboard"}, devices),
+		},
+		{
+			name: "window size and position",
+			host: func(h *models.Host) {
+				h.PositionX, h.PositionY = 20, 40
+				h.SetWindowSize(1040, 827)
+				h.DynamicResolution = false
+			},
+			want: slices.Concat(connect, []string{"/size:1024x768", "/window-position:20x40"}, performance, []string{"+clipboard"}, devices),
+		},
+		{
+			name: "smart sizing and scaling",
+			host: func(h *models.Host) {
+				h.DynamicResolution, h.SmartSizing = false, true
+				h.DesktopScaleFactor, h.DeviceScaleFactor = 125, 140
+			},
+			want: slices.Concat(connect, []string{"/size:1184x761", "/window-position:100x100", "/smart-sizing", "/scale-desktop:125", "/scale:140"},
+				performance, []string{"+clipboard"}, devices),
+		},
+		{
+			name: "fullscreen on all monitors",
+			host: func(h *models.Host) { h.DisplayMode, h.ScreenMode, h.DynamicResolution = "fullscreen", 2, false },
+			want: slices.Concat(connect, []string{"/f", "/multimon"}, performance, []string{"+clipboard"}, devices),
+		},
+		{
+			name: "fullscreen on selected monitors",
+			host: func(h *models.Host) {
+				h.DisplayMode, h.ScreenMode = "fullscreen", 2
+				h.SelectedMonitors = []int{2, 0}
+			},
+			want: slices.Concat(connect, []string{"/f", "/monitors:0,2", "/multimon", "/dynamic-resolution"}, performance, []string{"+clipboard"}, devices),
+		},
+		{
+			name: "experience profile",
+			host: func(h *models.Host) { h.ExperienceProfile = rdp.ProfileLowSpeed },
+			want: slices.Concat(connect, window,
+				[]string{"/network:broadband-low", "-wallpaper", "-fonts", "-aero", "-window-drag", "-menu-anims", "-themes", "+compression"},
+				[]string{"+clipboard"}, devices),
+		},
+		{
+			name: "redirection off",
+			host: func(h *models.Host) {
+				h.RedirectClipboard = false
+				h.CustomProperties = map[string]string{
+					"audiomode":                "2",
+					"audiocapturemode":         "0",
+					"redirectprinters":         "0",
+					"redirectsmartcards":       "0",
+					"autoreconnection enabled": "0",
+				}
+			},
+			want: slices.Concat(connect, window, performance, []string{"-clipboard", "/audio-mode:2"}),
+		},
+		{
+			name: "all drives and remote audio",
+			host: func(h *models.Host) {
+				h.RedirectDrives, h.DrivesToRedirect = true, "*"
+				h.CustomProperties = map[string]string{"audiomode": "1"}
+			},
+			want: slices.Concat(connect, window, performance, []string{"+clipboard", "/drives", "/audio-mode:1"}, devices[1:]),
+		},
+		{
+			name: "folders and dynamic drives",
+			host: func(h *models.Host) {
+				h.RedirectDrives, h.DrivesToRedirect = true, "Docs=/home/alice/docs;C:;DynamicDrives"
+			},
+			want: slices.Concat(connect, window, performance,
+				[]string{"+clipboard", "/drive:Docs,/home/alice/docs", "/drive:hotplug,*"}, devices),
+		},
+		{
+			name: "certificate ignored",
+			host: func(h *models.Host) { h.CustomProperties = map[string]string{"authentication level": "0"} },
+			want: slices.Concat(connect, window, performance, []string{"+clipboard"}, devices, []string{"/cert:ignore"}),
+		},
+		{
+			name: "gateway",
+			host: func(h *models.Host) { h.GatewayHostname = "gw.example.com" },
+			want: slices.Concat(connect[:4], []string{"/from-stdin:force"}, window, performance, []string{"+clipboard"}, devices,
+				[]string{"/gateway:g:gw.example.com,usage-method:direct"}),
+		},
+		{
+			name: "gateway bypassed for local addresses",
+			host: func(h *models.Host) { h.GatewayHostname, h.GatewayBypassLocal = "gw.example.com", true },
+			want: slices.Concat(connect[:4], []string{"/from-stdin:force"}, window, performance, []string{"+clipboard"}, devices,
+				[]string{"/gateway:g:gw.example.com,usage-method:detect"}),
+		},
+		{
+			name: "gateway never used",
+			host: func(h *models.Host) {
+				h.GatewayHostname, h.GatewayUsageMethod = "gw.example.com", rdp.GatewayUsageNever
+			},
+			want: slices.Concat(connect, window, performance, []string{"+clipboard"}, devices),
+		},
+		{
+			name: "remoteapp",
+			app:  &models.RemoteApp{Name: "Calculator", Program: "||calc", CommandLine: "/mode:scientific", WorkingDirectory: `C:\Tools`},
+			want: slices.Concat(connect, window, performance, []string{"+clipboard"}, devices,
+				[]string{`/app:program:||calc,name:Calculator,cmd:/mode:scientific,workdir:C:\Tools`}),
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			generator, _, _ := newTestGenerator(t)
+			generator.SetExperienceProfiles(rdp.DefaultExperienceProfiles())
+			host, user := testHost(rdp.BackendXFreeRDP), testUser()
+			if test.host != nil {
+				test.host(&host)
+			}
+			if test.user != nil {
+				test.user(&user)
+			}
+			if got := generator.FreeRDPArgs(host, user, test.app); !slices.Equal(got, test.want) {
+				t.Errorf("FreeRDPArgs() =\n%q\nwant\n%q", got, test.want)
+			}
+		})
+	}
+}
+
+func TestValidateGatewayForBackend(t *testing.T) {
+	tests := []struct {
+		gateway string
+		source  int
+		backend string
+		wantErr bool
+	}{
+		{"", rdp.GatewayCredentialsSmartCard, rdp.BackendXFreeRDP, false},
+		{"gw.example.com", rdp.GatewayCredentialsPassword, rdp.BackendXFreeRDP, false},
+		{"gw.example.com", rdp.GatewayCredentialsSelectLater, rdp.BackendWLFreeRDP, false},
+		{"gw.example.com", rdp.GatewayCredentialsSmartCard, rdp.BackendMSTSC, false},
+		{"gw.example.com", rdp.GatewayCredentialsSmartCard, rdp.BackendXFreeRDP, true},
+		{"gw.example.com", rdp.GatewayCredentialsLoggedOn, rdp.BackendXFreeRDP, true},
+		{"gw.example.com", rdp.GatewayCredentialsPrompt, rdp.BackendWLFreeRDP, true},
+		{"gw.example.com", rdp.GatewayCredentialsCookie, rdp.BackendXFreeRDP, true},
+	}
+	for _, test := range tests {
+		err := rdp.ValidateGatewayForBackend(test.gateway, test.source, test.backend)
+		if (err != nil) != test.wantErr {
+			t.Errorf("ValidateGatewayForBackend(%q, %d, %s) = %v, want error %v", test.gateway, test.source, test.backend, err, test.wantErr)
+		}
+	}
+}
+
+func TestLaunchHostFreeRDPRejectsGatewaySmartCard(t *testing.T) {
+	generator, launcher, _ := newTestGenerator(t)
+	generator.SetPasswordCallback(func(models.User) (string, error) { return "secret", nil })
+	host := testHost(rdp.BackendXFreeRDP)
+	host.GatewayHostname, host.GatewayCredentialsSource = "gw.example.com", rdp.GatewayCredentialsSmartCard
+
+	if _, err := generator.LaunchHost(host, testUser()); err == nil {
+		t.Error("launch with a smart card gateway succeeded")
+	}
+	if len(launcher.Launches()) != 0 {
+		t.Errorf("launches %+v", launcher.Launches())
+	}
+}
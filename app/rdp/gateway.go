@@ -0,0 +1,75 @@
+package rdp
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/chrilep/LaunchRDP/app/models"
+)
+
+// Gateway usage methods (gatewayusagemethod)
+const (
+	GatewayUsageNever       = 0 // connect directly
+	GatewayUsageAlways      = 1 // always use the configured gateway
+	GatewayUsageBypassLocal = 2 // use the gateway, but not for local addresses
+	GatewayUsageDefault     = 3 // use the gateway settings from group policy
+	GatewayUsageAutoDetect  = 4 // detect gateway settings automatically
+)
+
+// Gateway credential sources (gatewaycredentialssource)
+const (
+	GatewayCredentialsPassword    = 0 // user name and password (NTLM)
+	GatewayCredentialsSmartCard   = 1
+	GatewayCredentialsLoggedOn    = 2 // credentials of the logged-on Windows user
+	GatewayCredentialsPrompt      = 3
+	GatewayCredentialsSelectLater = 4 // mstsc asks when connecting
+	GatewayCredentialsCookie      = 5
+)
+
+// ValidateGatewaySettings checks usage method and credential source against the property registry
+func ValidateGatewaySettings(usageMethod, credentialsSource int) error {
+	def, _ := LookupProperty("gatewayusagemethod")
+	if err := def.Validate(strconv.Itoa(usageMethod)); err != nil {
+		return err
+	}
+	def, _ = LookupProperty("gatewaycredentialssource")
+	return def.Validate(strconv.Itoa(credentialsSource))
+}
+
+// ValidateGatewayForBackend checks that the launcher backend can sign in to the
+// gateway. FreeRDP signs in to the gateway with the host user's credentials (the
+// password from stdin), it cannot use a smart card, the logged-on user, a prompt
+// or a cookie, and LaunchRDP stores no separate gateway login.
+func ValidateGatewayForBackend(gatewayHostname string, credentialsSource int, backend string) error {
+	if gatewayHostname == "" || backend == BackendMSTSC {
+		return nil
+	}
+	if credentialsSource != GatewayCredentialsPassword && credentialsSource != GatewayCredentialsSelectLater {
+		return fmt.Errorf("%s can only sign in to gateway %s with the host user's password (gateway credentials source %d needs mstsc)",
+			backend, gatewayHostname, credentialsSource)
+	}
+	return nil
+}
+
+// gatewayUsageMethod resolves the stored usage method and bypass flag to the
+// value written to the .rdp file. 0 means "never", also for hosts with a gateway.
+func gatewayUsageMethod(host models.Host) int {
+	usage := host.GatewayUsageMethod
+	if usage == GatewayUsageAlways && host.GatewayBypassLocal {
+		usage = GatewayUsageBypassLocal
+	}
+	return usage
+}
+
+// applyGatewaySettings writes the gateway properties of a host. Without a
+// gateway host name the registry defaults (direct connection) are kept.
+func applyGatewaySettings(values map[string]string, host models.Host) {
+	if host.GatewayHostname == "" {
+		return
+	}
+	values["gatewayhostname"] = host.GatewayHostname
+	values["gatewayusagemethod"] = strconv.Itoa(gatewayUsageMethod(host))
+	values["gatewaycredentialssource"] = strconv.Itoa(host.GatewayCredentialsSource)
+	// Use the explicit settings above instead of the default gateway profile
+	values["gatewayprofileusagemethod"] = "1"
+}
@@ -3,42 +3,32 @@ package rdp
 import (
 	"fmt"
 	"os"
-	"os/exec"
 	"path/filepath"
 	"strings"
-	"syscall"
-	"unsafe"
 
 	"github.com/chrilep/LaunchRDP/app/config"
 	"github.com/chrilep/LaunchRDP/app/logging"
 	"github.com/chrilep/LaunchRDP/app/models"
 )
 
-// Windows API declarations for window enumeration
-var (
-	user32                  = syscall.NewLazyDLL("user32.dll")
-	procEnumWindows         = user32.NewProc("EnumWindows")
-	procGetWindowTextW      = user32.NewProc("GetWindowTextW")
-	procGetClassNameW       = user32.NewProc("GetClassNameW")
-	procIsWindowVisible     = user32.NewProc("IsWindowVisible")
-	procSetForegroundWindow = user32.NewProc("SetForegroundWindow")
-	procShowWindow          = user32.NewProc("ShowWindow")
-	procIsIconic            = user32.NewProc("IsIconic")
-)
-
-const (
-	SW_RESTORE = 9
-)
-
 // Generator handles RDP file generation and launching
 type Generator struct {
 	// Callback function to save user after password migration
 	SaveUserCallback func(user models.User) error
+	// Callback function returning the plain password of a user (FreeRDP backends)
+	PasswordCallback func(user models.User) (string, error)
+	// Experience profiles by ID, see SetExperienceProfiles
+	profiles map[string]models.ExperienceProfile
+	// Signs generated files when set, see SetSigner
+	signer *Signer
+	// Process start and window lookup, see SetLauncher and SetWindowFinder
+	launcher Launcher
+	windows  WindowFinder
 }
 
 // NewGenerator creates a new RDP generator
 func NewGenerator() *Generator {
-	return &Generator{}
+	return &Generator{launcher: execLauncher{}, windows: defaultWindowFinder()}
 }
 
 // SetSaveUserCallback sets the callback function for saving users after password migration
@@ -53,238 +43,55 @@ func (g *Generator) GenerateRDPFile(host models.Host, user models.User) (string,
 
 	// Use host ID for safe filename - avoids issues with special characters in usernames/hostnames
 	filename := fmt.Sprintf("%s.rdp", host.ID)
-	filepath := config.GetTempPath(filename)
-	logging.Log(debug, "RDP file path (using host ID for safety):", filepath)
 
 	// Build RDP file content
 	logging.Log(debug, "Building RDP content")
-	content := g.buildRDPContent(host, user)
+	content := g.buildRDPContent(host, user, nil)
 	logging.Log(debug, "RDP content built, length:", len(content), "bytes")
 
-	// Write to temporary file
-	logging.Log(debug, "Writing RDP file to disk")
-	if err := os.WriteFile(filepath, []byte(content), 0644); err != nil {
-		logging.Log(true, "ERROR: Failed to write RDP file:", err)
-		return "", fmt.Errorf("failed to write RDP file: %w", err)
+	content, err := g.signContent(content)
+	if err != nil {
+		return "", err
 	}
-	logging.Log(debug, "RDP file written successfully")
-
-	return filepath, nil
+	return writeTempRDPFile(filename, content)
 }
 
-// buildRDPContent creates the RDP file content based on host and user settings
-func (g *Generator) buildRDPContent(host models.Host, user models.User) string {
+// GenerateRemoteAppFile creates a temporary RDP file that starts a single RemoteApp program
+func (g *Generator) GenerateRemoteAppFile(host models.Host, user models.User, app models.RemoteApp) (string, error) {
 	debug := false
-	var builder strings.Builder
-
-	// Core connection settings
-	builder.WriteString(fmt.Sprintf("full address:s:%s\n", host.Address))
-	builder.WriteString(fmt.Sprintf("server port:i:%d\n", host.Port))
-
-	// Username
-	builder.WriteString(fmt.Sprintf("username:s:%s\n", user.Username))
-
-	// Redirection & display base
-	if host.RedirectClipboard {
-		builder.WriteString("redirectclipboard:i:1\n")
-	} else {
-		builder.WriteString("redirectclipboard:i:0\n")
-	}
-	// Dynamic resolution off for fixed window; could be toggled later
-	builder.WriteString("dynamic resolution:i:0\n")
-	// Screen mode: 2 = fullscreen, 1 = windowed
-	modeID := 1
-	if strings.ToLower(host.DisplayMode) == "fullscreen" || host.ScreenMode == 2 {
-		modeID = 2
-	}
-	builder.WriteString(fmt.Sprintf("screen mode id:i:%d\n", modeID))
-
-	if modeID == 2 {
-		// Fullscreen: use current desktop metrics approximated by large size if none stored
-		w := host.DesktopWidth
-		h := host.DesktopHeight
-		if w <= 0 || h <= 0 {
-			w = 1920
-			h = 1080
-		}
-		builder.WriteString(fmt.Sprintf("desktopwidth:i:%d\n", w))
-		builder.WriteString(fmt.Sprintf("desktopheight:i:%d\n", h))
-	} else {
-		builder.WriteString(fmt.Sprintf("desktopwidth:i:%d\n", host.DesktopWidth))
-		builder.WriteString(fmt.Sprintf("desktopheight:i:%d\n", host.DesktopHeight))
-	}
+	logging.Log(debug, "GenerateRemoteAppFile started for host:", host.Name, "app:", app.Name, "user:", user.Username)
 
-	// Window positioning - calculate winposstr from current values
-	// Format: "0,1,<x>,<y>,<right>,<bottom>"
-	windowWidth := host.WindowWidth
-	windowHeight := host.WindowHeight
-
-	// DEBUG: Log all host values
-	logging.Log(debug, "RDP Generator Debug - Host Values:")
-	logging.Log(debug, "  WindowWidth:", windowWidth)
-	logging.Log(debug, "  WindowHeight:", windowHeight)
-	logging.Log(debug, "  DesktopWidth:", host.DesktopWidth)
-	logging.Log(debug, "  DesktopHeight:", host.DesktopHeight)
-	logging.Log(debug, "  PositionX:", host.PositionX)
-	logging.Log(debug, "  PositionY:", host.PositionY)
-
-	// Fallback if window dimensions are not set (legacy data or missing values)
-	if windowWidth == 0 || windowHeight == 0 {
-		logging.Log(debug, "  Using fallback calculation for window size")
-		// Estimate window size from desktop size (add typical border sizes)
-		windowWidth = host.DesktopWidth + 16   // Add estimated horizontal borders
-		windowHeight = host.DesktopHeight + 59 // Add estimated title bar + borders
-		logging.Log(debug, "  Calculated WindowWidth:", windowWidth)
-		logging.Log(debug, "  Calculated WindowHeight:", windowHeight)
-	}
-
-	windowRight := host.PositionX + windowWidth
-	windowBottom := host.PositionY + windowHeight
-	winPosStr := fmt.Sprintf("0,1,%d,%d,%d,%d", host.PositionX, host.PositionY, windowRight, windowBottom)
-
-	logging.Log(debug, "  Final winPosStr:", winPosStr)
-	builder.WriteString(fmt.Sprintf("winposstr:s:%s\n", winPosStr))
-
-	// Display and performance settings
-	// Multi-monitor support: enable when fullscreen, disable for windowed mode
-	if modeID == 2 {
-		builder.WriteString("use multimon:i:1\n")
-	} else {
-		builder.WriteString("use multimon:i:0\n")
-	}
-	builder.WriteString("session bpp:i:32\n")
-	builder.WriteString("compression:i:1\n")
-	builder.WriteString("keyboardhook:i:1\n")
-	builder.WriteString("audiocapturemode:i:1\n")
-	builder.WriteString("videoplaybackmode:i:1\n")
-	builder.WriteString("connection type:i:7\n")
-	builder.WriteString("networkautodetect:i:1\n")
-	builder.WriteString("bandwidthautodetect:i:1\n")
-	builder.WriteString("displayconnectionbar:i:1\n")
-	builder.WriteString("enableworkspacereconnect:i:0\n")
-	builder.WriteString("remoteappmousemoveinject:i:1\n")
-
-	// Visual performance settings
-	builder.WriteString("disable wallpaper:i:0\n")
-	builder.WriteString("allow font smoothing:i:0\n")
-	builder.WriteString("allow desktop composition:i:0\n")
-	builder.WriteString("disable full window drag:i:1\n")
-	builder.WriteString("disable menu anims:i:1\n")
-	builder.WriteString("disable themes:i:0\n")
-	builder.WriteString("disable cursor setting:i:0\n")
-	builder.WriteString("bitmapcachepersistenable:i:1\n")
-
-	// Audio and device redirection
-	builder.WriteString("audiomode:i:0\n")
-	builder.WriteString("redirectprinters:i:1\n")
-	builder.WriteString("redirectlocation:i:1\n")
-	builder.WriteString("redirectcomports:i:1\n")
-	builder.WriteString("redirectsmartcards:i:1\n")
-	builder.WriteString("redirectwebauthn:i:1\n")
-	builder.WriteString("redirectposdevices:i:0\n")
-	builder.WriteString("camerastoredirect:s:*\n")
-	builder.WriteString("devicestoredirect:s:*\n")
-
-	// Drive redirection (configurable)
-	if host.RedirectDrives {
-		builder.WriteString("drivestoredirect:s:*\n")
-	} else {
-		builder.WriteString("drivestoredirect:s:\n")
+	if err := ValidateRemoteApp(app); err != nil {
+		return "", err
 	}
 
-	// Connection and security settings
-	builder.WriteString("autoreconnection enabled:i:1\n")
-	builder.WriteString("authentication level:i:2\n")
-	builder.WriteString("prompt for credentials:i:0\n")
-	builder.WriteString("negotiate security layer:i:1\n")
-	builder.WriteString("remoteapplicationmode:i:0\n")
-	builder.WriteString("alternate shell:s:\n")
-	builder.WriteString("shell working directory:s:\n")
-
-	// Gateway settings (empty by default)
-	builder.WriteString("gatewayhostname:s:\n")
-	builder.WriteString("gatewayusagemethod:i:4\n")
-	builder.WriteString("gatewaycredentialssource:i:4\n")
-	builder.WriteString("gatewayprofileusagemethod:i:0\n")
-	builder.WriteString("promptcredentialonce:i:0\n")
-	builder.WriteString("gatewaybrokeringtype:i:0\n")
-	builder.WriteString("use redirection server name:i:0\n")
-	builder.WriteString("rdgiskdcproxy:i:0\n")
-	builder.WriteString("kdcproxyname:s:\n")
-	builder.WriteString("enablerdsaadauth:i:0\n")
-
-	return builder.String()
-}
-
-// findExistingRDPWindow searches for an existing mstsc.exe window with the target address
-func findExistingRDPWindow(targetAddress string) (uintptr, bool) {
-	debug := false
-	logging.Log(debug, "Searching for existing RDP window for:", targetAddress)
-
-	var foundHwnd uintptr
-
-	// Callback function for EnumWindows
-	callback := syscall.NewCallback(func(hwnd uintptr, lParam uintptr) uintptr {
-		// Check if window is visible
-		visible, _, _ := procIsWindowVisible.Call(hwnd)
-		if visible == 0 {
-			return 1 // Continue enumeration
-		}
-
-		// Get window class name
-		className := make([]uint16, 256)
-		procGetClassNameW.Call(hwnd, uintptr(unsafe.Pointer(&className[0])), 256)
-		classNameStr := syscall.UTF16ToString(className)
-
-		// Check if it's a Terminal Services Client window (mstsc.exe)
-		if classNameStr != "TscShellContainerClass" {
-			return 1 // Continue enumeration
-		}
-
-		// Get window title
-		titleBuf := make([]uint16, 512)
-		procGetWindowTextW.Call(hwnd, uintptr(unsafe.Pointer(&titleBuf[0])), 512)
-		title := syscall.UTF16ToString(titleBuf)
-
-		logging.Log(debug, "Found RDP window with title:", title)
-
-		// Check if the title contains the target address
-		if strings.Contains(strings.ToLower(title), strings.ToLower(targetAddress)) {
-			logging.Log(debug, "Match found! HWND:", hwnd)
-			foundHwnd = hwnd
-			return 0 // Stop enumeration
-		}
-
-		return 1 // Continue enumeration
-	})
+	// Host ID + app ID keeps the filename safe and separate from the desktop session file
+	filename := fmt.Sprintf("%s_%s.rdp", host.ID, app.ID)
+	content := g.buildRDPContent(host, user, &app)
+	logging.Log(debug, "RemoteApp content built, length:", len(content), "bytes")
 
-	// Enumerate all windows
-	procEnumWindows.Call(callback, 0)
-
-	if foundHwnd != 0 {
-		logging.Log(debug, "Existing RDP window found:", foundHwnd)
-		return foundHwnd, true
+	content, err := g.signContent(content)
+	if err != nil {
+		return "", err
 	}
-
-	logging.Log(debug, "No existing RDP window found")
-	return 0, false
+	return writeTempRDPFile(filename, content)
 }
 
-// bringWindowToFront brings a window to the foreground and restores it if minimized
-func bringWindowToFront(hwnd uintptr) {
+// writeTempRDPFile writes generated content to the temp directory and returns the path
+func writeTempRDPFile(filename, content string) (string, error) {
 	debug := false
-	logging.Log(debug, "Bringing window to front, HWND:", hwnd)
+	filepath := config.GetTempPath(filename)
+	logging.Log(debug, "RDP file path (using host ID for safety):", filepath)
 
-	// Check if window is minimized
-	isMinimized, _, _ := procIsIconic.Call(hwnd)
-	if isMinimized != 0 {
-		logging.Log(debug, "Window is minimized, restoring...")
-		procShowWindow.Call(hwnd, SW_RESTORE)
+	// Write to temporary file
+	logging.Log(debug, "Writing RDP file to disk")
+	if err := os.WriteFile(filepath, []byte(content), 0644); err != nil {
+		logging.Log(true, "ERROR: Failed to write RDP file:", err)
+		return "", fmt.Errorf("failed to write RDP file: %w", err)
 	}
+	logging.Log(debug, "RDP file written successfully")
 
-	// Bring window to foreground
-	procSetForegroundWindow.Call(hwnd)
-	logging.Log(debug, "Window brought to front")
+	return filepath, nil
 }
 
 // LaunchRDP launches an RDP session using mstsc.exe
@@ -303,15 +110,12 @@ func (g *Generator) LaunchRDP(rdpFilePath string) error {
 
 	// Launch mstsc.exe with the RDP file
 	logging.Log(debug, "Launching mstsc.exe with RDP file")
-	cmd := exec.Command("mstsc", absPath)
-
-	logging.Log(debug, "Starting mstsc process...")
-	if err := cmd.Start(); err != nil {
+	if err := g.launcher.Start("mstsc", []string{absPath}, ""); err != nil {
 		logging.Log(true, "ERROR: Failed to launch mstsc:", err)
 		return fmt.Errorf("failed to launch mstsc: %w", err)
 	}
 
-	logging.Log(debug, "mstsc process started successfully, PID:", cmd.Process.Pid)
+	logging.Log(debug, "mstsc process started successfully")
 	return nil
 }
 
@@ -323,10 +127,19 @@ func (g *Generator) LaunchHost(host models.Host, user models.User) (bool, error)
 	logging.Log(debug, "User details - ID:", user.ID, "Name:", user.Name, "Username:", user.Username)
 	logging.Log(debug, "User has encrypted password:", user.EncryptedPassword != "")
 
+	// FreeRDP backends get their settings as arguments instead of an .rdp file
+	if backend := HostBackend(host); backend != BackendMSTSC {
+		if err := g.launchFreeRDP(backend, host, user, nil); err != nil {
+			return false, fmt.Errorf("failed to launch RDP session: %w", err)
+		}
+		return false, nil
+	}
+
 	// Check for existing RDP window first
-	if hwnd, found := findExistingRDPWindow(host.Address); found {
-		logging.Log(true, "Found existing RDP window for", host.Address, "- bringing to front")
-		bringWindowToFront(hwnd)
+	// The window title shows the address mstsc connected to
+	if hwnd, found := g.windows.FindWindow(host.CredentialTarget()); found {
+		logging.Log(true, "Found existing RDP window for", host.CredentialTarget(), "- bringing to front")
+		g.windows.Activate(hwnd)
 		return true, nil
 	}
 
@@ -355,6 +168,38 @@ func (g *Generator) LaunchHost(host models.Host, user models.User) (bool, error)
 	return false, nil
 }
 
+// LaunchRemoteApp launches a single RemoteApp program of a host. RemoteApp
+// windows are not matched against existing desktop sessions, so every call starts
+// the program (mstsc reuses an existing RemoteApp connection by itself).
+func (g *Generator) LaunchRemoteApp(host models.Host, user models.User, app models.RemoteApp) error {
+	debug := false
+	logging.Log(debug, "LaunchRemoteApp started for host:", host.Name, "app:", app.Name, "user:", user.Username)
+
+	if backend := HostBackend(host); backend != BackendMSTSC {
+		if err := ValidateRemoteApp(app); err != nil {
+			return err
+		}
+		if err := g.launchFreeRDP(backend, host, user, &app); err != nil {
+			return fmt.Errorf("failed to launch RemoteApp: %w", err)
+		}
+		return nil
+	}
+
+	rdpFile, err := g.GenerateRemoteAppFile(host, user, app)
+	if err != nil {
+		logging.Log(true, "ERROR: Failed to generate RemoteApp file:", err)
+		return fmt.Errorf("failed to generate RemoteApp file: %w", err)
+	}
+
+	if err := g.LaunchRDP(rdpFile); err != nil {
+		logging.Log(true, "ERROR: Failed to launch RemoteApp:", err)
+		return fmt.Errorf("failed to launch RemoteApp: %w", err)
+	}
+
+	logging.Log(debug, "LaunchRemoteApp completed successfully")
+	return nil
+}
+
 // CleanupTempFiles removes old RDP files from temp directory
 func (g *Generator) CleanupTempFiles() error {
 	tempDir := config.TempDir
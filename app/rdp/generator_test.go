@@ -0,0 +1,273 @@
+package rdp_test
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/chrilep/LaunchRDP/app/config"
+	"github.com/chrilep/LaunchRDP/app/models"
+	"github.com/chrilep/LaunchRDP/app/rdp"
+	"github.com/chrilep/LaunchRDP/app/rdp/rdptest"
+)
+
+// newTestGenerator returns a generator with recording fakes and a temporary
+// directory for the generated files
+func newTestGenerator(t *testing.T) (*rdp.Generator, *rdptest.Launcher, *rdptest.WindowFinder) {
+	t.Helper()
+	previous := config.TempDir
+	config.TempDir = t.TempDir()
+	t.Cleanup(func() { config.TempDir = previous })
+
+	launcher := &rdptest.Launcher{}
+	windows := rdptest.NewWindowFinder()
+	generator := rdp.NewGenerator()
+	generator.SetLauncher(launcher)
+	generator.SetWindowFinder(windows)
+	return generator, launcher, windows
+}
+
+func testHost(launcher string) models.Host {
+	host := models.NewHost("Server", "srv.example.com", 3389, "user-1")
+	host.ID = "host-1"
+	host.Launcher = launcher
+	return host
+}
+
+func testUser() models.User {
+	user := models.NewUser("Alice", `CORP\alice`)
+	user.ID = "user-1"
+	user.EncryptedPassword = "encrypted"
+	return user
+}
+
+func TestLaunchHostStartsMSTSC(t *testing.T) {
+	generator, launcher, windows := newTestGenerator(t)
+	host, user := testHost(rdp.BackendMSTSC), testUser()
+
+	reused, err := generator.LaunchHost(host, user)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if reused {
+		t.Error("reused = true without an open window")
+	}
+	if len(windows.Activated()) != 0 {
+		t.Errorf("activated windows %v", windows.Activated())
+	}
+
+	launches := launcher.Launches()
+	if len(launches) != 1 {
+		t.Fatalf("%d launches, want 1", len(launches))
+	}
+	wantPath := config.GetTempPath(host.ID + ".rdp")
+	if launch := launches[0]; launch.Program != "mstsc" || !slices.Equal(launch.Args, []string{wantPath}) || launch.Stdin != "" {
+		t.Errorf("launch = %+v, want mstsc %s without stdin", launch, wantPath)
+	}
+
+	content, err := os.ReadFile(wantPath)
+	if err != nil {
+		t.Fatalf("generated file missing: %v", err)
+	}
+	if want := rdp.BuildRDPContent(generator, host, user, nil); string(content) != want {
+		t.Errorf("file content differs from the generated content:\n%s", content)
+	}
+	for _, line := range []string{"full address:s:srv.example.com\n", `username:s:CORP\alice` + "\n"} {
+		if !strings.Contains(string(content), line) {
+			t.Errorf("file lacks %q", line)
+		}
+	}
+	if strings.Contains(string(content), "password") {
+		t.Error("file contains a password property")
+	}
+}
+
+func TestLaunchHostReusesWindow(t *testing.T) {
+	tests := []struct {
+		name   string
+		alias  string
+		window string // address of the open window
+	}{
+		{"address", "", "SRV.example.com"},
+		// mstsc connects to, and titles the window with, the credential alias
+		{"credential alias", "srv-alias", "srv-alias"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			generator, launcher, windows := newTestGenerator(t)
+			host := testHost(rdp.BackendMSTSC)
+			host.CredentialAlias = test.alias
+			windows.AddWindow(test.window, 42)
+
+			reused, err := generator.LaunchHost(host, testUser())
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reused {
+				t.Error("reused = false with an open window")
+			}
+			if got := windows.Activated(); !slices.Equal(got, []uintptr{42}) {
+				t.Errorf("activated %v, want [42]", got)
+			}
+			if len(launcher.Launches()) != 0 {
+				t.Errorf("started %v", launcher.Launches())
+			}
+			if _, err := os.Stat(config.GetTempPath(host.ID + ".rdp")); !os.IsNotExist(err) {
+				t.Error("file generated for a reused window")
+			}
+		})
+	}
+}
+
+func TestLaunchHostOtherWindowStartsNew(t *testing.T) {
+	generator, launcher, windows := newTestGenerator(t)
+	windows.AddWindow("other.example.com", 7)
+
+	reused, err := generator.LaunchHost(testHost(rdp.BackendMSTSC), testUser())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if reused || len(windows.Activated()) != 0 || len(launcher.Launches()) != 1 {
+		t.Errorf("reused = %v, activated %v, %d launches", reused, windows.Activated(), len(launcher.Launches()))
+	}
+}
+
+func TestLaunchHostFreeRDPPipesPassword(t *testing.T) {
+	generator, launcher, windows := newTestGenerator(t)
+	// FreeRDP sessions are never reused
+	windows.AddWindow("srv.example.com", 42)
+	var passwordOf string
+	generator.SetPasswordCallback(func(user models.User) (string, error) {
+		passwordOf = user.ID
+		return "s3cret pass", nil
+	})
+
+	reused, err := generator.LaunchHost(testHost(rdp.BackendXFreeRDP), testUser())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if reused || len(windows.Activated()) != 0 {
+		t.Errorf("reused = %v, activated %v", reused, windows.Activated())
+	}
+	if passwordOf != "user-1" {
+		t.Errorf("password requested for %q", passwordOf)
+	}
+
+	launches := launcher.Launches()
+	if len(launches) != 1 {
+		t.Fatalf("%d launches, want 1", len(launches))
+	}
+	launch := launches[0]
+	if launch.Program != rdp.BackendXFreeRDP {
+		t.Errorf("program = %q", launch.Program)
+	}
+	if launch.Stdin != "s3cret pass\n" {
+		t.Errorf("stdin = %q, want the password and a newline", launch.Stdin)
+	}
+	for _, want := range []string{"/v:srv.example.com", "/u:alice", "/d:CORP", "/from-stdin"} {
+		if !slices.Contains(launch.Args, want) {
+			t.Errorf("args lack %s: %v", want, launch.Args)
+		}
+	}
+	if strings.Contains(strings.Join(launch.Args, " "), "s3cret") {
+		t.Error("password is part of the arguments")
+	}
+	if entries, _ := os.ReadDir(config.TempDir); len(entries) != 0 {
+		t.Errorf("FreeRDP launch wrote %d files", len(entries))
+	}
+}
+
+func TestLaunchHostFreeRDPWithoutPassword(t *testing.T) {
+	generator, launcher, _ := newTestGenerator(t)
+	user := testUser()
+
+	// A stored password needs a password source
+	if _, err := generator.LaunchHost(testHost(rdp.BackendWLFreeRDP), user); err == nil {
+		t.Error("launched without password source")
+	}
+	if len(launcher.Launches()) != 0 {
+		t.Errorf("started %v", launcher.Launches())
+	}
+
+	// Users without password are prompted by FreeRDP
+	user.EncryptedPassword = ""
+	if _, err := generator.LaunchHost(testHost(rdp.BackendWLFreeRDP), user); err != nil {
+		t.Fatal(err)
+	}
+	launches := launcher.Launches()
+	if len(launches) != 1 || launches[0].Stdin != "" || slices.Contains(launches[0].Args, "/from-stdin") {
+		t.Errorf("launches = %+v", launches)
+	}
+}
+
+func TestLaunchHostLauncherError(t *testing.T) {
+	for _, backend := range []string{rdp.BackendMSTSC, rdp.BackendXFreeRDP} {
+		t.Run(backend, func(t *testing.T) {
+			generator, launcher, _ := newTestGenerator(t)
+			generator.SetPasswordCallback(func(models.User) (string, error) { return "pw", nil })
+			failure := errors.New("executable not found")
+			launcher.Err = failure
+
+			reused, err := generator.LaunchHost(testHost(backend), testUser())
+			if !errors.Is(err, failure) {
+				t.Errorf("err = %v, want it to wrap %v", err, failure)
+			}
+			if reused {
+				t.Error("reused = true on failure")
+			}
+			if len(launcher.Launches()) != 1 {
+				t.Errorf("%d launches, want 1", len(launcher.Launches()))
+			}
+		})
+	}
+}
+
+func TestLaunchRemoteApp(t *testing.T) {
+	generator, launcher, windows := newTestGenerator(t)
+	host := testHost(rdp.BackendMSTSC)
+	app := models.RemoteApp{ID: "app-1", Name: "Notepad", Program: "||notepad"}
+	// RemoteApps never reuse the desktop window
+	windows.AddWindow(host.Address, 42)
+
+	if err := generator.LaunchRemoteApp(host, testUser(), app); err != nil {
+		t.Fatal(err)
+	}
+	wantPath := config.GetTempPath("host-1_app-1.rdp")
+	launches := launcher.Launches()
+	if len(launches) != 1 || !slices.Equal(launches[0].Args, []string{wantPath}) {
+		t.Fatalf("launches = %+v, want mstsc %s", launches, wantPath)
+	}
+	content, err := os.ReadFile(wantPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(content), "remoteapplicationprogram:s:||notepad\n") {
+		t.Errorf("RemoteApp program missing:\n%s", content)
+	}
+
+	if err := generator.LaunchRemoteApp(host, testUser(), models.RemoteApp{ID: "app-2"}); err == nil {
+		t.Error("RemoteApp without program launched")
+	}
+	if len(launcher.Launches()) != 1 {
+		t.Errorf("%d launches after invalid RemoteApp", len(launcher.Launches()))
+	}
+}
+
+func TestCleanupTempFiles(t *testing.T) {
+	generator, _, _ := newTestGenerator(t)
+	for _, name := range []string{"a.rdp", "b.rdp", "keep.txt"} {
+		if err := os.WriteFile(filepath.Join(config.TempDir, name), nil, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := generator.CleanupTempFiles(); err != nil {
+		t.Fatal(err)
+	}
+	entries, _ := os.ReadDir(config.TempDir)
+	if len(entries) != 1 || entries[0].Name() != "keep.txt" {
+		t.Errorf("left %v", entries)
+	}
+}
@@ -0,0 +1,218 @@
+package rdp
+
+import (
+	"net"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/chrilep/LaunchRDP/app/logging"
+	"github.com/chrilep/LaunchRDP/app/models"
+)
+
+// ImportedHost is the result of mapping a parsed .rdp file onto a host
+type ImportedHost struct {
+	Host     models.Host
+	Username string // "username" property, used to find or create the matching user
+}
+
+// droppedProperties are never kept on import: they are machine bound secrets,
+// signatures that become invalid as soon as the generator rewrites the file, and
+// an alternate address that would let a signed file connect elsewhere than its
+// full address. writeImportedProperties skips them for hosts imported earlier.
+var droppedProperties = map[string]bool{
+	"password 51":            true,
+	"signscope":              true,
+	"signature":              true,
+	"alternate full address": true,
+}
+
+// addressProperties are read explicitly before the property loop
+var addressProperties = map[string]bool{
+	"full address": true,
+	"server port":  true,
+	"username":     true,
+}
+
+// ImportFile parses an .rdp file and maps it onto a new host named after the file
+func ImportFile(path string) (ImportedHost, error) {
+	file, err := ParseFile(path)
+	if err != nil {
+		return ImportedHost{}, err
+	}
+	name := strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+	return ImportHost(file, name), nil
+}
+
+// ImportHost maps known properties onto models.Host fields. Other registered
+// properties become per-host overrides, everything else is kept in
+// Host.ImportedProperties.
+func ImportHost(file *File, name string) ImportedHost {
+	debug := false
+
+	address, _ := file.GetString("full address")
+	address = strings.TrimSpace(address)
+	port := 3389
+	if host, p, ok := splitAddressPort(address); ok {
+		address = host
+		port = p
+	}
+	if p, ok := file.GetInt("server port"); ok && p > 0 {
+		port = p
+	}
+	if name == "" {
+		name = address
+	}
+
+	host := models.NewHost(name, address, port, "")
+	host.CustomProperties = map[string]string{}
+	host.ImportedProperties = map[string]string{}
+	remoteApp := models.RemoteApp{}
+	remoteAppMode := false
+
+	for _, prop := range file.Properties {
+		if droppedProperties[prop.Name] {
+			continue
+		}
+		switch prop.Name {
+		case "screen mode id":
+			if v, err := strconv.Atoi(prop.Value); err == nil && v == 2 {
+				host.ScreenMode = 2
+				host.DisplayMode = "fullscreen"
+			} else {
+				host.ScreenMode = 1
+				host.DisplayMode = "window"
+			}
+		case "desktopwidth":
+			host.DesktopWidth, _ = strconv.Atoi(prop.Value)
+		case "desktopheight":
+			host.DesktopHeight, _ = strconv.Atoi(prop.Value)
+		case "winposstr":
+			if x, y, w, h, ok := parseWinPosStr(prop.Value); ok {
+				host.PositionX = x
+				host.PositionY = y
+				host.WindowWidth = w
+				host.WindowHeight = h
+				host.WinPosStr = prop.Value
+			}
+		case "redirectclipboard":
+			host.RedirectClipboard = prop.Value != "0"
+		case "dynamic resolution":
+			host.DynamicResolution = prop.Value != "0"
+		case "gatewayhostname":
+			host.GatewayHostname = strings.TrimSpace(prop.Value)
+		case "gatewayusagemethod":
+			usage, _ := strconv.Atoi(prop.Value)
+			host.GatewayBypassLocal = usage == GatewayUsageBypassLocal
+			if usage == GatewayUsageBypassLocal {
+				usage = GatewayUsageAlways
+			}
+			host.GatewayUsageMethod = usage
+		case "gatewaycredentialssource":
+			host.GatewayCredentialsSource, _ = strconv.Atoi(prop.Value)
+		case "desktopscalefactor":
+			host.DesktopScaleFactor, _ = strconv.Atoi(prop.Value)
+		case "devicescalefactor":
+			host.DeviceScaleFactor, _ = strconv.Atoi(prop.Value)
+		case "smart sizing":
+			host.SmartSizing = prop.Value != "0"
+		case "selectedmonitors":
+			for _, part := range strings.Split(prop.Value, ",") {
+				if m, err := strconv.Atoi(strings.TrimSpace(part)); err == nil && m >= 0 {
+					host.SelectedMonitors = append(host.SelectedMonitors, m)
+				}
+			}
+		case "remoteapplicationmode":
+			remoteAppMode = prop.Value == "1"
+		case "remoteapplicationprogram":
+			remoteApp.Program = prop.Value
+		case "remoteapplicationname":
+			remoteApp.Name = prop.Value
+		case "remoteapplicationcmdline":
+			remoteApp.CommandLine = prop.Value
+		case "drivestoredirect":
+			host.DrivesToRedirect = prop.Value
+			host.RedirectDrives = strings.TrimSpace(prop.Value) != ""
+		default:
+			if addressProperties[prop.Name] {
+				continue
+			}
+			if name, err := ValidateCustomProperty(prop.Name, prop.Value); err == nil {
+				// Values equal to the registry default need no override
+				if def, _ := LookupProperty(name); prop.Value != def.Default {
+					host.CustomProperties[name] = prop.Value
+				}
+				continue
+			}
+			host.ImportedProperties[prop.Name] = prop.Type + ":" + prop.Value
+		}
+	}
+
+	// winposstr missing: estimate the window size from the desktop size
+	if _, ok := file.Get("winposstr"); !ok {
+		host.WindowWidth = host.DesktopWidth + models.WindowFrameWidth
+		host.WindowHeight = host.DesktopHeight + models.WindowFrameHeight
+		host.WinPosStr = ""
+	}
+	// RemoteApp files become a RemoteApp entry of the host
+	if remoteAppMode && remoteApp.Program != "" {
+		app := models.NewRemoteApp(remoteApp.Name, remoteApp.Program)
+		if app.Name == "" {
+			app.Name = name
+		}
+		app.CommandLine = remoteApp.CommandLine
+		app.WorkingDirectory = host.CustomProperties["shell working directory"]
+		delete(host.CustomProperties, "shell working directory")
+		delete(host.CustomProperties, "disableremoteappcapscheck")
+		host.RemoteApps = []models.RemoteApp{app}
+	}
+	if len(host.CustomProperties) == 0 {
+		host.CustomProperties = nil
+	}
+	if len(host.ImportedProperties) == 0 {
+		host.ImportedProperties = nil
+	}
+
+	username, _ := file.GetString("username")
+	logging.Log(debug, "Imported host", host.Name, "address:", host.Address, "port:", host.Port,
+		"user:", username, "overrides:", len(host.CustomProperties), "unmapped properties:", len(host.ImportedProperties))
+	return ImportedHost{Host: host, Username: strings.TrimSpace(username)}
+}
+
+// splitAddressPort splits "host:port" and "[v6]:port" forms of "full address"
+func splitAddressPort(address string) (string, int, bool) {
+	if !strings.Contains(address, ":") {
+		return address, 0, false
+	}
+	host, portStr, err := net.SplitHostPort(address)
+	if err != nil {
+		return address, 0, false
+	}
+	port, err := strconv.Atoi(portStr)
+	if err != nil || port <= 0 {
+		return address, 0, false
+	}
+	return host, port, true
+}
+
+// parseWinPosStr parses "0,1,<left>,<top>,<right>,<bottom>" into position and size
+func parseWinPosStr(value string) (x, y, width, height int, ok bool) {
+	parts := strings.Split(value, ",")
+	if len(parts) != 6 {
+		return 0, 0, 0, 0, false
+	}
+	nums := make([]int, len(parts))
+	for i, p := range parts {
+		n, err := strconv.Atoi(strings.TrimSpace(p))
+		if err != nil {
+			return 0, 0, 0, 0, false
+		}
+		nums[i] = n
+	}
+	width = nums[4] - nums[2]
+	height = nums[5] - nums[3]
+	if width <= 0 || height <= 0 {
+		return 0, 0, 0, 0, false
+	}
+	return nums[2], nums[3], width, height, true
+}
@@ -0,0 +1,133 @@
+package rdp
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/chrilep/LaunchRDP/app/models"
+)
+
+func TestImportFileMSTSC(t *testing.T) {
+	// The same mstsc export in every encoding mstsc and other tools write
+	for _, name := range []string{"mstsc_utf16le_bom", "mstsc_utf16le_no_bom", "mstsc_utf8_bom"} {
+		t.Run(name, func(t *testing.T) {
+			imported, err := ImportFile(filepath.Join("testdata", "import", name+".rdp"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			host := imported.Host
+			if imported.Username != `CORP\alice` {
+				t.Errorf("username = %q", imported.Username)
+			}
+			if host.Name != name || host.Address != "srv.example.com" || host.Port != 3390 {
+				t.Errorf("host = %q %s:%d", host.Name, host.Address, host.Port)
+			}
+			if host.ScreenMode != 2 || host.DisplayMode != "fullscreen" || host.DesktopWidth != 1600 || host.DesktopHeight != 900 {
+				t.Errorf("display = %d %q %dx%d", host.ScreenMode, host.DisplayMode, host.DesktopWidth, host.DesktopHeight)
+			}
+			if host.PositionX != 100 || host.PositionY != 50 || host.WindowWidth != 1616 || host.WindowHeight != 959 {
+				t.Errorf("window = %d,%d %dx%d", host.PositionX, host.PositionY, host.WindowWidth, host.WindowHeight)
+			}
+			if !host.RedirectClipboard || !host.RedirectDrives || host.DrivesToRedirect != `C:\;D:\` {
+				t.Errorf("redirection = %v %v %q", host.RedirectClipboard, host.RedirectDrives, host.DrivesToRedirect)
+			}
+			// "bypass for local" is stored as always plus the bypass flag
+			if host.GatewayHostname != "gw.example.com" || host.GatewayUsageMethod != GatewayUsageAlways ||
+				!host.GatewayBypassLocal || host.GatewayCredentialsSource != 0 {
+				t.Errorf("gateway = %q %d %v %d", host.GatewayHostname, host.GatewayUsageMethod, host.GatewayBypassLocal, host.GatewayCredentialsSource)
+			}
+			// Only values that differ from the registry defaults become overrides
+			wantCustom := map[string]string{"keyboardhook": "2", "kdcproxyname": "kdc.example.com"}
+			if !reflect.DeepEqual(host.CustomProperties, wantCustom) {
+				t.Errorf("custom properties = %v, want %v", host.CustomProperties, wantCustom)
+			}
+			// Unknown properties are kept, the password, signature and alternate address dropped
+			wantImported := map[string]string{"some future setting": "i:5"}
+			if !reflect.DeepEqual(host.ImportedProperties, wantImported) {
+				t.Errorf("imported properties = %v, want %v", host.ImportedProperties, wantImported)
+			}
+		})
+	}
+}
+
+func TestImportFileRemoteApp(t *testing.T) {
+	imported, err := ImportFile(filepath.Join("testdata", "import", "remoteapp.rdp"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	host := imported.Host
+	if host.Address != "apps.example.com" || host.Port != 3389 || imported.Username != "" {
+		t.Errorf("host = %s:%d user %q", host.Address, host.Port, imported.Username)
+	}
+	if len(host.RemoteApps) != 1 {
+		t.Fatalf("RemoteApps = %+v", host.RemoteApps)
+	}
+	app := host.RemoteApps[0]
+	if app.Program != "||calc" || app.Name != "Calculator" || app.CommandLine != "/mode:scientific" || app.WorkingDirectory != `C:\Tools` {
+		t.Errorf("RemoteApp = %+v", app)
+	}
+	// The RemoteApp settings are not kept as overrides of the desktop
+	if host.CustomProperties != nil || host.ImportedProperties != nil {
+		t.Errorf("custom %v, imported %v", host.CustomProperties, host.ImportedProperties)
+	}
+}
+
+func TestImportFileLegacy(t *testing.T) {
+	imported, err := ImportFile(filepath.Join("testdata", "import", "ipv6_no_winposstr.rdp"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	host := imported.Host
+	// "server port" wins over the port in "full address"
+	if host.Address != "2001:db8::1" || host.Port != 3392 {
+		t.Errorf("address = %s port %d", host.Address, host.Port)
+	}
+	// Without winposstr the window is estimated from the desktop
+	if host.WindowWidth != 1024+models.WindowFrameWidth || host.WindowHeight != 768+models.WindowFrameHeight || host.WinPosStr != "" {
+		t.Errorf("window = %dx%d %q", host.WindowWidth, host.WindowHeight, host.WinPosStr)
+	}
+	if host.DisplayMode != "window" {
+		t.Errorf("display mode = %q", host.DisplayMode)
+	}
+}
+
+func TestImportFileMissing(t *testing.T) {
+	if _, err := ImportFile(filepath.Join("testdata", "import", "missing.rdp")); err == nil {
+		t.Error("missing file imported")
+	}
+}
+
+func TestImportHostMapping(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		check   func(models.Host) bool
+	}{
+		{"name from address", "full address:s:srv.example.com", func(h models.Host) bool { return h.Name == "srv.example.com" }},
+		{"port in address", "full address:s:srv:3390", func(h models.Host) bool { return h.Address == "srv" && h.Port == 3390 }},
+		{"invalid port in address", "full address:s:srv:x", func(h models.Host) bool { return h.Address == "srv:x" && h.Port == 3389 }},
+		{"windowed", "full address:s:srv\nscreen mode id:i:1", func(h models.Host) bool { return h.ScreenMode == 1 && h.DisplayMode == "window" }},
+		{"invalid winposstr", "full address:s:srv\nwinposstr:s:0,1,10,10,5,5", func(h models.Host) bool { return h.WinPosStr != "0,1,10,10,5,5" && h.WindowWidth > 0 }},
+		{"selected monitors", "full address:s:srv\nselectedmonitors:s:0, 2,x,-1", func(h models.Host) bool {
+			return reflect.DeepEqual(h.SelectedMonitors, []int{0, 2})
+		}},
+		{"empty drives", "full address:s:srv\ndrivestoredirect:s:", func(h models.Host) bool { return !h.RedirectDrives }},
+		{"default values need no override", "full address:s:srv\nsession bpp:i:32", func(h models.Host) bool { return h.CustomProperties == nil }},
+		{"invalid value kept unmapped", "full address:s:srv\nsession bpp:i:7", func(h models.Host) bool {
+			return h.CustomProperties == nil && h.ImportedProperties["session bpp"] == "i:7"
+		}},
+		{"remoteapp mode without program", "full address:s:srv\nremoteapplicationmode:i:1", func(h models.Host) bool { return len(h.RemoteApps) == 0 }},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			file, err := Parse([]byte(test.content))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if host := ImportHost(file, "").Host; !test.check(host) {
+				t.Errorf("unexpected host %+v", host)
+			}
+		})
+	}
+}
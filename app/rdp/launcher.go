@@ -0,0 +1,59 @@
+package rdp
+
+import (
+	"os/exec"
+	"strings"
+
+	"github.com/chrilep/LaunchRDP/app/logging"
+)
+
+// Launcher starts RDP client processes
+type Launcher interface {
+	// Start runs program without waiting for it to exit. A non-empty stdin is
+	// written to the standard input of the process (used for passwords).
+	Start(program string, args []string, stdin string) error
+}
+
+// WindowFinder locates session windows that are already open, so launching a
+// host twice activates the existing session instead of starting a new one
+type WindowFinder interface {
+	// FindWindow returns the window of a session connected to address
+	FindWindow(address string) (window uintptr, found bool)
+	// Activate brings a window returned by FindWindow to the foreground
+	Activate(window uintptr)
+}
+
+// SetLauncher replaces the process launcher (nil restores the default)
+func (g *Generator) SetLauncher(launcher Launcher) {
+	if launcher == nil {
+		launcher = execLauncher{}
+	}
+	g.launcher = launcher
+}
+
+// SetWindowFinder replaces the window lookup (nil restores the default)
+func (g *Generator) SetWindowFinder(finder WindowFinder) {
+	if finder == nil {
+		finder = defaultWindowFinder()
+	}
+	g.windows = finder
+}
+
+// execLauncher starts processes with os/exec
+type execLauncher struct{}
+
+func (execLauncher) Start(program string, args []string, stdin string) error {
+	debug := false
+	cmd := exec.Command(program, args...)
+	if stdin != "" {
+		cmd.Stdin = strings.NewReader(stdin)
+	}
+	if err := cmd.Start(); err != nil {
+		return err
+	}
+	logging.Log(debug, program, "process started successfully, PID:", cmd.Process.Pid)
+
+	// Reap the process when the session ends
+	go cmd.Wait()
+	return nil
+}
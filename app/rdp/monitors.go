@@ -0,0 +1,35 @@
+package rdp
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// ValidateMonitorSelection checks that monitor indexes are non-negative and unique
+func ValidateMonitorSelection(monitors []int) error {
+	seen := make(map[int]bool, len(monitors))
+	for _, m := range monitors {
+		if m < 0 {
+			return fmt.Errorf("invalid monitor index %d", m)
+		}
+		if seen[m] {
+			return fmt.Errorf("monitor %d selected twice", m)
+		}
+		seen[m] = true
+	}
+	return nil
+}
+
+// selectedMonitorsValue formats monitor indexes for "selectedmonitors" (sorted, comma separated)
+func selectedMonitorsValue(monitors []int) string {
+	sorted := make([]int, len(monitors))
+	copy(sorted, monitors)
+	sort.Ints(sorted)
+	parts := make([]string, len(sorted))
+	for i, m := range sorted {
+		parts[i] = strconv.Itoa(m)
+	}
+	return strings.Join(parts, ",")
+}
@@ -0,0 +1,181 @@
+package rdp
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"unicode/utf16"
+
+	"github.com/chrilep/LaunchRDP/app/logging"
+)
+
+// RDP property value types as used in the "name:type:value" grammar
+const (
+	TypeString  = "s" // free text
+	TypeInteger = "i" // decimal integer
+	TypeBinary  = "b" // hex encoded binary blob (e.g. "password 51")
+)
+
+// Property is a single "name:type:value" line of an .rdp file
+type Property struct {
+	Name  string
+	Type  string
+	Value string
+}
+
+// String returns the property in .rdp line format (without line break)
+func (p Property) String() string {
+	return fmt.Sprintf("%s:%s:%s", p.Name, p.Type, p.Value)
+}
+
+// File is a parsed .rdp file. Properties keep the order of the source file.
+type File struct {
+	Properties []Property
+	// Skipped holds lines that did not match the grammar (for diagnostics only)
+	Skipped []string
+}
+
+// ParseFile reads and parses an .rdp file from disk
+func ParseFile(path string) (*File, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read RDP file: %w", err)
+	}
+	file, err := Parse(data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse RDP file %s: %w", path, err)
+	}
+	return file, nil
+}
+
+// Parse parses .rdp content. mstsc writes UTF-16LE with BOM, other tools
+// write UTF-8 (with or without BOM); all variants are accepted.
+func Parse(data []byte) (*File, error) {
+	debug := false
+
+	text, err := decodeRDPText(data)
+	if err != nil {
+		return nil, err
+	}
+
+	file := &File{}
+	for _, line := range strings.Split(text, "\n") {
+		line = strings.TrimRight(line, "\r")
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		prop, ok := parseLine(line)
+		if !ok {
+			logging.Log(debug, "Skipping malformed RDP line:", line)
+			file.Skipped = append(file.Skipped, line)
+			continue
+		}
+		file.Properties = append(file.Properties, prop)
+	}
+
+	logging.Log(debug, "Parsed RDP content:", len(file.Properties), "properties,", len(file.Skipped), "skipped lines")
+	return file, nil
+}
+
+// parseLine splits a line into name, type and value. The value is everything
+// after the second colon, so values may contain colons themselves.
+func parseLine(line string) (Property, bool) {
+	first := strings.Index(line, ":")
+	if first <= 0 {
+		return Property{}, false
+	}
+	rest := line[first+1:]
+	second := strings.Index(rest, ":")
+	if second < 0 {
+		return Property{}, false
+	}
+
+	prop := Property{
+		Name:  strings.ToLower(strings.TrimSpace(line[:first])),
+		Type:  strings.ToLower(strings.TrimSpace(rest[:second])),
+		Value: rest[second+1:],
+	}
+	switch prop.Type {
+	case TypeString, TypeBinary:
+	case TypeInteger:
+		prop.Value = strings.TrimSpace(prop.Value)
+		if _, err := strconv.Atoi(prop.Value); err != nil {
+			return Property{}, false
+		}
+	default:
+		return Property{}, false
+	}
+	return prop, true
+}
+
+// decodeRDPText converts raw file bytes to a Go string based on the BOM
+func decodeRDPText(data []byte) (string, error) {
+	switch {
+	case bytes.HasPrefix(data, []byte{0xFF, 0xFE}):
+		return decodeUTF16LE(data[2:])
+	case bytes.HasPrefix(data, []byte{0xEF, 0xBB, 0xBF}):
+		return string(data[3:]), nil
+	case len(data) >= 2 && data[0] != 0 && data[1] == 0:
+		// UTF-16LE without BOM (ASCII first character followed by zero byte)
+		return decodeUTF16LE(data)
+	default:
+		return string(data), nil
+	}
+}
+
+// decodeUTF16LE decodes little-endian UTF-16 bytes
+func decodeUTF16LE(data []byte) (string, error) {
+	if len(data)%2 != 0 {
+		return "", fmt.Errorf("invalid UTF-16 content: odd number of bytes")
+	}
+	units := make([]uint16, len(data)/2)
+	for i := range units {
+		units[i] = uint16(data[i*2]) | uint16(data[i*2+1])<<8
+	}
+	return string(utf16.Decode(units)), nil
+}
+
+// Get returns the property with the given name (case-insensitive).
+// If a name appears more than once, the last occurrence wins like in mstsc.
+func (f *File) Get(name string) (Property, bool) {
+	name = strings.ToLower(name)
+	for i := len(f.Properties) - 1; i >= 0; i-- {
+		if f.Properties[i].Name == name {
+			return f.Properties[i], true
+		}
+	}
+	return Property{}, false
+}
+
+// GetString returns the raw value of a property and whether it exists
+func (f *File) GetString(name string) (string, bool) {
+	prop, ok := f.Get(name)
+	if !ok {
+		return "", false
+	}
+	return prop.Value, true
+}
+
+// GetInt returns the integer value of a property
+func (f *File) GetInt(name string) (int, bool) {
+	prop, ok := f.Get(name)
+	if !ok {
+		return 0, false
+	}
+	v, err := strconv.Atoi(strings.TrimSpace(prop.Value))
+	if err != nil {
+		return 0, false
+	}
+	return v, true
+}
+
+// GetBool returns true for integer properties with a non-zero value
+func (f *File) GetBool(name string) (bool, bool) {
+	v, ok := f.GetInt(name)
+	if !ok {
+		return false, false
+	}
+	return v != 0, true
+}
@@ -0,0 +1,121 @@
+package rdp
+
+import (
+	"reflect"
+	"testing"
+)
+
+// withBOM prefixes little-endian UTF-16 content with its byte order mark
+func withBOM(data []byte) []byte {
+	return append([]byte{0xFF, 0xFE}, data...)
+}
+
+func TestParse(t *testing.T) {
+	const sample = "full address:s:srv.example.com\r\nscreen mode id:i:2\r\nusername:s:CORP\\alice\r\n"
+	want := []Property{
+		{"full address", TypeString, "srv.example.com"},
+		{"screen mode id", TypeInteger, "2"},
+		{"username", TypeString, `CORP\alice`},
+	}
+
+	tests := []struct {
+		name    string
+		data    []byte
+		want    []Property
+		skipped []string
+	}{
+		{"utf-8 crlf", []byte(sample), want, nil},
+		{"utf-8 lf", []byte("full address:s:srv.example.com\nscreen mode id:i:2\nusername:s:CORP\\alice"), want, nil},
+		{"utf-8 bom", append([]byte{0xEF, 0xBB, 0xBF}, sample...), want, nil},
+		{"utf-16le bom", withBOM(utf16LE(sample)), want, nil},
+		{"utf-16le without bom", utf16LE(sample), want, nil},
+		{"utf-16le non-ascii", withBOM(utf16LE("username:s:jürgen\r\n")), []Property{{"username", TypeString, "jürgen"}}, nil},
+		{
+			"values with colons",
+			[]byte("full address:s:srv.example.com:3390\nremoteapplicationcmdline:s:/a:b c:d\nalternate shell:s:C:\\Windows\\notepad.exe\n"),
+			[]Property{
+				{"full address", TypeString, "srv.example.com:3390"},
+				{"remoteapplicationcmdline", TypeString, "/a:b c:d"},
+				{"alternate shell", TypeString, `C:\Windows\notepad.exe`},
+			},
+			nil,
+		},
+		{
+			"names and types normalized",
+			[]byte(" Full Address :S:srv\nDesktopWidth:I: 1024 \n"),
+			[]Property{{"full address", TypeString, "srv"}, {"desktopwidth", TypeInteger, "1024"}},
+			nil,
+		},
+		{
+			"string values kept as written",
+			[]byte("drivestoredirect:s:\nusername:s: alice \n"),
+			[]Property{{"drivestoredirect", TypeString, ""}, {"username", TypeString, " alice "}},
+			nil,
+		},
+		{
+			"binary",
+			[]byte("password 51:b:01000000D08C\n"),
+			[]Property{{"password 51", TypeBinary, "01000000D08C"}},
+			nil,
+		},
+		{
+			"invalid integers",
+			[]byte("screen mode id:i:two\ndesktopwidth:i:\nsession bpp:i:32\n"),
+			[]Property{{"session bpp", TypeInteger, "32"}},
+			[]string{"screen mode id:i:two", "desktopwidth:i:"},
+		},
+		{
+			"unknown types and malformed lines",
+			[]byte("foo:x:1\nno colon\n:s:no name\nonly:one\n\n   \nkeyboardhook:i:2\n"),
+			[]Property{{"keyboardhook", TypeInteger, "2"}},
+			[]string{"foo:x:1", "no colon", ":s:no name", "only:one"},
+		},
+		{"empty", nil, nil, nil},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			file, err := Parse(test.data)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(file.Properties, test.want) {
+				t.Errorf("properties =\n%q\nwant\n%q", file.Properties, test.want)
+			}
+			if !reflect.DeepEqual(file.Skipped, test.skipped) {
+				t.Errorf("skipped = %q, want %q", file.Skipped, test.skipped)
+			}
+		})
+	}
+}
+
+func TestParseInvalidUTF16(t *testing.T) {
+	if file, err := Parse([]byte{0xFF, 0xFE, 'a', 0, 'b'}); err == nil {
+		t.Errorf("Parse() = %+v, want an error for an odd byte count", file)
+	}
+}
+
+func TestFileGetters(t *testing.T) {
+	file, err := Parse([]byte("Screen Mode ID:i:1\nscreen mode id:i:2\nredirectclipboard:i:0\nusername:s:alice\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The last occurrence wins, names are case-insensitive
+	if v, ok := file.GetInt("SCREEN MODE ID"); !ok || v != 2 {
+		t.Errorf("GetInt() = %d, %v, want 2", v, ok)
+	}
+	if v, ok := file.GetBool("redirectclipboard"); !ok || v {
+		t.Errorf("GetBool() = %v, %v, want false", v, ok)
+	}
+	if _, ok := file.GetInt("username"); ok {
+		t.Error("GetInt() of a string property succeeded")
+	}
+	if v, ok := file.GetString("username"); !ok || v != "alice" {
+		t.Errorf("GetString() = %q, %v", v, ok)
+	}
+	if _, ok := file.Get("missing"); ok {
+		t.Error("Get() of a missing property succeeded")
+	}
+	if got := (Property{"full address", TypeString, "srv:3390"}).String(); got != "full address:s:srv:3390" {
+		t.Errorf("String() = %q", got)
+	}
+}
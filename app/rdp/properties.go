@@ -0,0 +1,245 @@
+package rdp
+
+import (
+	"encoding/hex"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/chrilep/LaunchRDP/app/logging"
+)
+
+// Property groups, used to organize the settings UI
+const (
+	GroupConnection  = "connection"
+	GroupDisplay     = "display"
+	GroupPerformance = "performance"
+	GroupRedirection = "redirection"
+	GroupSecurity    = "security"
+	GroupRemoteApp   = "remoteapp"
+	GroupGateway     = "gateway"
+)
+
+// PropertyDef describes a supported .rdp property
+type PropertyDef struct {
+	Name        string   `json:"name"`
+	Type        string   `json:"type"`              // TypeString, TypeInteger or TypeBinary
+	Default     string   `json:"default"`           // value written when nothing else is set
+	Allowed     []string `json:"allowed,omitempty"` // permitted values, empty = any value of Type
+	Group       string   `json:"group"`
+	Description string   `json:"description"`
+	OmitEmpty   bool     `json:"omitEmpty,omitempty"` // not written at all while the value is empty
+}
+
+// Validate checks that value matches the property type and allowed values
+func (d PropertyDef) Validate(value string) error {
+	switch d.Type {
+	case TypeInteger:
+		if _, err := strconv.Atoi(value); err != nil {
+			return fmt.Errorf("property %q expects an integer, got %q", d.Name, value)
+		}
+	case TypeBinary:
+		if _, err := hex.DecodeString(value); err != nil {
+			return fmt.Errorf("property %q expects hex encoded binary data", d.Name)
+		}
+	}
+	if len(d.Allowed) == 0 {
+		return nil
+	}
+	for _, allowed := range d.Allowed {
+		if value == allowed {
+			return nil
+		}
+	}
+	return fmt.Errorf("property %q does not allow value %q (allowed: %s)", d.Name, value, strings.Join(d.Allowed, ", "))
+}
+
+var boolValues = []string{"0", "1"}
+
+// properties is the registry of every property the generator writes.
+// The slice order is the order of lines in the generated .rdp file, so keep
+// new entries in the section of their group to keep the output grouped.
+var properties = []PropertyDef{
+	// Connection settings
+	{Name: "full address", Type: TypeString, Group: GroupConnection, Description: "Host name or IP address of the remote computer"},
+	{Name: "server port", Type: TypeInteger, Default: "3389", Group: GroupConnection, Description: "TCP port of the remote computer"},
+	{Name: "username", Type: TypeString, Group: GroupConnection, Description: "User account used to sign in"},
+	{Name: "keyboardhook", Type: TypeInteger, Default: "1", Allowed: []string{"0", "1", "2"}, Group: GroupConnection, Description: "Windows key combinations: 0 = local, 1 = remote, 2 = remote in fullscreen only"},
+	{Name: "enableworkspacereconnect", Type: TypeInteger, Default: "0", Allowed: boolValues, Group: GroupConnection, Description: "Reconnect RemoteApp and desktop workspace sessions"},
+	{Name: "autoreconnection enabled", Type: TypeInteger, Default: "1", Allowed: boolValues, Group: GroupConnection, Description: "Reconnect automatically when the connection drops"},
+	{Name: "use redirection server name", Type: TypeInteger, Default: "0", Allowed: boolValues, Group: GroupConnection, Description: "Use the redirection server name for load balancing"},
+
+	// Display settings
+	{Name: "dynamic resolution", Type: TypeInteger, Default: "0", Allowed: boolValues, Group: GroupDisplay, Description: "Update the remote resolution when the window is resized"},
+	{Name: "screen mode id", Type: TypeInteger, Default: "1", Allowed: []string{"1", "2"}, Group: GroupDisplay, Description: "1 = windowed, 2 = fullscreen"},
+	{Name: "desktopwidth", Type: TypeInteger, Default: "1920", Group: GroupDisplay, Description: "Remote desktop width in pixels"},
+	{Name: "desktopheight", Type: TypeInteger, Default: "1080", Group: GroupDisplay, Description: "Remote desktop height in pixels"},
+	{Name: "winposstr", Type: TypeString, Group: GroupDisplay, Description: "Window position: 0,1,left,top,right,bottom"},
+	{Name: "use multimon", Type: TypeInteger, Default: "0", Allowed: boolValues, Group: GroupDisplay, Description: "Span the session across all local monitors"},
+	{Name: "selectedmonitors", Type: TypeString, Group: GroupDisplay, OmitEmpty: true, Description: "Comma separated monitor IDs used with use multimon, empty for all"},
+	{Name: "desktopscalefactor", Type: TypeInteger, Allowed: []string{"100", "125", "150", "175", "200", "250", "300", "400", "500"}, Group: GroupDisplay, OmitEmpty: true, Description: "Scale factor of the remote desktop in percent"},
+	{Name: "devicescalefactor", Type: TypeInteger, Allowed: []string{"100", "140", "180"}, Group: GroupDisplay, OmitEmpty: true, Description: "Scale factor of the remote device in percent"},
+	{Name: "smart sizing", Type: TypeInteger, Allowed: boolValues, Group: GroupDisplay, OmitEmpty: true, Description: "Scale the session to fit the window instead of showing scroll bars"},
+	{Name: "session bpp", Type: TypeInteger, Default: "32", Allowed: []string{"8", "15", "16", "24", "32"}, Group: GroupDisplay, Description: "Color depth in bits per pixel"},
+	{Name: "displayconnectionbar", Type: TypeInteger, Default: "1", Allowed: boolValues, Group: GroupDisplay, Description: "Show the connection bar in fullscreen mode"},
+
+	// Performance settings
+	{Name: "compression", Type: TypeInteger, Default: "1", Allowed: boolValues, Group: GroupPerformance, Description: "Compress data sent to the client"},
+	{Name: "videoplaybackmode", Type: TypeInteger, Default: "1", Allowed: boolValues, Group: GroupPerformance, Description: "Use RDP efficient multimedia streaming for video playback"},
+	{Name: "connection type", Type: TypeInteger, Default: "7", Allowed: []string{"1", "2", "3", "4", "5", "6", "7"}, Group: GroupPerformance, Description: "Connection speed: 1 = modem ... 6 = LAN, 7 = auto detect"},
+	{Name: "networkautodetect", Type: TypeInteger, Default: "1", Allowed: boolValues, Group: GroupPerformance, Description: "Detect the network type automatically"},
+	{Name: "bandwidthautodetect", Type: TypeInteger, Default: "1", Allowed: boolValues, Group: GroupPerformance, Description: "Detect the available bandwidth automatically"},
+	{Name: "disable wallpaper", Type: TypeInteger, Default: "0", Allowed: boolValues, Group: GroupPerformance, Description: "Hide the desktop wallpaper"},
+	{Name: "allow font smoothing", Type: TypeInteger, Default: "0", Allowed: boolValues, Group: GroupPerformance, Description: "Enable font smoothing (ClearType)"},
+	{Name: "allow desktop composition", Type: TypeInteger, Default: "0", Allowed: boolValues, Group: GroupPerformance, Description: "Enable desktop composition (Aero)"},
+	{Name: "disable full window drag", Type: TypeInteger, Default: "1", Allowed: boolValues, Group: GroupPerformance, Description: "Show only the window outline while dragging"},
+	{Name: "disable menu anims", Type: TypeInteger, Default: "1", Allowed: boolValues, Group: GroupPerformance, Description: "Disable menu and window animations"},
+	{Name: "disable themes", Type: TypeInteger, Default: "0", Allowed: boolValues, Group: GroupPerformance, Description: "Disable visual styles"},
+	{Name: "disable cursor setting", Type: TypeInteger, Default: "0", Allowed: boolValues, Group: GroupPerformance, Description: "Disable cursor shadow and blinking"},
+	{Name: "bitmapcachepersistenable", Type: TypeInteger, Default: "1", Allowed: boolValues, Group: GroupPerformance, Description: "Keep the bitmap cache on disk between sessions"},
+
+	// Device and drive redirection
+	{Name: "redirectclipboard", Type: TypeInteger, Default: "1", Allowed: boolValues, Group: GroupRedirection, Description: "Redirect the local clipboard"},
+	{Name: "audiocapturemode", Type: TypeInteger, Default: "1", Allowed: boolValues, Group: GroupRedirection, Description: "Redirect the local microphone"},
+	{Name: "audiomode", Type: TypeInteger, Default: "0", Allowed: []string{"0", "1", "2"}, Group: GroupRedirection, Description: "Audio output: 0 = local, 1 = remote, 2 = none"},
+	{Name: "redirectprinters", Type: TypeInteger, Default: "1", Allowed: boolValues, Group: GroupRedirection, Description: "Redirect local printers"},
+	{Name: "redirectlocation", Type: TypeInteger, Default: "1", Allowed: boolValues, Group: GroupRedirection, Description: "Redirect the local location"},
+	{Name: "redirectcomports", Type: TypeInteger, Default: "1", Allowed: boolValues, Group: GroupRedirection, Description: "Redirect local COM ports"},
+	{Name: "redirectsmartcards", Type: TypeInteger, Default: "1", Allowed: boolValues, Group: GroupRedirection, Description: "Redirect local smart cards"},
+	{Name: "redirectwebauthn", Type: TypeInteger, Default: "1", Allowed: boolValues, Group: GroupRedirection, Description: "Redirect WebAuthn requests (Windows Hello, security keys)"},
+	{Name: "redirectposdevices", Type: TypeInteger, Default: "0", Allowed: boolValues, Group: GroupRedirection, Description: "Redirect point of service devices"},
+	{Name: "camerastoredirect", Type: TypeString, Default: "*", Group: GroupRedirection, Description: "Cameras to redirect, * for all"},
+	{Name: "devicestoredirect", Type: TypeString, Default: "*", Group: GroupRedirection, Description: "Plug and play devices to redirect, * for all"},
+	{Name: "drivestoredirect", Type: TypeString, Group: GroupRedirection, Description: "Drives to redirect, * for all, empty for none"},
+
+	// RemoteApp settings (empty by default)
+	{Name: "remoteappmousemoveinject", Type: TypeInteger, Default: "1", Allowed: boolValues, Group: GroupRemoteApp, Description: "Inject local mouse movement into RemoteApp windows"},
+	{Name: "remoteapplicationmode", Type: TypeInteger, Default: "0", Allowed: boolValues, Group: GroupRemoteApp, Description: "Launch a RemoteApp instead of a full desktop"},
+	{Name: "alternate shell", Type: TypeString, Group: GroupRemoteApp, Description: "Program started instead of the desktop shell"},
+	{Name: "shell working directory", Type: TypeString, Group: GroupRemoteApp, Description: "Working directory of the alternate shell"},
+	{Name: "remoteapplicationprogram", Type: TypeString, Group: GroupRemoteApp, OmitEmpty: true, Description: "RemoteApp program: ||alias of a published app or full path"},
+	{Name: "remoteapplicationname", Type: TypeString, Group: GroupRemoteApp, OmitEmpty: true, Description: "RemoteApp display name"},
+	{Name: "remoteapplicationcmdline", Type: TypeString, Group: GroupRemoteApp, OmitEmpty: true, Description: "RemoteApp command-line arguments"},
+	{Name: "disableremoteappcapscheck", Type: TypeInteger, Allowed: boolValues, Group: GroupRemoteApp, OmitEmpty: true, Description: "Allow programs that are not published as RemoteApp"},
+
+	// Gateway settings (empty by default)
+	{Name: "gatewayhostname", Type: TypeString, Group: GroupGateway, Description: "Remote Desktop Gateway host name"},
+	{Name: "gatewayusagemethod", Type: TypeInteger, Default: "4", Allowed: []string{"0", "1", "2", "3", "4"}, Group: GroupGateway, Description: "0 = never, 1 = always, 2 = bypass for local, 3 = default, 4 = auto detect"},
+	{Name: "gatewaycredentialssource", Type: TypeInteger, Default: "4", Allowed: []string{"0", "1", "2", "3", "4", "5"}, Group: GroupGateway, Description: "0 = NTLM, 1 = smart card, 2 = logged on, 3 = prompt, 4 = select later, 5 = cookie"},
+	{Name: "gatewayprofileusagemethod", Type: TypeInteger, Default: "0", Allowed: boolValues, Group: GroupGateway, Description: "0 = use default gateway profile, 1 = use explicit settings"},
+	{Name: "promptcredentialonce", Type: TypeInteger, Default: "0", Allowed: boolValues, Group: GroupGateway, Description: "Use the same credentials for gateway and remote computer"},
+	{Name: "gatewaybrokeringtype", Type: TypeInteger, Default: "0", Group: GroupGateway, Description: "Gateway brokering type"},
+	{Name: "rdgiskdcproxy", Type: TypeInteger, Default: "0", Allowed: boolValues, Group: GroupGateway, Description: "Use the gateway as Kerberos KDC proxy"},
+	{Name: "kdcproxyname", Type: TypeString, Group: GroupGateway, Description: "Kerberos KDC proxy name"},
+
+	// Security settings
+	{Name: "authentication level", Type: TypeInteger, Default: "2", Allowed: []string{"0", "1", "2", "3"}, Group: GroupSecurity, Description: "Server authentication: 0 = connect, 1 = do not connect, 2 = warn, 3 = no requirement"},
+	{Name: "prompt for credentials", Type: TypeInteger, Default: "0", Allowed: boolValues, Group: GroupSecurity, Description: "Always prompt for credentials"},
+	{Name: "negotiate security layer", Type: TypeInteger, Default: "1", Allowed: boolValues, Group: GroupSecurity, Description: "Negotiate the security layer (TLS/NLA)"},
+	{Name: "enablerdsaadauth", Type: TypeInteger, Default: "0", Allowed: boolValues, Group: GroupSecurity, Description: "Use Microsoft Entra ID (Azure AD) authentication"},
+}
+
+// propertyIndex maps lower-case property names to their registry entry
+var propertyIndex = indexProperties(properties)
+
+func indexProperties(defs []PropertyDef) map[string]int {
+	index := make(map[string]int, len(defs))
+	for i, def := range defs {
+		index[def.Name] = i
+	}
+	return index
+}
+
+// Properties returns a copy of the property registry in output order
+func Properties() []PropertyDef {
+	defs := make([]PropertyDef, len(properties))
+	copy(defs, properties)
+	return defs
+}
+
+// LookupProperty returns the registry entry for a property name (case-insensitive)
+func LookupProperty(name string) (PropertyDef, bool) {
+	i, ok := propertyIndex[strings.ToLower(strings.TrimSpace(name))]
+	if !ok {
+		return PropertyDef{}, false
+	}
+	return properties[i], true
+}
+
+// DefaultValues returns the default value of every registered property
+func DefaultValues() map[string]string {
+	values := make(map[string]string, len(properties))
+	for _, def := range properties {
+		values[def.Name] = def.Default
+	}
+	return values
+}
+
+// renderProperties writes all registered properties in registry order
+func renderProperties(builder *strings.Builder, values map[string]string) {
+	for _, def := range properties {
+		value := values[def.Name]
+		if def.OmitEmpty && value == "" {
+			continue
+		}
+		builder.WriteString(fmt.Sprintf("%s:%s:%s\n", def.Name, def.Type, value))
+	}
+}
+
+// reservedProperties have dedicated host fields (the credential target depends
+// on them) and cannot be overridden per host
+var reservedProperties = map[string]bool{
+	"full address": true,
+	"server port":  true,
+	"username":     true,
+}
+
+// ValidateCustomProperty checks a single per-host override and returns the
+// canonical (lower-case) property name
+func ValidateCustomProperty(name, value string) (string, error) {
+	def, ok := LookupProperty(name)
+	if !ok {
+		return "", fmt.Errorf("unknown RDP property %q", name)
+	}
+	if reservedProperties[def.Name] {
+		return "", fmt.Errorf("RDP property %q is set from the host settings and cannot be overridden", def.Name)
+	}
+	if err := def.Validate(value); err != nil {
+		return "", err
+	}
+	return def.Name, nil
+}
+
+// NormalizeCustomProperties validates per-host overrides and returns them keyed
+// by canonical property name. An empty map is returned as nil. Names that only
+// differ in case are rejected, the value to keep would be ambiguous.
+func NormalizeCustomProperties(props map[string]string) (map[string]string, error) {
+	if len(props) == 0 {
+		return nil, nil
+	}
+	normalized := make(map[string]string, len(props))
+	for name, value := range props {
+		canonical, err := ValidateCustomProperty(name, value)
+		if err != nil {
+			return nil, err
+		}
+		if _, exists := normalized[canonical]; exists {
+			return nil, fmt.Errorf("RDP property %q is set more than once", canonical)
+		}
+		normalized[canonical] = value
+	}
+	return normalized, nil
+}
+
+// applyCustomProperties merges per-host overrides over the generated values.
+// Invalid entries (e.g. from a hand-edited hosts.json) are logged and skipped.
+func applyCustomProperties(values map[string]string, custom map[string]string) {
+	for name, value := range custom {
+		canonical, err := ValidateCustomProperty(name, value)
+		if err != nil {
+			logging.Log(true, "Warning: ignoring custom RDP property:", err)
+			continue
+		}
+		values[canonical] = value
+	}
+}
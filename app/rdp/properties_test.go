@@ -0,0 +1,86 @@
+package rdp
+
+import (
+	"maps"
+	"testing"
+)
+
+func TestValidateCustomProperty(t *testing.T) {
+	tests := []struct {
+		name      string
+		property  string
+		value     string
+		canonical string
+		wantErr   bool
+	}{
+		{name: "integer", property: "audiomode", value: "2", canonical: "audiomode"},
+		{name: "case and spaces", property: "  Disable Wallpaper ", value: "1", canonical: "disable wallpaper"},
+		{name: "string", property: "alternate shell", value: "C:\\Tools\\app.exe /x:1", canonical: "alternate shell"},
+		{name: "empty string", property: "drivestoredirect", value: "", canonical: "drivestoredirect"},
+		{name: "integer without allowed list", property: "desktopwidth", value: "2560", canonical: "desktopwidth"},
+		{name: "reserved full address", property: "full address", value: "other.example.com", wantErr: true},
+		{name: "reserved server port", property: "Server Port", value: "3390", wantErr: true},
+		{name: "reserved username", property: "username", value: "bob", wantErr: true},
+		{name: "unknown", property: "future setting", value: "1", wantErr: true},
+		{name: "integer expected", property: "desktopwidth", value: "wide", wantErr: true},
+		{name: "empty integer", property: "audiomode", value: "", wantErr: true},
+		{name: "value not allowed", property: "audiomode", value: "3", wantErr: true},
+		{name: "boolean not allowed", property: "redirectclipboard", value: "2", wantErr: true},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			canonical, err := ValidateCustomProperty(test.property, test.value)
+			if test.wantErr {
+				if err == nil {
+					t.Errorf("ValidateCustomProperty(%q, %q) = %q, want an error", test.property, test.value, canonical)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ValidateCustomProperty(%q, %q): %v", test.property, test.value, err)
+			}
+			if canonical != test.canonical {
+				t.Errorf("ValidateCustomProperty(%q) = %q, want %q", test.property, canonical, test.canonical)
+			}
+		})
+	}
+}
+
+func TestNormalizeCustomProperties(t *testing.T) {
+	tests := []struct {
+		name    string
+		props   map[string]string
+		want    map[string]string
+		wantErr bool
+	}{
+		{name: "nil", props: nil, want: nil},
+		{name: "empty is nil", props: map[string]string{}, want: nil},
+		{
+			name:  "canonical names",
+			props: map[string]string{"AudioMode": "1", "Smart Sizing": "1", "kdcproxyname": "kdc.example.com"},
+			want:  map[string]string{"audiomode": "1", "smart sizing": "1", "kdcproxyname": "kdc.example.com"},
+		},
+		{name: "duplicate after normalization", props: map[string]string{"audiomode": "1", "AudioMode": "2"}, wantErr: true},
+		{name: "duplicate with the same value", props: map[string]string{"audiomode": "1", " audiomode": "1"}, wantErr: true},
+		{name: "reserved", props: map[string]string{"audiomode": "1", "Username": "bob"}, wantErr: true},
+		{name: "type mismatch", props: map[string]string{"session bpp": "true color"}, wantErr: true},
+		{name: "unknown", props: map[string]string{"audiomode": "1", "not a property": "1"}, wantErr: true},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got, err := NormalizeCustomProperties(test.props)
+			if test.wantErr {
+				if err == nil {
+					t.Errorf("NormalizeCustomProperties() = %v, want an error", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if (got == nil) != (test.want == nil) || !maps.Equal(got, test.want) {
+				t.Errorf("NormalizeCustomProperties() = %v, want %v", got, test.want)
+			}
+		})
+	}
+}
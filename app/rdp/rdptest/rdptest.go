@@ -0,0 +1,86 @@
+// Package rdptest provides fakes for testing code that launches RDP sessions
+// without starting processes or looking at real windows.
+package rdptest
+
+import (
+	"strings"
+	"sync"
+
+	"github.com/chrilep/LaunchRDP/app/rdp"
+)
+
+var (
+	_ rdp.Launcher     = (*Launcher)(nil)
+	_ rdp.WindowFinder = (*WindowFinder)(nil)
+)
+
+// Launch is one recorded Launcher.Start call
+type Launch struct {
+	Program string
+	Args    []string
+	Stdin   string
+}
+
+// Launcher records started processes instead of running them
+type Launcher struct {
+	mu       sync.Mutex
+	launches []Launch
+	// Err is returned by Start when set (the call is recorded anyway)
+	Err error
+}
+
+// Start records the call and returns Err
+func (l *Launcher) Start(program string, args []string, stdin string) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.launches = append(l.launches, Launch{Program: program, Args: append([]string{}, args...), Stdin: stdin})
+	return l.Err
+}
+
+// Launches returns all recorded calls in order
+func (l *Launcher) Launches() []Launch {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return append([]Launch{}, l.launches...)
+}
+
+// WindowFinder simulates open session windows by address
+type WindowFinder struct {
+	mu        sync.Mutex
+	windows   map[string]uintptr
+	activated []uintptr
+}
+
+// NewWindowFinder creates a finder without open windows
+func NewWindowFinder() *WindowFinder {
+	return &WindowFinder{windows: make(map[string]uintptr)}
+}
+
+// AddWindow simulates an open session window for address
+func (f *WindowFinder) AddWindow(address string, window uintptr) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.windows[strings.ToLower(address)] = window
+}
+
+// FindWindow returns the simulated window of address (case-insensitive like window titles)
+func (f *WindowFinder) FindWindow(address string) (uintptr, bool) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	window, found := f.windows[strings.ToLower(address)]
+	return window, found
+}
+
+// Activate records the activated window
+func (f *WindowFinder) Activate(window uintptr) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.activated = append(f.activated, window)
+}
+
+// Activated returns all activated windows in order
+func (f *WindowFinder) Activated() []uintptr {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]uintptr{}, f.activated...)
+}
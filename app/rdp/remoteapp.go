@@ -0,0 +1,42 @@
+package rdp
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/chrilep/LaunchRDP/app/models"
+)
+
+// ValidateRemoteApp checks that a RemoteApp entry can be launched
+func ValidateRemoteApp(app models.RemoteApp) error {
+	if strings.TrimSpace(app.Program) == "" {
+		return fmt.Errorf("remote app %q has no program", app.Name)
+	}
+	if strings.ContainsAny(app.Program+app.CommandLine+app.WorkingDirectory, "\r\n") {
+		return fmt.Errorf("remote app %q contains line breaks", app.Name)
+	}
+	return nil
+}
+
+// FindRemoteApp returns the RemoteApp entry of a host by ID
+func FindRemoteApp(host models.Host, appID string) (models.RemoteApp, bool) {
+	for _, app := range host.RemoteApps {
+		if app.ID == appID {
+			return app, true
+		}
+	}
+	return models.RemoteApp{}, false
+}
+
+// applyRemoteApp switches the connection to RemoteApp mode for a single program
+func applyRemoteApp(values map[string]string, app models.RemoteApp) {
+	values["remoteapplicationmode"] = "1"
+	values["remoteapplicationprogram"] = app.Program
+	values["remoteapplicationname"] = app.Name
+	values["remoteapplicationcmdline"] = app.CommandLine
+	values["shell working directory"] = app.WorkingDirectory
+	// Programs given by path instead of a published "||alias" need the capability check disabled
+	if !strings.HasPrefix(app.Program, "||") {
+		values["disableremoteappcapscheck"] = "1"
+	}
+}
@@ -0,0 +1,37 @@
+package rdp
+
+import (
+	"strconv"
+
+	"github.com/chrilep/LaunchRDP/app/models"
+)
+
+// ValidateScaling checks scale factors against the property registry (0 = not set)
+func ValidateScaling(desktopScaleFactor, deviceScaleFactor int) error {
+	if desktopScaleFactor != 0 {
+		def, _ := LookupProperty("desktopscalefactor")
+		if err := def.Validate(strconv.Itoa(desktopScaleFactor)); err != nil {
+			return err
+		}
+	}
+	if deviceScaleFactor != 0 {
+		def, _ := LookupProperty("devicescalefactor")
+		if err := def.Validate(strconv.Itoa(deviceScaleFactor)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// applyScaling writes the scaling properties that are set on the host
+func applyScaling(values map[string]string, host models.Host) {
+	if host.DesktopScaleFactor > 0 {
+		values["desktopscalefactor"] = strconv.Itoa(host.DesktopScaleFactor)
+	}
+	if host.DeviceScaleFactor > 0 {
+		values["devicescalefactor"] = strconv.Itoa(host.DeviceScaleFactor)
+	}
+	if host.SmartSizing {
+		values["smart sizing"] = "1"
+	}
+}
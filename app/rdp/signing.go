@@ -0,0 +1,393 @@
+package rdp
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/pem"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+	"unicode/utf16"
+
+	"software.sslmate.com/src/go-pkcs12"
+
+	"github.com/chrilep/LaunchRDP/app/logging"
+)
+
+// signedProperties maps the properties rdpsign signs to the names used in "signscope".
+// Only these properties are covered by the signature, all others can still be changed.
+var signedProperties = map[string]string{
+	"full address":                      "Full Address",
+	"alternate full address":            "Alternate Full Address",
+	"pcb":                               "PCB",
+	"use redirection server name":       "Use Redirection Server Name",
+	"server port":                       "Server Port",
+	"negotiate security layer":          "Negotiate Security Layer",
+	"enablecredsspsupport":              "EnableCredSspSupport",
+	"disableconnectionsharing":          "DisableConnectionSharing",
+	"autoreconnection enabled":          "AutoReconnection Enabled",
+	"gatewayhostname":                   "GatewayHostname",
+	"gatewayusagemethod":                "GatewayUsageMethod",
+	"gatewayprofileusagemethod":         "GatewayProfileUsageMethod",
+	"gatewaycredentialssource":          "GatewayCredentialsSource",
+	"support url":                       "Support URL",
+	"promptcredentialonce":              "PromptCredentialOnce",
+	"require pre-authentication":        "Require pre-authentication",
+	"pre-authentication server address": "Pre-authentication server address",
+	"alternate shell":                   "Alternate Shell",
+	"shell working directory":           "Shell Working Directory",
+	"remoteapplicationprogram":          "RemoteApplicationProgram",
+	"remoteapplicationexpandworkingdir": "RemoteApplicationExpandWorkingdir",
+	"remoteapplicationmode":             "RemoteApplicationMode",
+	"remoteapplicationguid":             "RemoteApplicationGuid",
+	"remoteapplicationname":             "RemoteApplicationName",
+	"remoteapplicationicon":             "RemoteApplicationIcon",
+	"remoteapplicationfile":             "RemoteApplicationFile",
+	"remoteapplicationfileextensions":   "RemoteApplicationFileExtensions",
+	"remoteapplicationcmdline":          "RemoteApplicationCmdLine",
+	"remoteapplicationexpandcmdline":    "RemoteApplicationExpandCmdLine",
+	"prompt for credentials":            "Prompt For Credentials",
+	"authentication level":              "Authentication Level",
+	"audiomode":                         "AudioMode",
+	"redirectdrives":                    "RedirectDrives",
+	"redirectprinters":                  "RedirectPrinters",
+	"redirectcomports":                  "RedirectCOMPorts",
+	"redirectsmartcards":                "RedirectSmartCards",
+	"redirectposdevices":                "RedirectPOSDevices",
+	"redirectclipboard":                 "RedirectClipboard",
+	"devicestoredirect":                 "DevicesToRedirect",
+	"drivestoredirect":                  "DrivesToRedirect",
+	"loadbalanceinfo":                   "LoadBalanceInfo",
+	"redirectdirectx":                   "RedirectDirectX",
+	"rdgiskdcproxy":                     "RDGIsKDCProxy",
+	"kdcproxyname":                      "KDCProxyName",
+	"eventloguploadaddress":             "EventLogUploadAddress",
+}
+
+// Signer signs generated .rdp content like rdpsign.exe
+type Signer struct {
+	cert  *x509.Certificate
+	chain []*x509.Certificate // intermediate certificates embedded in the signature
+	key   crypto.Signer
+}
+
+// SignerInfo describes the signing certificate for the UI
+type SignerInfo struct {
+	Subject    string    `json:"subject"`
+	Issuer     string    `json:"issuer"`
+	Thumbprint string    `json:"thumbprint"` // SHA-1, as shown by Windows and used for trusted publishers
+	NotAfter   time.Time `json:"notAfter"`
+}
+
+// NewSigner creates a signer from a certificate and its private key (RSA or ECDSA)
+func NewSigner(cert *x509.Certificate, key crypto.Signer, chain ...*x509.Certificate) (*Signer, error) {
+	switch key.(type) {
+	case *rsa.PrivateKey, *ecdsa.PrivateKey:
+	default:
+		return nil, fmt.Errorf("unsupported private key type %T", key)
+	}
+	if !publicKeysEqual(cert.PublicKey, key.Public()) {
+		return nil, fmt.Errorf("private key does not match certificate %q", cert.Subject.CommonName)
+	}
+	return &Signer{cert: cert, chain: chain, key: key}, nil
+}
+
+// LoadSignerPFX loads certificate, key and chain from a PFX/PKCS#12 file. Both
+// the AES-256 (PBES2) files current Windows versions export and legacy
+// TripleDES/RC2 files are supported.
+func LoadSignerPFX(path, password string) (*Signer, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read PFX file: %w", err)
+	}
+	key, cert, chain, err := pkcs12.DecodeChain(data, password)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode PFX file: %w", err)
+	}
+	signer, ok := key.(crypto.Signer)
+	if !ok {
+		return nil, fmt.Errorf("unsupported private key type %T", key)
+	}
+	return NewSigner(cert, signer, chain...)
+}
+
+// LoadSignerPEM loads a PEM certificate (optionally followed by its chain) and a PEM private key.
+// keyPath may be empty if the key is stored in the certificate file.
+func LoadSignerPEM(certPath, keyPath string) (*Signer, error) {
+	var blocks []*pem.Block
+	for _, path := range []string{certPath, keyPath} {
+		if path == "" {
+			continue
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read PEM file: %w", err)
+		}
+		for {
+			var block *pem.Block
+			block, data = pem.Decode(data)
+			if block == nil {
+				break
+			}
+			blocks = append(blocks, block)
+		}
+	}
+	return signerFromPEMBlocks(blocks)
+}
+
+// signerFromPEMBlocks picks the private key and the certificate matching it
+func signerFromPEMBlocks(blocks []*pem.Block) (*Signer, error) {
+	var key crypto.Signer
+	var certs []*x509.Certificate
+	for _, block := range blocks {
+		switch {
+		case block.Type == "CERTIFICATE":
+			cert, err := x509.ParseCertificate(block.Bytes)
+			if err != nil {
+				return nil, fmt.Errorf("failed to parse certificate: %w", err)
+			}
+			certs = append(certs, cert)
+		case strings.HasSuffix(block.Type, "PRIVATE KEY"):
+			if key != nil {
+				return nil, fmt.Errorf("found more than one private key")
+			}
+			parsed, err := parsePrivateKey(block.Bytes)
+			if err != nil {
+				return nil, err
+			}
+			key = parsed
+		}
+	}
+	if key == nil {
+		return nil, fmt.Errorf("no private key found")
+	}
+	for i, cert := range certs {
+		if publicKeysEqual(cert.PublicKey, key.Public()) {
+			chain := append(append([]*x509.Certificate{}, certs[:i]...), certs[i+1:]...)
+			return NewSigner(cert, key, chain...)
+		}
+	}
+	return nil, fmt.Errorf("no certificate matches the private key")
+}
+
+// parsePrivateKey accepts PKCS#8, PKCS#1 (RSA) and SEC 1 (EC) encoded keys
+func parsePrivateKey(der []byte) (crypto.Signer, error) {
+	if key, err := x509.ParsePKCS8PrivateKey(der); err == nil {
+		if signer, ok := key.(crypto.Signer); ok {
+			return signer, nil
+		}
+		return nil, fmt.Errorf("unsupported private key type %T", key)
+	}
+	if key, err := x509.ParsePKCS1PrivateKey(der); err == nil {
+		return key, nil
+	}
+	if key, err := x509.ParseECPrivateKey(der); err == nil {
+		return key, nil
+	}
+	return nil, fmt.Errorf("failed to parse private key (expected PKCS#8, PKCS#1 or EC)")
+}
+
+func publicKeysEqual(a, b crypto.PublicKey) bool {
+	key, ok := a.(interface{ Equal(crypto.PublicKey) bool })
+	return ok && key.Equal(b)
+}
+
+// Info returns subject, issuer, thumbprint and expiry of the signing certificate
+func (s *Signer) Info() SignerInfo {
+	sum := sha1.Sum(s.cert.Raw)
+	return SignerInfo{
+		Subject:    s.cert.Subject.String(),
+		Issuer:     s.cert.Issuer.String(),
+		Thumbprint: strings.ToUpper(hex.EncodeToString(sum[:])),
+		NotAfter:   s.cert.NotAfter,
+	}
+}
+
+// Sign returns content with "signscope" and "signature" appended, like rdpsign.exe.
+// Existing signature lines are replaced. "alternate full address" is always set
+// to the full address, so a signed file cannot connect to another host than the
+// one its full address names.
+func (s *Signer) Sign(content string) (string, error) {
+	var lines []string
+	fullAddress := ""
+	for _, line := range strings.Split(strings.ReplaceAll(content, "\r\n", "\n"), "\n") {
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		name, rest, _ := strings.Cut(line, ":")
+		name = strings.ToLower(strings.TrimSpace(name))
+		if name == "signscope" || name == "signature" || name == "alternate full address" {
+			continue
+		}
+		if name == "full address" {
+			if fullAddress != "" {
+				return "", fmt.Errorf("cannot sign RDP content with more than one full address")
+			}
+			fullAddress = rest
+		}
+		lines = append(lines, line)
+	}
+	if fullAddress == "" {
+		return "", fmt.Errorf("cannot sign RDP content without full address")
+	}
+	lines = append(lines, "alternate full address:"+fullAddress)
+
+	// Signed lines and the scope keep the order of the file
+	var signed, scope []string
+	for _, line := range lines {
+		name, _, _ := strings.Cut(line, ":")
+		if scopeName, ok := signedProperties[strings.ToLower(strings.TrimSpace(name))]; ok {
+			signed = append(signed, line)
+			scope = append(scope, scopeName)
+		}
+	}
+	scopeLine := "signscope:s:" + strings.Join(scope, ",")
+	signed = append(signed, scopeLine)
+
+	// rdpsign signs the UTF-16LE text of the signed lines, CRLF terminated, plus a NUL
+	message := utf16LE(strings.Join(signed, "\r\n") + "\r\n\x00")
+	signature, err := s.signDetached(message)
+	if err != nil {
+		return "", err
+	}
+
+	// Signature blob: version 0x00010001, type 1, length, then the PKCS#7 data
+	var blob bytes.Buffer
+	binary.Write(&blob, binary.LittleEndian, uint32(0x00010001))
+	binary.Write(&blob, binary.LittleEndian, uint32(1))
+	binary.Write(&blob, binary.LittleEndian, uint32(len(signature)))
+	blob.Write(signature)
+
+	var builder strings.Builder
+	for _, line := range lines {
+		builder.WriteString(line + "\n")
+	}
+	builder.WriteString(scopeLine + "\n")
+	builder.WriteString("signature:s:" + base64.StdEncoding.EncodeToString(blob.Bytes()) + "\n")
+	return builder.String(), nil
+}
+
+// SetSigner sets the certificate generated files are signed with (nil = unsigned files)
+func (g *Generator) SetSigner(signer *Signer) {
+	g.signer = signer
+}
+
+// signContent signs generated content if a signer is configured
+func (g *Generator) signContent(content string) (string, error) {
+	if g.signer == nil {
+		return content, nil
+	}
+	signed, err := g.signer.Sign(content)
+	if err != nil {
+		logging.Log(true, "ERROR: Failed to sign RDP file:", err)
+		return "", err
+	}
+	return signed, nil
+}
+
+func utf16LE(s string) []byte {
+	units := utf16.Encode([]rune(s))
+	out := make([]byte, 2*len(units))
+	for i, u := range units {
+		binary.LittleEndian.PutUint16(out[2*i:], u)
+	}
+	return out
+}
+
+// ASN.1 structures of a detached CMS/PKCS#7 SignedData without signed attributes
+// (what "openssl smime -sign -binary -noattr -outform DER" produces)
+var (
+	oidData            = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 7, 1}
+	oidSignedData      = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 7, 2}
+	oidSHA256          = asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 2, 1}
+	oidRSAEncryption   = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 1, 1}
+	oidECDSAWithSHA256 = asn1.ObjectIdentifier{1, 2, 840, 10045, 4, 3, 2}
+)
+
+type contentInfo struct {
+	ContentType asn1.ObjectIdentifier
+	Content     asn1.RawValue // [0] EXPLICIT, tagged in the RawValue
+}
+
+type encapsulatedContentInfo struct {
+	ContentType asn1.ObjectIdentifier
+}
+
+type issuerAndSerialNumber struct {
+	Issuer       asn1.RawValue
+	SerialNumber asn1.RawValue
+}
+
+type signerInfo struct {
+	Version            int
+	SID                issuerAndSerialNumber
+	DigestAlgorithm    pkix.AlgorithmIdentifier
+	SignatureAlgorithm pkix.AlgorithmIdentifier
+	Signature          []byte
+}
+
+type signedData struct {
+	Version          int
+	DigestAlgorithms []pkix.AlgorithmIdentifier `asn1:"set"`
+	EncapContentInfo encapsulatedContentInfo
+	Certificates     asn1.RawValue // [0] IMPLICIT, tagged in the RawValue
+	SignerInfos      []signerInfo  `asn1:"set"`
+}
+
+// signDetached creates a DER encoded detached SignedData over message (SHA-256)
+func (s *Signer) signDetached(message []byte) ([]byte, error) {
+	digest := sha256.Sum256(message)
+	signature, err := s.key.Sign(rand.Reader, digest[:], crypto.SHA256)
+	if err != nil {
+		return nil, fmt.Errorf("failed to sign RDP content: %w", err)
+	}
+
+	signatureAlgorithm := pkix.AlgorithmIdentifier{Algorithm: oidRSAEncryption, Parameters: asn1.NullRawValue}
+	if _, ok := s.key.(*ecdsa.PrivateKey); ok {
+		signatureAlgorithm = pkix.AlgorithmIdentifier{Algorithm: oidECDSAWithSHA256}
+	}
+	digestAlgorithm := pkix.AlgorithmIdentifier{Algorithm: oidSHA256, Parameters: asn1.NullRawValue}
+
+	serial, err := asn1.Marshal(s.cert.SerialNumber)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode certificate serial number: %w", err)
+	}
+	var certs []byte
+	for _, cert := range append([]*x509.Certificate{s.cert}, s.chain...) {
+		certs = append(certs, cert.Raw...)
+	}
+
+	sd := signedData{
+		Version:          1,
+		DigestAlgorithms: []pkix.AlgorithmIdentifier{digestAlgorithm},
+		EncapContentInfo: encapsulatedContentInfo{ContentType: oidData},
+		Certificates:     asn1.RawValue{Class: asn1.ClassContextSpecific, Tag: 0, IsCompound: true, Bytes: certs},
+		SignerInfos: []signerInfo{{
+			Version: 1,
+			SID: issuerAndSerialNumber{
+				Issuer:       asn1.RawValue{FullBytes: s.cert.RawIssuer},
+				SerialNumber: asn1.RawValue{FullBytes: serial},
+			},
+			DigestAlgorithm:    digestAlgorithm,
+			SignatureAlgorithm: signatureAlgorithm,
+			Signature:          signature,
+		}},
+	}
+	inner, err := asn1.Marshal(sd)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode signed data: %w", err)
+	}
+	return asn1.Marshal(contentInfo{ContentType: oidSignedData, Content: asn1.RawValue{Class: asn1.ClassContextSpecific, Tag: 0, IsCompound: true, Bytes: inner}})
+}
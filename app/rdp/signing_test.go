@@ -0,0 +1,398 @@
+package rdp
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/pem"
+	"math/big"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"software.sslmate.com/src/go-pkcs12"
+)
+
+// selfSigned creates a self-signed code signing certificate for key
+func selfSigned(t *testing.T, key crypto.Signer, name string) *x509.Certificate {
+	t.Helper()
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(time.Now().UnixNano()),
+		Subject:      pkix.Name{CommonName: name},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(24 * time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageCodeSigning},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, key.Public(), key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return cert
+}
+
+func newRSAKey(t *testing.T) crypto.Signer {
+	t.Helper()
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return key
+}
+
+func newECDSAKey(t *testing.T) crypto.Signer {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return key
+}
+
+// verifySigned parses the signature of signed content back and verifies it
+// against the lines named in signscope and the certificate. It returns the
+// signed property names.
+func verifySigned(t *testing.T, signed string, cert *x509.Certificate) []string {
+	t.Helper()
+	var lines []string
+	var scopeLine, signatureValue string
+	for _, line := range strings.Split(strings.TrimSuffix(signed, "\n"), "\n") {
+		switch {
+		case strings.HasPrefix(line, "signscope:s:"):
+			scopeLine = line
+		case strings.HasPrefix(line, "signature:s:"):
+			signatureValue = strings.TrimPrefix(line, "signature:s:")
+		default:
+			lines = append(lines, line)
+		}
+	}
+	if scopeLine == "" || signatureValue == "" {
+		t.Fatalf("signscope or signature missing:\n%s", signed)
+	}
+
+	// Lines of the scope, in file order
+	scope := map[string]bool{}
+	for _, name := range strings.Split(strings.TrimPrefix(scopeLine, "signscope:s:"), ",") {
+		scope[name] = true
+	}
+	var message, names []string
+	for _, line := range lines {
+		name, _, _ := strings.Cut(line, ":")
+		if scope[signedProperties[name]] {
+			message = append(message, line)
+			names = append(names, name)
+		}
+	}
+	if len(message) != len(scope) {
+		t.Fatalf("signscope names %d properties, the file has %d of them", len(scope), len(message))
+	}
+	message = append(message, scopeLine)
+	digest := sha256.Sum256(utf16LE(strings.Join(message, "\r\n") + "\r\n\x00"))
+
+	blob, err := base64.StdEncoding.DecodeString(signatureValue)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(blob) < 12 {
+		t.Fatalf("signature blob too short: %d bytes", len(blob))
+	}
+	if version, kind := binary.LittleEndian.Uint32(blob), binary.LittleEndian.Uint32(blob[4:]); version != 0x00010001 || kind != 1 {
+		t.Errorf("blob header = %#x, %d", version, kind)
+	}
+	if length := binary.LittleEndian.Uint32(blob[8:]); int(length) != len(blob)-12 {
+		t.Errorf("blob length = %d, want %d", length, len(blob)-12)
+	}
+
+	var info contentInfo
+	if _, err := asn1.Unmarshal(blob[12:], &info); err != nil {
+		t.Fatalf("failed to parse ContentInfo: %v", err)
+	}
+	if !info.ContentType.Equal(oidSignedData) {
+		t.Fatalf("content type = %v, want signedData", info.ContentType)
+	}
+	var sd signedData
+	if _, err := asn1.Unmarshal(info.Content.Bytes, &sd); err != nil {
+		t.Fatalf("failed to parse SignedData: %v", err)
+	}
+	if !bytes.HasPrefix(sd.Certificates.Bytes, cert.Raw) {
+		t.Error("signing certificate is not embedded first")
+	}
+	if len(sd.SignerInfos) != 1 {
+		t.Fatalf("%d signer infos, want 1", len(sd.SignerInfos))
+	}
+	signer := sd.SignerInfos[0]
+	if !bytes.Equal(signer.SID.Issuer.FullBytes, cert.RawIssuer) {
+		t.Error("signer issuer does not match the certificate")
+	}
+	var serial *big.Int
+	if _, err := asn1.Unmarshal(signer.SID.SerialNumber.FullBytes, &serial); err != nil || serial.Cmp(cert.SerialNumber) != 0 {
+		t.Errorf("signer serial = %v, want %v", serial, cert.SerialNumber)
+	}
+	if !signer.DigestAlgorithm.Algorithm.Equal(oidSHA256) {
+		t.Errorf("digest algorithm = %v, want SHA-256", signer.DigestAlgorithm.Algorithm)
+	}
+
+	switch key := cert.PublicKey.(type) {
+	case *rsa.PublicKey:
+		if err := rsa.VerifyPKCS1v15(key, crypto.SHA256, digest[:], signer.Signature); err != nil {
+			t.Errorf("RSA signature does not verify: %v", err)
+		}
+	case *ecdsa.PublicKey:
+		if !ecdsa.VerifyASN1(key, digest[:], signer.Signature) {
+			t.Error("ECDSA signature does not verify")
+		}
+	default:
+		t.Fatalf("unexpected key type %T", key)
+	}
+	return names
+}
+
+func TestSignVerifies(t *testing.T) {
+	for _, test := range []struct {
+		name string
+		key  func(t *testing.T) crypto.Signer
+	}{
+		{"rsa", newRSAKey},
+		{"ecdsa", newECDSAKey},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			key := test.key(t)
+			cert := selfSigned(t, key, "LaunchRDP test")
+			signer, err := NewSigner(cert, key)
+			if err != nil {
+				t.Fatal(err)
+			}
+			content := NewGenerator().buildRDPContent(goldenHost(), goldenUser(), nil)
+			signed, err := signer.Sign(content)
+			if err != nil {
+				t.Fatal(err)
+			}
+			names := verifySigned(t, signed, cert)
+			for _, want := range []string{"full address", "alternate full address", "server port", "redirectclipboard"} {
+				if !strings.Contains(strings.Join(names, ","), want) {
+					t.Errorf("%q is not signed (scope %v)", want, names)
+				}
+			}
+			if !strings.Contains(signed, "alternate full address:s:srv.example.com\n") {
+				t.Error("alternate full address is not the full address")
+			}
+
+			// Signing signed content again replaces the signature
+			again, err := signer.Sign(signed)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if strings.Count(again, "signature:s:") != 1 || strings.Count(again, "alternate full address:") != 1 {
+				t.Errorf("re-signed content has duplicate lines:\n%s", again)
+			}
+			verifySigned(t, again, cert)
+		})
+	}
+}
+
+// TestSignOverridesAlternateAddress checks that a signed file always connects
+// to the host of its full address
+func TestSignOverridesAlternateAddress(t *testing.T) {
+	key := newECDSAKey(t)
+	cert := selfSigned(t, key, "LaunchRDP test")
+	signer, err := NewSigner(cert, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	content := "full address:s:srv.example.com\nalternate full address:s:evil.example.com\nsignscope:s:Full Address\nsignature:s:AAAA\n"
+	signed, err := signer.Sign(content)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(signed, "evil") || strings.Contains(signed, "signature:s:AAAA") {
+		t.Errorf("foreign alternate address or signature kept:\n%s", signed)
+	}
+	verifySigned(t, signed, cert)
+
+	if _, err := signer.Sign("server port:i:3389\n"); err == nil {
+		t.Error("content without full address was signed")
+	}
+	if _, err := signer.Sign("full address:s:a\nfull address:s:b\n"); err == nil {
+		t.Error("content with two full addresses was signed")
+	}
+}
+
+func TestImportedAlternateAddressDropped(t *testing.T) {
+	file, err := Parse([]byte("full address:s:srv.example.com\nalternate full address:s:evil.example.com\nsignscope:s:Full Address\nsignature:s:AAAA\nsome unknown:s:kept\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	imported := ImportHost(file, "srv")
+	for _, name := range []string{"alternate full address", "signscope", "signature"} {
+		if _, ok := imported.Host.ImportedProperties[name]; ok {
+			t.Errorf("%q was imported", name)
+		}
+	}
+
+	// Hosts imported before the property was dropped
+	host := goldenHost()
+	host.ImportedProperties = map[string]string{"alternate full address": "s:evil.example.com", "signature": "s:AAAA", "some unknown": "s:kept"}
+	content := NewGenerator().buildRDPContent(host, goldenUser(), nil)
+	if strings.Contains(content, "evil") || strings.Contains(content, "signature") {
+		t.Errorf("dropped imported properties written:\n%s", content)
+	}
+	if !strings.Contains(content, "some unknown:s:kept\n") {
+		t.Error("unknown imported property missing")
+	}
+}
+
+func TestNewSignerRejectsKeys(t *testing.T) {
+	key := newRSAKey(t)
+	cert := selfSigned(t, key, "LaunchRDP test")
+	if _, err := NewSigner(cert, newECDSAKey(t)); err == nil {
+		t.Error("key of another certificate accepted")
+	}
+	_, edKey, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := NewSigner(selfSigned(t, edKey, "ed25519"), edKey); err == nil {
+		t.Error("Ed25519 key accepted")
+	}
+}
+
+func writePEM(t *testing.T, path string, blocks ...*pem.Block) {
+	t.Helper()
+	var data []byte
+	for _, block := range blocks {
+		data = append(data, pem.EncodeToMemory(block)...)
+	}
+	if err := os.WriteFile(path, data, 0600); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestLoadSignerPEM(t *testing.T) {
+	dir := t.TempDir()
+	rsaKey := newRSAKey(t).(*rsa.PrivateKey)
+	rsaCert := selfSigned(t, rsaKey, "RSA signer")
+	ecKey := newECDSAKey(t).(*ecdsa.PrivateKey)
+	ecCert := selfSigned(t, ecKey, "ECDSA signer")
+	pkcs8, err := x509.MarshalPKCS8PrivateKey(rsaKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sec1, err := x509.MarshalECPrivateKey(ecKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Separate files, PKCS#8 key
+	writePEM(t, filepath.Join(dir, "rsa.crt"), &pem.Block{Type: "CERTIFICATE", Bytes: rsaCert.Raw})
+	writePEM(t, filepath.Join(dir, "rsa.key"), &pem.Block{Type: "PRIVATE KEY", Bytes: pkcs8})
+	// One file: PKCS#1 key, then a chain certificate before the signing certificate
+	writePEM(t, filepath.Join(dir, "rsa.pem"),
+		&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(rsaKey)},
+		&pem.Block{Type: "CERTIFICATE", Bytes: ecCert.Raw},
+		&pem.Block{Type: "CERTIFICATE", Bytes: rsaCert.Raw})
+	// SEC 1 EC key
+	writePEM(t, filepath.Join(dir, "ec.pem"),
+		&pem.Block{Type: "CERTIFICATE", Bytes: ecCert.Raw},
+		&pem.Block{Type: "EC PRIVATE KEY", Bytes: sec1})
+	writePEM(t, filepath.Join(dir, "ec.key"), &pem.Block{Type: "EC PRIVATE KEY", Bytes: sec1})
+
+	for _, test := range []struct {
+		name, cert, key string
+		want            *x509.Certificate
+		chain           int
+	}{
+		{"separate files", "rsa.crt", "rsa.key", rsaCert, 0},
+		{"combined with chain", "rsa.pem", "", rsaCert, 1},
+		{"ec key", "ec.pem", "", ecCert, 0},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			keyPath := ""
+			if test.key != "" {
+				keyPath = filepath.Join(dir, test.key)
+			}
+			signer, err := LoadSignerPEM(filepath.Join(dir, test.cert), keyPath)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !signer.cert.Equal(test.want) || len(signer.chain) != test.chain {
+				t.Errorf("loaded %q with %d chain certificates, want %q with %d",
+					signer.cert.Subject.CommonName, len(signer.chain), test.want.Subject.CommonName, test.chain)
+			}
+			signed, err := signer.Sign("full address:s:srv.example.com\n")
+			if err != nil {
+				t.Fatal(err)
+			}
+			verifySigned(t, signed, test.want)
+		})
+	}
+
+	// Certificate without its key
+	if _, err := LoadSignerPEM(filepath.Join(dir, "rsa.crt"), filepath.Join(dir, "ec.key")); err == nil {
+		t.Error("mismatched certificate and key accepted")
+	}
+	if _, err := LoadSignerPEM(filepath.Join(dir, "rsa.crt"), ""); err == nil {
+		t.Error("certificate without key accepted")
+	}
+}
+
+func TestLoadSignerPFX(t *testing.T) {
+	dir := t.TempDir()
+	caKey := newECDSAKey(t)
+	caCert := selfSigned(t, caKey, "Chain")
+	for _, test := range []struct {
+		name    string
+		encoder *pkcs12.Encoder
+		key     func(t *testing.T) crypto.Signer
+	}{
+		// Current Windows exports AES-256 with PBES2
+		{"aes256 rsa", pkcs12.Modern2023, newRSAKey},
+		{"aes256 ecdsa", pkcs12.Modern2023, newECDSAKey},
+		{"tripledes rsa", pkcs12.LegacyDES, newRSAKey},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			key := test.key(t)
+			cert := selfSigned(t, key, "PFX signer")
+			data, err := test.encoder.Encode(key, cert, []*x509.Certificate{caCert}, "secret")
+			if err != nil {
+				t.Fatal(err)
+			}
+			path := filepath.Join(dir, strings.ReplaceAll(test.name, " ", "_")+".pfx")
+			if err := os.WriteFile(path, data, 0600); err != nil {
+				t.Fatal(err)
+			}
+
+			signer, err := LoadSignerPFX(path, "secret")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !signer.cert.Equal(cert) || len(signer.chain) != 1 {
+				t.Errorf("loaded %q with %d chain certificates", signer.cert.Subject.CommonName, len(signer.chain))
+			}
+			signed, err := signer.Sign("full address:s:srv.example.com\n")
+			if err != nil {
+				t.Fatal(err)
+			}
+			verifySigned(t, signed, cert)
+
+			if _, err := LoadSignerPFX(path, "wrong"); err == nil {
+				t.Error("wrong PFX password accepted")
+			}
+		})
+	}
+}
@@ -0,0 +1,14 @@
+//go:build !windows
+
+package rdp
+
+// noWindowFinder never finds a window: FreeRDP sessions are not reused
+type noWindowFinder struct{}
+
+func defaultWindowFinder() WindowFinder {
+	return noWindowFinder{}
+}
+
+func (noWindowFinder) FindWindow(string) (uintptr, bool) { return 0, false }
+
+func (noWindowFinder) Activate(uintptr) {}
@@ -0,0 +1,105 @@
+//go:build windows
+
+package rdp
+
+import (
+	"strings"
+	"syscall"
+	"unsafe"
+
+	"github.com/chrilep/LaunchRDP/app/logging"
+)
+
+// Windows API declarations for window enumeration
+var (
+	user32                  = syscall.NewLazyDLL("user32.dll")
+	procEnumWindows         = user32.NewProc("EnumWindows")
+	procGetWindowTextW      = user32.NewProc("GetWindowTextW")
+	procGetClassNameW       = user32.NewProc("GetClassNameW")
+	procIsWindowVisible     = user32.NewProc("IsWindowVisible")
+	procSetForegroundWindow = user32.NewProc("SetForegroundWindow")
+	procShowWindow          = user32.NewProc("ShowWindow")
+	procIsIconic            = user32.NewProc("IsIconic")
+)
+
+const (
+	SW_RESTORE = 9
+)
+
+// mstscWindowFinder finds mstsc.exe session windows by their title
+type mstscWindowFinder struct{}
+
+func defaultWindowFinder() WindowFinder {
+	return mstscWindowFinder{}
+}
+
+// FindWindow searches for an existing mstsc.exe window with the target address
+func (mstscWindowFinder) FindWindow(targetAddress string) (uintptr, bool) {
+	debug := false
+	logging.Log(debug, "Searching for existing RDP window for:", targetAddress)
+
+	var foundHwnd uintptr
+
+	// Callback function for EnumWindows
+	callback := syscall.NewCallback(func(hwnd uintptr, lParam uintptr) uintptr {
+		// Check if window is visible
+		visible, _, _ := procIsWindowVisible.Call(hwnd)
+		if visible == 0 {
+			return 1 // Continue enumeration
+		}
+
+		// Get window class name
+		className := make([]uint16, 256)
+		procGetClassNameW.Call(hwnd, uintptr(unsafe.Pointer(&className[0])), 256)
+		classNameStr := syscall.UTF16ToString(className)
+
+		// Check if it's a Terminal Services Client window (mstsc.exe)
+		if classNameStr != "TscShellContainerClass" {
+			return 1 // Continue enumeration
+		}
+
+		// Get window title
+		titleBuf := make([]uint16, 512)
+		procGetWindowTextW.Call(hwnd, uintptr(unsafe.Pointer(&titleBuf[0])), 512)
+		title := syscall.UTF16ToString(titleBuf)
+
+		logging.Log(debug, "Found RDP window with title:", title)
+
+		// Check if the title contains the target address
+		if strings.Contains(strings.ToLower(title), strings.ToLower(targetAddress)) {
+			logging.Log(debug, "Match found! HWND:", hwnd)
+			foundHwnd = hwnd
+			return 0 // Stop enumeration
+		}
+
+		return 1 // Continue enumeration
+	})
+
+	// Enumerate all windows
+	procEnumWindows.Call(callback, 0)
+
+	if foundHwnd != 0 {
+		logging.Log(debug, "Existing RDP window found:", foundHwnd)
+		return foundHwnd, true
+	}
+
+	logging.Log(debug, "No existing RDP window found")
+	return 0, false
+}
+
+// Activate brings a window to the foreground and restores it if minimized
+func (mstscWindowFinder) Activate(hwnd uintptr) {
+	debug := false
+	logging.Log(debug, "Bringing window to front, HWND:", hwnd)
+
+	// Check if window is minimized
+	isMinimized, _, _ := procIsIconic.Call(hwnd)
+	if isMinimized != 0 {
+		logging.Log(debug, "Window is minimized, restoring...")
+		procShowWindow.Call(hwnd, SW_RESTORE)
+	}
+
+	// Bring window to foreground
+	procSetForegroundWindow.Call(hwnd)
+	logging.Log(debug, "Window brought to front")
+}
@@ -0,0 +1,111 @@
+package storage
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/chrilep/LaunchRDP/app/fileutil"
+	"github.com/chrilep/LaunchRDP/app/logging"
+)
+
+// BackupSuffix is appended to the previous version of a file saved by writeFileAtomic
+const BackupSuffix = ".bak"
+
+// Recovery reports a file that was corrupt and loaded from its backup instead
+type Recovery struct {
+	File  string `json:"file"`
+	Error string `json:"error"`
+}
+
+// Recoveries returns the files this storage loaded from their backup
+func (s *Storage) Recoveries() []Recovery {
+	s.recoveriesMu.Lock()
+	defer s.recoveriesMu.Unlock()
+	return append([]Recovery{}, s.recoveries...)
+}
+
+// addRecovery records a recovery, once per file
+func (s *Storage) addRecovery(recovery Recovery) {
+	s.recoveriesMu.Lock()
+	defer s.recoveriesMu.Unlock()
+	for i := range s.recoveries {
+		if s.recoveries[i].File == recovery.File {
+			s.recoveries[i] = recovery
+			return
+		}
+	}
+	s.recoveries = append(s.recoveries, recovery)
+}
+
+// writeFileAtomic replaces a file atomically (see fileutil.WriteFileAtomic),
+// keeping a valid previous version as <path>.bak first
+func writeFileAtomic(path string, data []byte, perm os.FileMode) error {
+	debug := false
+
+	// Keep the previous version, unless it is corrupt and would replace a good backup
+	if previous, err := os.ReadFile(path); err == nil && json.Valid(previous) {
+		if err := fileutil.WriteFileSynced(path+BackupSuffix, previous, perm); err != nil {
+			return fmt.Errorf("failed to write backup: %w", err)
+		}
+	}
+	if err := fileutil.WriteFileAtomic(path, data, perm); err != nil {
+		return err
+	}
+
+	logging.Log(debug, "Atomically wrote", path, len(data), "bytes")
+	return nil
+}
+
+// readJSON reads and unmarshals a JSON file, upgrading it with the migrations
+// (nil for unversioned files). If the file is corrupt (unreadable or invalid JSON),
+// the backup is loaded instead and the recovery is reported by Recoveries. The
+// corrupt file is kept as <path>.corrupt. Files of a newer schema version are
+// never replaced by the backup.
+// A missing file returns the os.ReadFile error, check it with os.IsNotExist.
+func (s *Storage) readJSON(path string, v any, migrations []migration) error {
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return err
+	}
+	if err == nil {
+		if err = decodeJSON(path, data, v, migrations); err == nil {
+			return nil
+		}
+		if _, tooNew := err.(*SchemaTooNewError); tooNew {
+			logging.Log(true, "ERROR:", err)
+			return err
+		}
+	}
+	primaryErr := err
+	logging.Log(true, "ERROR: Corrupt file", path+":", primaryErr, "- trying backup")
+
+	backup, err := os.ReadFile(path + BackupSuffix)
+	if err != nil {
+		return fmt.Errorf("%s is corrupt (%v) and no backup is available: %v", filepath.Base(path), primaryErr, err)
+	}
+	if err := decodeJSON(path, backup, v, migrations); err != nil {
+		return fmt.Errorf("%s and its backup are corrupt: %v, %w", filepath.Base(path), primaryErr, err)
+	}
+
+	if data != nil {
+		if err := os.WriteFile(path+".corrupt", data, 0600); err != nil {
+			logging.Log(true, "Warning: Failed to keep corrupt file:", err)
+		}
+	}
+	s.addRecovery(Recovery{File: filepath.Base(path), Error: primaryErr.Error()})
+	logging.Log(true, "Recovered", path, "from backup")
+	return nil
+}
+
+// decodeJSON migrates (if migrations are given) and unmarshals file content
+func decodeJSON(path string, data []byte, v any, migrations []migration) error {
+	if migrations != nil {
+		var err error
+		if data, err = migrateFile(path, data, migrations); err != nil {
+			return err
+		}
+	}
+	return json.Unmarshal(data, v)
+}
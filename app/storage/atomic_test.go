@@ -0,0 +1,125 @@
+package storage
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/chrilep/LaunchRDP/app/models"
+)
+
+// saveHostNamed saves a single host
+func saveHostNamed(t *testing.T, s *Storage, name string) {
+	t.Helper()
+	if err := s.SaveHosts([]models.Host{models.NewHost(name, name+".example.com", 3389, "")}); err != nil {
+		t.Fatal(err)
+	}
+}
+
+const corruptJSON = `{"schema_version": 1, "hosts": [{"name": "trunc`
+
+func TestWriteFileAtomicKeepsBackup(t *testing.T) {
+	s := newTestStorage(t)
+	saveHostNamed(t, s, "first")
+	if _, err := os.Stat(s.hostsPath + BackupSuffix); !os.IsNotExist(err) {
+		t.Errorf("backup written without a previous version: %v", err)
+	}
+	first, _ := os.ReadFile(s.hostsPath)
+
+	saveHostNamed(t, s, "second")
+	if backup, err := os.ReadFile(s.hostsPath + BackupSuffix); err != nil || string(backup) != string(first) {
+		t.Errorf("backup = %q, %v, want the first version", backup, err)
+	}
+
+	// Only the file and its backup remain, no temp files
+	entries, _ := os.ReadDir(filepath.Dir(s.hostsPath))
+	for _, entry := range entries {
+		if strings.Contains(entry.Name(), ".tmp-") {
+			t.Errorf("temp file %s left behind", entry.Name())
+		}
+	}
+}
+
+func TestReadJSONRecoversFromBackup(t *testing.T) {
+	s := newTestStorage(t)
+	saveHostNamed(t, s, "first")
+	saveHostNamed(t, s, "second")
+	if err := os.WriteFile(s.hostsPath, []byte(corruptJSON), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	hosts, err := s.LoadHosts()
+	if err != nil {
+		t.Fatalf("LoadHosts() with a valid backup: %v", err)
+	}
+	if names := hostNames(hosts); !slices.Equal(names, []string{"first"}) {
+		t.Errorf("loaded %v, want the backup [first]", names)
+	}
+	if corrupt, err := os.ReadFile(s.hostsPath + ".corrupt"); err != nil || string(corrupt) != corruptJSON {
+		t.Errorf("corrupt copy = %q, %v", corrupt, err)
+	}
+	recoveries := s.Recoveries()
+	if len(recoveries) != 1 || recoveries[0].File != HostsFileName || recoveries[0].Error == "" {
+		t.Errorf("Recoveries() = %+v", recoveries)
+	}
+
+	// A second load reports the file once
+	if _, err := s.LoadHosts(); err != nil {
+		t.Fatal(err)
+	}
+	if len(s.Recoveries()) != 1 {
+		t.Errorf("Recoveries() = %+v, want one entry", s.Recoveries())
+	}
+}
+
+func TestReadJSONWithoutBackup(t *testing.T) {
+	s := newTestStorage(t)
+	if err := os.WriteFile(s.hostsPath, []byte(corruptJSON), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if hosts, err := s.LoadHosts(); err == nil {
+		t.Fatalf("LoadHosts() = %v, want an error", hosts)
+	} else if !strings.Contains(err.Error(), "no backup") {
+		t.Errorf("err = %v", err)
+	}
+	if len(s.Recoveries()) != 0 {
+		t.Errorf("Recoveries() = %+v", s.Recoveries())
+	}
+	// The corrupt file is left as it is for the user
+	if data, _ := os.ReadFile(s.hostsPath); string(data) != corruptJSON {
+		t.Errorf("corrupt file changed to %q", data)
+	}
+
+	// A corrupt backup fails as well
+	if err := os.WriteFile(s.hostsPath+BackupSuffix, []byte("{"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.LoadHosts(); err == nil || !strings.Contains(err.Error(), "backup are corrupt") {
+		t.Errorf("err = %v, want both files corrupt", err)
+	}
+}
+
+func TestWriteFileAtomicKeepsGoodBackup(t *testing.T) {
+	s := newTestStorage(t)
+	saveHostNamed(t, s, "first")
+	saveHostNamed(t, s, "second")
+	good, _ := os.ReadFile(s.hostsPath + BackupSuffix)
+	if err := os.WriteFile(s.hostsPath, []byte(corruptJSON), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	// Saving over the corrupt file must not replace the good backup with it
+	saveHostNamed(t, s, "third")
+	if backup, _ := os.ReadFile(s.hostsPath + BackupSuffix); string(backup) != string(good) {
+		t.Errorf("backup replaced by %q", backup)
+	}
+	hosts, err := s.LoadHosts()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if names := hostNames(hosts); !slices.Equal(names, []string{"third"}) {
+		t.Errorf("loaded %v, want [third]", names)
+	}
+}
@@ -0,0 +1,103 @@
+package storage
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/chrilep/LaunchRDP/app/models"
+)
+
+// LockFileName is locked by the OS while a process modifies the storage files
+const LockFileName = "storage.lock"
+
+// lock serializes modifications: the mutex within this process, the OS file lock
+// across processes (a second instance, a CLI next to the GUI). The returned
+// function releases both.
+func (s *Storage) lock() (func(), error) {
+	s.mu.Lock()
+
+	file, err := os.OpenFile(s.lockPath, os.O_RDWR|os.O_CREATE, 0600)
+	if err != nil {
+		s.mu.Unlock()
+		return nil, fmt.Errorf("failed to open lock file: %w", err)
+	}
+	if err := lockFile(file); err != nil {
+		file.Close()
+		s.mu.Unlock()
+		return nil, fmt.Errorf("failed to lock storage: %w", err)
+	}
+
+	return func() {
+		_ = unlockFile(file)
+		file.Close()
+		s.mu.Unlock()
+	}, nil
+}
+
+// UpdateHosts loads the hosts, passes them to fn and saves the result, all under
+// the storage lock so concurrent updates are not lost. Nothing is saved if fn
+// returns an error, which is then returned unchanged.
+func (s *Storage) UpdateHosts(fn func([]models.Host) ([]models.Host, error)) error {
+	unlock, err := s.lock()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	hosts, err := s.LoadHosts()
+	if err != nil {
+		return err
+	}
+	hosts, err = fn(hosts)
+	if err != nil {
+		return err
+	}
+	return s.saveHosts(hosts)
+}
+
+// UpdateUsers is UpdateHosts for users
+func (s *Storage) UpdateUsers(fn func([]models.User) ([]models.User, error)) error {
+	unlock, err := s.lock()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	users, err := s.LoadUsers()
+	if err != nil {
+		return err
+	}
+	users, err = fn(users)
+	if err != nil {
+		return err
+	}
+	return s.saveUsers(users)
+}
+
+// UpdateUsersAndHosts updates users and hosts in one locked step (e.g. an import
+// that creates users for new hosts). Users are saved first, so hosts never refer
+// to users that were not saved.
+func (s *Storage) UpdateUsersAndHosts(fn func([]models.User, []models.Host) ([]models.User, []models.Host, error)) error {
+	unlock, err := s.lock()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	users, err := s.LoadUsers()
+	if err != nil {
+		return err
+	}
+	hosts, err := s.LoadHosts()
+	if err != nil {
+		return err
+	}
+	users, hosts, err = fn(users, hosts)
+	if err != nil {
+		return err
+	}
+	if err := s.saveUsers(users); err != nil {
+		return err
+	}
+	return s.saveHosts(hosts)
+}
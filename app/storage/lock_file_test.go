@@ -0,0 +1,13 @@
+//go:build unix || windows
+
+package storage
+
+import "testing"
+
+// TestUpdateHostsAcrossInstances checks the file lock: storages on the same
+// files (like two processes) share no mutex
+func TestUpdateHostsAcrossInstances(t *testing.T) {
+	s := newTestStorage(t)
+	appendHosts(t, []*Storage{s, NewStorage(), NewStorage()}, 20)
+	checkHostCount(t, s, 60)
+}
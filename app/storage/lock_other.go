@@ -0,0 +1,16 @@
+//go:build !unix && !windows
+
+package storage
+
+import "os"
+
+// lockFile is a no-op on platforms without file locking; the in-process mutex
+// still serializes updates
+func lockFile(file *os.File) error {
+	return nil
+}
+
+// unlockFile is a no-op, see lockFile
+func unlockFile(file *os.File) error {
+	return nil
+}
@@ -0,0 +1,97 @@
+package storage
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+	"testing"
+
+	"github.com/chrilep/LaunchRDP/app/models"
+)
+
+// appendHosts appends n hosts per storage concurrently, each in its own UpdateHosts
+func appendHosts(t *testing.T, storages []*Storage, n int) {
+	t.Helper()
+	var wg sync.WaitGroup
+	errs := make(chan error, len(storages)*n)
+	for i, s := range storages {
+		for j := 0; j < n; j++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				name := fmt.Sprintf("host-%d-%d", i, j)
+				errs <- s.UpdateHosts(func(hosts []models.Host) ([]models.Host, error) {
+					return append(hosts, models.NewHost(name, name, 3389, "")), nil
+				})
+			}()
+		}
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+// checkHostCount checks that all n hosts were saved, each once
+func checkHostCount(t *testing.T, s *Storage, n int) {
+	t.Helper()
+	hosts, err := s.LoadHosts()
+	if err != nil {
+		t.Fatal(err)
+	}
+	seen := make(map[string]bool)
+	for _, host := range hosts {
+		if seen[host.Name] {
+			t.Errorf("%s saved twice", host.Name)
+		}
+		seen[host.Name] = true
+	}
+	if len(seen) != n {
+		t.Errorf("%d hosts saved, want %d", len(seen), n)
+	}
+}
+
+func TestUpdateHostsConcurrent(t *testing.T) {
+	s := newTestStorage(t)
+	appendHosts(t, []*Storage{s}, 50)
+	checkHostCount(t, s, 50)
+}
+
+func TestUpdateHostsCallbackError(t *testing.T) {
+	s := newTestStorage(t)
+	saveHostNamed(t, s, "first")
+	saveHostNamed(t, s, "second")
+	hostsBefore, _ := os.ReadFile(s.hostsPath)
+	backupBefore, _ := os.ReadFile(s.hostsPath + BackupSuffix)
+	failure := errors.New("name taken")
+
+	err := s.UpdateHosts(func(hosts []models.Host) ([]models.Host, error) {
+		return append(hosts, models.NewHost("third", "third", 3389, "")), failure
+	})
+	if err != failure {
+		t.Errorf("err = %v, want the callback error unchanged", err)
+	}
+	err = s.UpdateUsersAndHosts(func(users []models.User, hosts []models.Host) ([]models.User, []models.Host, error) {
+		return append(users, models.NewUser("alice", "alice")), nil, failure
+	})
+	if err != failure {
+		t.Errorf("err = %v, want the callback error unchanged", err)
+	}
+
+	if data, _ := os.ReadFile(s.hostsPath); string(data) != string(hostsBefore) {
+		t.Error("hosts file changed")
+	}
+	if data, _ := os.ReadFile(s.hostsPath + BackupSuffix); string(data) != string(backupBefore) {
+		t.Error("hosts backup changed")
+	}
+	if _, err := os.Stat(s.usersPath); !os.IsNotExist(err) {
+		t.Errorf("users file written: %v", err)
+	}
+
+	// The lock was released
+	saveHostNamed(t, s, "third")
+}
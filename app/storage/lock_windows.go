@@ -0,0 +1,50 @@
+//go:build windows
+
+package storage
+
+import (
+	"os"
+	"syscall"
+	"unsafe"
+)
+
+var (
+	kernel32         = syscall.NewLazyDLL("kernel32.dll")
+	procLockFileEx   = kernel32.NewProc("LockFileEx")
+	procUnlockFileEx = kernel32.NewProc("UnlockFileEx")
+)
+
+const LOCKFILE_EXCLUSIVE_LOCK = 0x00000002
+
+// lockFile blocks until the exclusive lock on the first byte of the file is acquired
+func lockFile(file *os.File) error {
+	var overlapped syscall.Overlapped
+	ret, _, err := procLockFileEx.Call(
+		file.Fd(),
+		uintptr(LOCKFILE_EXCLUSIVE_LOCK),
+		0, // reserved
+		1, // bytes to lock (low)
+		0, // bytes to lock (high)
+		uintptr(unsafe.Pointer(&overlapped)),
+	)
+	if ret == 0 {
+		return err
+	}
+	return nil
+}
+
+// unlockFile releases the lock taken by lockFile
+func unlockFile(file *os.File) error {
+	var overlapped syscall.Overlapped
+	ret, _, err := procUnlockFileEx.Call(
+		file.Fd(),
+		0, // reserved
+		1, // bytes to unlock (low)
+		0, // bytes to unlock (high)
+		uintptr(unsafe.Pointer(&overlapped)),
+	)
+	if ret == 0 {
+		return err
+	}
+	return nil
+}
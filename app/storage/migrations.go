@@ -0,0 +1,239 @@
+package storage
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/chrilep/LaunchRDP/app/fileutil"
+	"github.com/chrilep/LaunchRDP/app/logging"
+	"github.com/chrilep/LaunchRDP/app/models"
+)
+
+// Schema versions written by this version of LaunchRDP ("schema_version" field).
+// Files without the field are version 0.
+const (
+	HostsSchemaVersion = 1
+	UsersSchemaVersion = 1
+)
+
+// migration upgrades a document from version-1 to version. Documents are
+// migrated as raw JSON objects, so migrations keep working when the models change.
+type migration struct {
+	version     int
+	description string
+	migrate     func(doc map[string]any) error
+}
+
+// hostsMigrations and usersMigrations must be sorted by version without gaps,
+// the last version is the current schema version
+var hostsMigrations = []migration{
+	{1, "fill window size, display mode and port of legacy hosts", migrateHostsV1},
+}
+
+var usersMigrations = []migration{
+	{1, "split login and domain from the username of legacy users", migrateUsersV1},
+}
+
+// SchemaTooNewError is returned for files written by a newer LaunchRDP. They are
+// not loaded, because saving them would drop the fields this version doesn't know.
+type SchemaTooNewError struct {
+	File    string
+	Version int
+	Latest  int
+}
+
+func (e *SchemaTooNewError) Error() string {
+	return fmt.Sprintf("%s has schema version %d, this LaunchRDP supports up to %d - please update LaunchRDP", e.File, e.Version, e.Latest)
+}
+
+// migrateDocument upgrades a JSON document step by step to the latest version of
+// the migrations. It returns the (possibly unchanged) document and the version it
+// had before.
+func migrateDocument(data []byte, migrations []migration) ([]byte, int, error) {
+	var doc map[string]any
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber() // keep numbers exactly as written
+	if err := decoder.Decode(&doc); err != nil {
+		return nil, 0, err
+	}
+
+	version, err := intField(doc, "schema_version")
+	if err != nil {
+		return nil, 0, err
+	}
+	latest := 0
+	if len(migrations) > 0 {
+		latest = migrations[len(migrations)-1].version
+	}
+	if version > latest {
+		return nil, version, &SchemaTooNewError{Version: version, Latest: latest}
+	}
+	if version == latest {
+		return data, version, nil
+	}
+
+	for _, m := range migrations {
+		if m.version <= version {
+			continue
+		}
+		if err := m.migrate(doc); err != nil {
+			return nil, version, fmt.Errorf("migration to schema version %d (%s) failed: %w", m.version, m.description, err)
+		}
+		doc["schema_version"] = m.version
+	}
+
+	migrated, err := json.Marshal(doc)
+	if err != nil {
+		return nil, version, fmt.Errorf("failed to marshal migrated document: %w", err)
+	}
+	return migrated, version, nil
+}
+
+// migrateFile migrates the content of a storage file. Before the first migration
+// of a version the original is kept as <path>.v<version>.bak; the migrated
+// document is written with the next save.
+func migrateFile(path string, data []byte, migrations []migration) ([]byte, error) {
+	debug := false
+	migrated, version, err := migrateDocument(data, migrations)
+	if tooNew, ok := err.(*SchemaTooNewError); ok {
+		tooNew.File = path
+		return nil, tooNew
+	}
+	if err != nil {
+		return nil, err
+	}
+	if bytes.Equal(migrated, data) {
+		return data, nil
+	}
+
+	backupPath := fmt.Sprintf("%s.v%d%s", path, version, BackupSuffix)
+	if _, err := os.Stat(backupPath); os.IsNotExist(err) {
+		if err := fileutil.WriteFileSynced(backupPath, data, 0644); err != nil {
+			return nil, fmt.Errorf("failed to back up %s before migration: %w", path, err)
+		}
+		logging.Log(true, "Migrating", path, "from schema version", version, "- original kept as", backupPath)
+	}
+	logging.Log(debug, "Migrated", path, "from schema version", version)
+	return migrated, nil
+}
+
+// migrateHostsV1 fills fields that old versions left empty and the generator
+// used to patch on every launch
+func migrateHostsV1(doc map[string]any) error {
+	return forEachObject(doc, "hosts", func(host map[string]any) error {
+		windowWidth, err := intField(host, "window_width")
+		if err != nil {
+			return err
+		}
+		windowHeight, err := intField(host, "window_height")
+		if err != nil {
+			return err
+		}
+		if windowWidth == 0 || windowHeight == 0 {
+			desktopWidth, err := intField(host, "desktop_width")
+			if err != nil {
+				return err
+			}
+			desktopHeight, err := intField(host, "desktop_height")
+			if err != nil {
+				return err
+			}
+			// Estimate the window from the desktop size, like models.Host.EffectiveWindowSize
+			legacy := models.Host{DesktopWidth: desktopWidth, DesktopHeight: desktopHeight}
+			windowWidth, windowHeight = legacy.EffectiveWindowSize()
+			host["window_width"] = windowWidth
+			host["window_height"] = windowHeight
+		}
+
+		screenMode, err := intField(host, "screen_mode")
+		if err != nil {
+			return err
+		}
+		displayMode, _ := host["display_mode"].(string)
+		if displayMode == "" {
+			displayMode = "window"
+			if screenMode == 2 {
+				displayMode = "fullscreen"
+			}
+			host["display_mode"] = displayMode
+		}
+		if screenMode == 0 {
+			screenMode = 1
+			if strings.EqualFold(displayMode, "fullscreen") {
+				screenMode = 2
+			}
+			host["screen_mode"] = screenMode
+		}
+
+		if port, err := intField(host, "port"); err != nil {
+			return err
+		} else if port == 0 {
+			host["port"] = 3389
+		}
+		return nil
+	})
+}
+
+// migrateUsersV1 sets login and domain of users that only have a "DOMAIN\user"
+// or "user" username
+func migrateUsersV1(doc map[string]any) error {
+	return forEachObject(doc, "users", func(user map[string]any) error {
+		login, _ := user["login"].(string)
+		if login != "" {
+			return nil
+		}
+		username, _ := user["username"].(string)
+		if domain, name, found := strings.Cut(username, `\`); found {
+			user["login"] = name
+			if current, _ := user["domain"].(string); current == "" {
+				user["domain"] = domain
+			}
+		} else {
+			user["login"] = username
+		}
+		return nil
+	})
+}
+
+// forEachObject calls fn for every object of the array doc[key]
+func forEachObject(doc map[string]any, key string, fn func(map[string]any) error) error {
+	items, ok := doc[key].([]any)
+	if !ok {
+		if doc[key] == nil {
+			return nil
+		}
+		return fmt.Errorf("%q is not an array", key)
+	}
+	for i, item := range items {
+		object, ok := item.(map[string]any)
+		if !ok {
+			return fmt.Errorf("%s[%d] is not an object", key, i)
+		}
+		if err := fn(object); err != nil {
+			return fmt.Errorf("%s[%d]: %w", key, i, err)
+		}
+	}
+	return nil
+}
+
+// intField reads an integer field of a document decoded with UseNumber, 0 if missing
+func intField(object map[string]any, key string) (int, error) {
+	switch value := object[key].(type) {
+	case nil:
+		return 0, nil
+	case json.Number:
+		n, err := strconv.Atoi(value.String())
+		if err != nil {
+			return 0, fmt.Errorf("%q is not an integer: %s", key, value)
+		}
+		return n, nil
+	case int:
+		return value, nil
+	default:
+		return 0, fmt.Errorf("%q is not a number", key)
+	}
+}
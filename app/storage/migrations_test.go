@@ -0,0 +1,201 @@
+package storage
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"testing"
+
+	"github.com/chrilep/LaunchRDP/app/models"
+)
+
+const hostsV0 = `{
+  "hosts": [
+    {"id": "h1", "name": "Legacy", "address": "old.example.com", "desktop_width": 1024, "desktop_height": 768},
+    {"id": "h2", "name": "Full", "address": "full.example.com", "port": 3390, "screen_mode": 2,
+     "window_width": 1280, "window_height": 800, "gateway_hostname": "gw.example.com"},
+    {"id": "h3", "name": "Never", "address": "direct.example.com", "gateway_usage_method": 0}
+  ]
+}`
+
+const usersV0 = `{
+  "users": [
+    {"id": "u1", "name": "Alice", "username": "CORP\\alice"},
+    {"id": "u2", "name": "Bob", "username": "bob"},
+    {"id": "u3", "name": "Carol", "username": "OLD\\carol", "login": "carol", "domain": "NEW"}
+  ]
+}`
+
+func writeFile(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// fileSchemaVersion reads the schema version of a storage file
+func fileSchemaVersion(path string) (int, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return 0, err
+	}
+	var doc struct {
+		SchemaVersion int `json:"schema_version"`
+	}
+	err = json.Unmarshal(data, &doc)
+	return doc.SchemaVersion, err
+}
+
+func TestMigrateHostsV0(t *testing.T) {
+	s := newTestStorage(t)
+	writeFile(t, s.hostsPath, hostsV0)
+
+	hosts, err := s.LoadHosts()
+	if err != nil {
+		t.Fatal(err)
+	}
+	byID := make(map[string]int)
+	for i, host := range hosts {
+		byID[host.ID] = i
+	}
+	legacy, full, never := hosts[byID["h1"]], hosts[byID["h2"]], hosts[byID["h3"]]
+
+	// The window is estimated from the desktop like Host.EffectiveWindowSize
+	if legacy.WindowWidth != 1040 || legacy.WindowHeight != 827 {
+		t.Errorf("legacy window = %dx%d, want 1040x827", legacy.WindowWidth, legacy.WindowHeight)
+	}
+	if legacy.Port != 3389 || legacy.ScreenMode != 1 || legacy.DisplayMode != "window" {
+		t.Errorf("legacy port %d, screen mode %d, display mode %q", legacy.Port, legacy.ScreenMode, legacy.DisplayMode)
+	}
+	// Set values are kept
+	if full.WindowWidth != 1280 || full.WindowHeight != 800 || full.Port != 3390 || full.DisplayMode != "fullscreen" {
+		t.Errorf("full = %dx%d port %d %q", full.WindowWidth, full.WindowHeight, full.Port, full.DisplayMode)
+	}
+	// 0 means "never", also for hosts with a gateway
+	if full.GatewayUsageMethod != 0 || never.GatewayUsageMethod != 0 {
+		t.Errorf("gateway usage methods %d, %d, want 0, 0", full.GatewayUsageMethod, never.GatewayUsageMethod)
+	}
+
+	// The original is kept, the migrated document is only written with the next save
+	if backup, err := os.ReadFile(s.hostsPath + ".v0" + BackupSuffix); err != nil || string(backup) != hostsV0 {
+		t.Errorf("v0 backup = %q, %v", backup, err)
+	}
+	if data, _ := os.ReadFile(s.hostsPath); string(data) != hostsV0 {
+		t.Error("hosts file rewritten by loading")
+	}
+	if err := s.SaveHosts(hosts); err != nil {
+		t.Fatal(err)
+	}
+	version, err := fileSchemaVersion(s.hostsPath)
+	if err != nil || version != HostsSchemaVersion {
+		t.Errorf("saved schema version = %d, %v, want %d", version, err, HostsSchemaVersion)
+	}
+}
+
+func TestMigrateUsersV0(t *testing.T) {
+	s := newTestStorage(t)
+	writeFile(t, s.usersPath, usersV0)
+
+	users, err := s.LoadUsers()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string][2]string{
+		"u1": {"alice", "CORP"},
+		"u2": {"bob", ""},
+		// Users with a login are left alone
+		"u3": {"carol", "NEW"},
+	}
+	if len(users) != len(want) {
+		t.Fatalf("%d users, want %d", len(users), len(want))
+	}
+	for _, user := range users {
+		if got := [2]string{user.Login, user.Domain}; got != want[user.ID] {
+			t.Errorf("%s: login, domain = %q, want %q", user.ID, got, want[user.ID])
+		}
+	}
+	if backup, err := os.ReadFile(s.usersPath + ".v0" + BackupSuffix); err != nil || string(backup) != usersV0 {
+		t.Errorf("v0 backup = %q, %v", backup, err)
+	}
+}
+
+func TestMigrationBackupNotOverwritten(t *testing.T) {
+	s := newTestStorage(t)
+	writeFile(t, s.hostsPath, hostsV0)
+	if _, err := s.LoadHosts(); err != nil {
+		t.Fatal(err)
+	}
+	// Another v0 file (e.g. restored by the user) keeps the first original
+	writeFile(t, s.hostsPath, `{"hosts": []}`)
+	if _, err := s.LoadHosts(); err != nil {
+		t.Fatal(err)
+	}
+	if backup, _ := os.ReadFile(s.hostsPath + ".v0" + BackupSuffix); string(backup) != hostsV0 {
+		t.Errorf("v0 backup replaced by %q", backup)
+	}
+}
+
+func TestMigrateCurrentVersionUnchanged(t *testing.T) {
+	s := newTestStorage(t)
+	writeFile(t, s.hostsPath, hostsV0)
+	hosts, err := s.LoadHosts()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := s.SaveHosts(hosts); err != nil {
+		t.Fatal(err)
+	}
+	saved, _ := os.ReadFile(s.hostsPath)
+	// Saving sorts the hosts
+	if hosts, err = s.LoadHosts(); err != nil {
+		t.Fatal(err)
+	}
+
+	// A document of the current version is passed through byte for byte
+	migrated, err := migrateFile(s.hostsPath, saved, hostsMigrations)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(migrated) != string(saved) {
+		t.Errorf("current document changed:\n%s", migrated)
+	}
+	again, err := s.LoadHosts()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fmt.Sprint(again) != fmt.Sprint(hosts) {
+		t.Errorf("hosts changed by a second load:\n%+v\n%+v", again, hosts)
+	}
+	if _, err := os.Stat(s.hostsPath + fmt.Sprintf(".v%d", HostsSchemaVersion) + BackupSuffix); !os.IsNotExist(err) {
+		t.Errorf("backup written for a current document: %v", err)
+	}
+}
+
+func TestSchemaTooNew(t *testing.T) {
+	s := newTestStorage(t)
+	saveHostNamed(t, s, "first")
+	saveHostNamed(t, s, "second")
+	future := fmt.Sprintf(`{"schema_version": %d, "hosts": [], "new_field": true}`, HostsSchemaVersion+1)
+	writeFile(t, s.hostsPath, future)
+
+	hosts, err := s.LoadHosts()
+	var tooNew *SchemaTooNewError
+	if !errors.As(err, &tooNew) {
+		t.Fatalf("LoadHosts() = %v, %v, want a SchemaTooNewError", hosts, err)
+	}
+	if tooNew.Version != HostsSchemaVersion+1 || tooNew.Latest != HostsSchemaVersion || tooNew.File != s.hostsPath {
+		t.Errorf("error = %+v", tooNew)
+	}
+	// Neither loaded from the backup nor touched
+	if len(s.Recoveries()) != 0 {
+		t.Errorf("Recoveries() = %+v", s.Recoveries())
+	}
+	if data, _ := os.ReadFile(s.hostsPath); string(data) != future {
+		t.Errorf("file changed to %q", data)
+	}
+	// Updates fail instead of dropping the unknown fields
+	if err := s.UpdateHosts(func(hosts []models.Host) ([]models.Host, error) { return hosts, nil }); !errors.As(err, &tooNew) {
+		t.Errorf("UpdateHosts() = %v", err)
+	}
+}
@@ -0,0 +1,164 @@
+package storage
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/chrilep/LaunchRDP/app/config"
+	"github.com/chrilep/LaunchRDP/app/logging"
+	"github.com/chrilep/LaunchRDP/app/models"
+)
+
+// Repository backends
+const (
+	BackendJSON   = "json"   // one JSON file per collection (hosts.json, users.json, ...)
+	BackendSQLite = "sqlite" // embedded SQLite database (launchrdp.db), for large host lists
+)
+
+// MaxHistoryEntries is the number of launches a repository keeps
+const MaxHistoryEntries = 1000
+
+// Repository stores hosts, users, groups and the launch history.
+// Update* functions run load, modify and save as one step that concurrent
+// updates (other goroutines or processes) cannot interleave with.
+//
+// The callbacks of Update* must not save to the repository: Save* and Update*
+// wait for the running update and never return. Load* inside a callback
+// returns the state before the update.
+type Repository interface {
+	LoadUsers() ([]models.User, error)
+	SaveUsers(users []models.User) error
+	UpdateUsers(fn func([]models.User) ([]models.User, error)) error
+
+	LoadHosts() ([]models.Host, error)
+	SaveHosts(hosts []models.Host) error
+	UpdateHosts(fn func([]models.Host) ([]models.Host, error)) error
+
+	UpdateUsersAndHosts(fn func([]models.User, []models.Host) ([]models.User, []models.Host, error)) error
+
+	LoadGroups() ([]models.Group, error)
+	UpdateGroups(fn func([]models.Group) ([]models.Group, error)) error
+
+	// LoadHistory returns the latest launches, newest first (limit <= 0 = all)
+	LoadHistory(limit int) ([]models.HistoryEntry, error)
+	// AddHistory records a launch, dropping the oldest entries beyond MaxHistoryEntries
+	AddHistory(entry models.HistoryEntry) error
+	// ReplaceHistory replaces all entries (newest first, like LoadHistory),
+	// keeping the latest MaxHistoryEntries
+	ReplaceHistory(entries []models.HistoryEntry) error
+
+	// Backend returns BackendJSON or BackendSQLite
+	Backend() string
+	Close() error
+}
+
+var (
+	_ Repository = (*Storage)(nil)
+	_ Repository = (*SQLiteRepository)(nil)
+)
+
+// latestHistory returns the newest MaxHistoryEntries of entries (newest first)
+func latestHistory(entries []models.HistoryEntry) []models.HistoryEntry {
+	if len(entries) > MaxHistoryEntries {
+		return entries[:MaxHistoryEntries]
+	}
+	return entries
+}
+
+// OpenRepository opens the repository in use: the SQLite database if it exists,
+// the JSON files otherwise. jsonStorage is returned as is for the JSON backend.
+func OpenRepository(jsonStorage *Storage) (Repository, error) {
+	path := config.GetConfigPath(DatabaseFileName)
+	if _, err := os.Stat(path); err == nil {
+		return OpenSQLite(path)
+	}
+	return jsonStorage, nil
+}
+
+// ReadOnlyRepository serves the loads of a repository and rejects every change
+// with Err. The app falls back to it when the database cannot be opened: the
+// JSON files may be outdated then, and changes to them would be lost once the
+// database opens again.
+type ReadOnlyRepository struct {
+	Repository
+	Err error
+}
+
+var _ Repository = (*ReadOnlyRepository)(nil)
+
+func (r *ReadOnlyRepository) readOnly() error {
+	return fmt.Errorf("storage is read-only: %w", r.Err)
+}
+
+// SaveUsers returns the read-only error
+func (r *ReadOnlyRepository) SaveUsers([]models.User) error { return r.readOnly() }
+
+// UpdateUsers returns the read-only error
+func (r *ReadOnlyRepository) UpdateUsers(func([]models.User) ([]models.User, error)) error {
+	return r.readOnly()
+}
+
+// SaveHosts returns the read-only error
+func (r *ReadOnlyRepository) SaveHosts([]models.Host) error { return r.readOnly() }
+
+// UpdateHosts returns the read-only error
+func (r *ReadOnlyRepository) UpdateHosts(func([]models.Host) ([]models.Host, error)) error {
+	return r.readOnly()
+}
+
+// UpdateUsersAndHosts returns the read-only error
+func (r *ReadOnlyRepository) UpdateUsersAndHosts(func([]models.User, []models.Host) ([]models.User, []models.Host, error)) error {
+	return r.readOnly()
+}
+
+// UpdateGroups returns the read-only error
+func (r *ReadOnlyRepository) UpdateGroups(func([]models.Group) ([]models.Group, error)) error {
+	return r.readOnly()
+}
+
+// AddHistory returns the read-only error
+func (r *ReadOnlyRepository) AddHistory(models.HistoryEntry) error { return r.readOnly() }
+
+// ReplaceHistory returns the read-only error
+func (r *ReadOnlyRepository) ReplaceHistory([]models.HistoryEntry) error { return r.readOnly() }
+
+// CopyRepository copies all users, hosts, groups and history entries from src to
+// dst, replacing the content of dst. It is the one-shot migration between backends.
+func CopyRepository(dst, src Repository) error {
+	debug := false
+
+	users, err := src.LoadUsers()
+	if err != nil {
+		return fmt.Errorf("failed to load users: %w", err)
+	}
+	hosts, err := src.LoadHosts()
+	if err != nil {
+		return fmt.Errorf("failed to load hosts: %w", err)
+	}
+	groups, err := src.LoadGroups()
+	if err != nil {
+		return fmt.Errorf("failed to load groups: %w", err)
+	}
+	history, err := src.LoadHistory(0)
+	if err != nil {
+		return fmt.Errorf("failed to load history: %w", err)
+	}
+
+	if err := dst.UpdateUsersAndHosts(func([]models.User, []models.Host) ([]models.User, []models.Host, error) {
+		return users, hosts, nil
+	}); err != nil {
+		return fmt.Errorf("failed to save users and hosts: %w", err)
+	}
+	if err := dst.UpdateGroups(func([]models.Group) ([]models.Group, error) {
+		return groups, nil
+	}); err != nil {
+		return fmt.Errorf("failed to save groups: %w", err)
+	}
+	if err := dst.ReplaceHistory(history); err != nil {
+		return fmt.Errorf("failed to save history: %w", err)
+	}
+
+	logging.Log(debug, "Copied", len(users), "users,", len(hosts), "hosts,", len(groups), "groups and", len(history),
+		"history entries from", src.Backend(), "to", dst.Backend())
+	return nil
+}
@@ -0,0 +1,377 @@
+package storage
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+
+	_ "modernc.org/sqlite" // pure Go driver, no cgo needed
+
+	"github.com/chrilep/LaunchRDP/app/logging"
+	"github.com/chrilep/LaunchRDP/app/models"
+)
+
+// DatabaseFileName is the database of the SQLite backend. Its presence selects
+// the backend, see OpenRepository.
+const DatabaseFileName = "launchrdp.db"
+
+// sqliteSchemaVersion is kept in PRAGMA user_version
+const sqliteSchemaVersion = 1
+
+// Every row keeps the complete model as JSON in "data", so new model fields need
+// no schema change. The other columns are only used for lookups and ordering.
+var sqliteSchema = []string{
+	`CREATE TABLE IF NOT EXISTS users (id TEXT PRIMARY KEY, username TEXT NOT NULL, data TEXT NOT NULL)`,
+	`CREATE TABLE IF NOT EXISTS hosts (id TEXT PRIMARY KEY, name TEXT NOT NULL, user_id TEXT NOT NULL, group_id TEXT NOT NULL, data TEXT NOT NULL)`,
+	`CREATE INDEX IF NOT EXISTS hosts_user_id ON hosts (user_id)`,
+	`CREATE TABLE IF NOT EXISTS host_groups (id TEXT PRIMARY KEY, name TEXT NOT NULL, data TEXT NOT NULL)`,
+	`CREATE TABLE IF NOT EXISTS history (seq INTEGER PRIMARY KEY AUTOINCREMENT, host_id TEXT NOT NULL, data TEXT NOT NULL)`,
+}
+
+// SQLiteRepository is the Repository in an embedded SQLite database. Saves only
+// write the rows that changed, which keeps large host lists fast.
+type SQLiteRepository struct {
+	db   *sql.DB
+	path string
+	mu   sync.Mutex // serializes updates within this process
+}
+
+// OpenSQLite opens (and creates) the database at path
+func OpenSQLite(path string) (*SQLiteRepository, error) {
+	debug := false
+	// Immediate transactions take the write lock up front, so updates of other
+	// processes wait (busy timeout) instead of failing on commit
+	dsn := path + "?_pragma=busy_timeout(5000)&_pragma=journal_mode(WAL)&_pragma=foreign_keys(1)&_txlock=immediate"
+	db, err := sql.Open("sqlite", dsn)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open database: %w", err)
+	}
+	// Writes are serialized by the mutex of the repository and the immediate
+	// transactions. Loads get connections of their own, so a Load* inside an
+	// Update* callback reads the committed state instead of waiting for the
+	// connection of the transaction.
+	db.SetMaxOpenConns(4)
+
+	var version int
+	if err := db.QueryRow(`PRAGMA user_version`).Scan(&version); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to read database version: %w", err)
+	}
+	if version > sqliteSchemaVersion {
+		db.Close()
+		return nil, &SchemaTooNewError{File: path, Version: version, Latest: sqliteSchemaVersion}
+	}
+	for _, statement := range sqliteSchema {
+		if _, err := db.Exec(statement); err != nil {
+			db.Close()
+			return nil, fmt.Errorf("failed to create database schema: %w", err)
+		}
+	}
+	if _, err := db.Exec(fmt.Sprintf(`PRAGMA user_version = %d`, sqliteSchemaVersion)); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to set database version: %w", err)
+	}
+
+	logging.Log(debug, "Opened SQLite repository", path)
+	return &SQLiteRepository{db: db, path: path}, nil
+}
+
+// Backend returns BackendSQLite
+func (r *SQLiteRepository) Backend() string {
+	return BackendSQLite
+}
+
+// Path returns the database file
+func (r *SQLiteRepository) Path() string {
+	return r.path
+}
+
+// Close closes the database
+func (r *SQLiteRepository) Close() error {
+	return r.db.Close()
+}
+
+// querier is implemented by *sql.DB and *sql.Tx
+type querier interface {
+	Query(query string, args ...any) (*sql.Rows, error)
+}
+
+// loadRows unmarshals the "data" column of every row of a query
+func loadRows[T any](q querier, query string, args ...any) ([]T, error) {
+	rows, err := q.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	items := []T{}
+	for rows.Next() {
+		var data string
+		if err := rows.Scan(&data); err != nil {
+			return nil, err
+		}
+		var item T
+		if err := json.Unmarshal([]byte(data), &item); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal row: %w", err)
+		}
+		items = append(items, item)
+	}
+	return items, rows.Err()
+}
+
+// sqliteRow is a row to write: the id, the lookup columns and the JSON data
+type sqliteRow struct {
+	id      string
+	columns []any
+	data    []byte
+}
+
+// syncRows makes a table contain exactly the given rows. The comparison runs in
+// SQLite: rows whose data is unchanged are not rewritten and rows that are not in
+// the list are deleted, without reading the table back.
+func syncRows(tx *sql.Tx, table string, columns []string, rows []sqliteRow) error {
+	placeholders := strings.TrimSuffix(strings.Repeat("?, ", len(columns)+2), ", ")
+	assignments := make([]string, 0, len(columns)+1)
+	for _, column := range columns {
+		assignments = append(assignments, column+" = excluded."+column)
+	}
+	assignments = append(assignments, "data = excluded.data")
+	upsert, err := tx.Prepare(fmt.Sprintf(`INSERT INTO %s (id, %s, data) VALUES (%s)
+		ON CONFLICT (id) DO UPDATE SET %s WHERE data IS NOT excluded.data`,
+		table, strings.Join(columns, ", "), placeholders, strings.Join(assignments, ", ")))
+	if err != nil {
+		return err
+	}
+	defer upsert.Close()
+
+	ids := make([]string, 0, len(rows))
+	for _, row := range rows {
+		args := append(append([]any{row.id}, row.columns...), string(row.data))
+		if _, err := upsert.Exec(args...); err != nil {
+			return fmt.Errorf("failed to write %s row %s: %w", table, row.id, err)
+		}
+		ids = append(ids, row.id)
+	}
+
+	idList, err := json.Marshal(ids)
+	if err != nil {
+		return err
+	}
+	if _, err := tx.Exec(`DELETE FROM `+table+` WHERE id NOT IN (SELECT value FROM json_each(?))`, string(idList)); err != nil {
+		return fmt.Errorf("failed to delete %s rows: %w", table, err)
+	}
+	return nil
+}
+
+// update runs fn in a write transaction, serialized within the process by the
+// mutex and across processes by the immediate transaction
+func (r *SQLiteRepository) update(fn func(tx *sql.Tx) error) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	tx, err := r.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	if err := fn(tx); err != nil {
+		tx.Rollback()
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return nil
+}
+
+const (
+	selectUsers  = `SELECT data FROM users ORDER BY username COLLATE NOCASE`
+	selectHosts  = `SELECT data FROM hosts ORDER BY name COLLATE NOCASE`
+	selectGroups = `SELECT data FROM host_groups ORDER BY name COLLATE NOCASE`
+)
+
+// LoadUsers loads all users, sorted alphabetically by username
+func (r *SQLiteRepository) LoadUsers() ([]models.User, error) {
+	users, err := loadRows[models.User](r.db, selectUsers)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load users: %w", err)
+	}
+	return users, nil
+}
+
+// SaveUsers replaces all users
+func (r *SQLiteRepository) SaveUsers(users []models.User) error {
+	return r.update(func(tx *sql.Tx) error {
+		return saveSQLiteUsers(tx, users)
+	})
+}
+
+// UpdateUsers loads the users, passes them to fn and saves the result in one transaction
+func (r *SQLiteRepository) UpdateUsers(fn func([]models.User) ([]models.User, error)) error {
+	return r.update(func(tx *sql.Tx) error {
+		users, err := loadRows[models.User](tx, selectUsers)
+		if err != nil {
+			return fmt.Errorf("failed to load users: %w", err)
+		}
+		if users, err = fn(users); err != nil {
+			return err
+		}
+		return saveSQLiteUsers(tx, users)
+	})
+}
+
+func saveSQLiteUsers(tx *sql.Tx, users []models.User) error {
+	rows := make([]sqliteRow, 0, len(users))
+	for _, user := range users {
+		data, err := json.Marshal(user)
+		if err != nil {
+			return fmt.Errorf("failed to marshal user: %w", err)
+		}
+		rows = append(rows, sqliteRow{id: user.ID, columns: []any{user.Username}, data: data})
+	}
+	return syncRows(tx, "users", []string{"username"}, rows)
+}
+
+// LoadHosts loads all hosts, sorted alphabetically by name
+func (r *SQLiteRepository) LoadHosts() ([]models.Host, error) {
+	hosts, err := loadRows[models.Host](r.db, selectHosts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load hosts: %w", err)
+	}
+	return hosts, nil
+}
+
+// SaveHosts replaces all hosts, only changed rows are written
+func (r *SQLiteRepository) SaveHosts(hosts []models.Host) error {
+	return r.update(func(tx *sql.Tx) error {
+		return saveSQLiteHosts(tx, hosts)
+	})
+}
+
+// UpdateHosts loads the hosts, passes them to fn and saves the result in one transaction
+func (r *SQLiteRepository) UpdateHosts(fn func([]models.Host) ([]models.Host, error)) error {
+	return r.update(func(tx *sql.Tx) error {
+		hosts, err := loadRows[models.Host](tx, selectHosts)
+		if err != nil {
+			return fmt.Errorf("failed to load hosts: %w", err)
+		}
+		if hosts, err = fn(hosts); err != nil {
+			return err
+		}
+		return saveSQLiteHosts(tx, hosts)
+	})
+}
+
+func saveSQLiteHosts(tx *sql.Tx, hosts []models.Host) error {
+	rows := make([]sqliteRow, 0, len(hosts))
+	for _, host := range hosts {
+		data, err := json.Marshal(host)
+		if err != nil {
+			return fmt.Errorf("failed to marshal host: %w", err)
+		}
+		rows = append(rows, sqliteRow{id: host.ID, columns: []any{host.Name, host.UserID, host.GroupID}, data: data})
+	}
+	return syncRows(tx, "hosts", []string{"name", "user_id", "group_id"}, rows)
+}
+
+// UpdateUsersAndHosts updates users and hosts in one transaction
+func (r *SQLiteRepository) UpdateUsersAndHosts(fn func([]models.User, []models.Host) ([]models.User, []models.Host, error)) error {
+	return r.update(func(tx *sql.Tx) error {
+		users, err := loadRows[models.User](tx, selectUsers)
+		if err != nil {
+			return fmt.Errorf("failed to load users: %w", err)
+		}
+		hosts, err := loadRows[models.Host](tx, selectHosts)
+		if err != nil {
+			return fmt.Errorf("failed to load hosts: %w", err)
+		}
+		if users, hosts, err = fn(users, hosts); err != nil {
+			return err
+		}
+		if err := saveSQLiteUsers(tx, users); err != nil {
+			return err
+		}
+		return saveSQLiteHosts(tx, hosts)
+	})
+}
+
+// LoadGroups loads all groups, sorted alphabetically by name
+func (r *SQLiteRepository) LoadGroups() ([]models.Group, error) {
+	groups, err := loadRows[models.Group](r.db, selectGroups)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load groups: %w", err)
+	}
+	return groups, nil
+}
+
+// UpdateGroups loads the groups, passes them to fn and saves the result in one transaction
+func (r *SQLiteRepository) UpdateGroups(fn func([]models.Group) ([]models.Group, error)) error {
+	return r.update(func(tx *sql.Tx) error {
+		groups, err := loadRows[models.Group](tx, selectGroups)
+		if err != nil {
+			return fmt.Errorf("failed to load groups: %w", err)
+		}
+		if groups, err = fn(groups); err != nil {
+			return err
+		}
+		rows := make([]sqliteRow, 0, len(groups))
+		for _, group := range groups {
+			data, err := json.Marshal(group)
+			if err != nil {
+				return fmt.Errorf("failed to marshal group: %w", err)
+			}
+			rows = append(rows, sqliteRow{id: group.ID, columns: []any{group.Name}, data: data})
+		}
+		return syncRows(tx, "host_groups", []string{"name"}, rows)
+	})
+}
+
+// LoadHistory returns the latest launches, newest first (limit <= 0 = all)
+func (r *SQLiteRepository) LoadHistory(limit int) ([]models.HistoryEntry, error) {
+	query := `SELECT data FROM history ORDER BY seq DESC`
+	if limit > 0 {
+		query += fmt.Sprintf(` LIMIT %d`, limit)
+	}
+	entries, err := loadRows[models.HistoryEntry](r.db, query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load history: %w", err)
+	}
+	return entries, nil
+}
+
+// AddHistory records a launch, dropping the oldest entries beyond MaxHistoryEntries
+func (r *SQLiteRepository) AddHistory(entry models.HistoryEntry) error {
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return fmt.Errorf("failed to marshal history entry: %w", err)
+	}
+	return r.update(func(tx *sql.Tx) error {
+		if _, err := tx.Exec(`INSERT INTO history (host_id, data) VALUES (?, ?)`, entry.HostID, string(data)); err != nil {
+			return fmt.Errorf("failed to write history entry: %w", err)
+		}
+		_, err := tx.Exec(`DELETE FROM history WHERE seq <= (SELECT MAX(seq) FROM history) - ?`, MaxHistoryEntries)
+		return err
+	})
+}
+
+// ReplaceHistory replaces all history entries (newest first, like LoadHistory)
+func (r *SQLiteRepository) ReplaceHistory(entries []models.HistoryEntry) error {
+	entries = latestHistory(entries)
+	return r.update(func(tx *sql.Tx) error {
+		if _, err := tx.Exec(`DELETE FROM history`); err != nil {
+			return fmt.Errorf("failed to clear history: %w", err)
+		}
+		// Oldest first, seq keeps the order
+		for i := len(entries) - 1; i >= 0; i-- {
+			data, err := json.Marshal(entries[i])
+			if err != nil {
+				return fmt.Errorf("failed to marshal history entry: %w", err)
+			}
+			if _, err := tx.Exec(`INSERT INTO history (host_id, data) VALUES (?, ?)`, entries[i].HostID, string(data)); err != nil {
+				return fmt.Errorf("failed to write history entry: %w", err)
+			}
+		}
+		return nil
+	})
+}
@@ -0,0 +1,259 @@
+package storage
+
+import (
+	"path/filepath"
+	"reflect"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/chrilep/LaunchRDP/app/models"
+)
+
+func openTestSQLite(t *testing.T) *SQLiteRepository {
+	t.Helper()
+	repo, err := OpenSQLite(filepath.Join(t.TempDir(), DatabaseFileName))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { repo.Close() })
+	return repo
+}
+
+// testRepositoryContent fills a repository with users, hosts, groups and history
+func testRepositoryContent(t *testing.T, repo Repository) {
+	t.Helper()
+	alice, bob := models.NewUser("Alice", `CORP\alice`), models.NewUser("Bob", "bob")
+	group := models.NewGroup("Servers")
+	web := models.NewHost("Web", "web.example.com", 3389, alice.ID)
+	web.GroupID = group.ID
+	web.RedirectDrives, web.DrivesToRedirect = true, "C:;D:"
+	web.RemoteApps = []models.RemoteApp{{ID: "app-1", Name: "Notepad", Program: "||notepad"}}
+	db := models.NewHost("DB", "db.example.com", 3390, bob.ID)
+	db.GatewayHostname, db.GatewayUsageMethod = "gw.example.com", 1
+	db.CustomProperties = map[string]string{"audiomode:i": "2"}
+
+	err := repo.UpdateUsersAndHosts(func([]models.User, []models.Host) ([]models.User, []models.Host, error) {
+		return []models.User{alice, bob}, []models.Host{web, db}, nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := repo.UpdateGroups(func([]models.Group) ([]models.Group, error) { return []models.Group{group}, nil }); err != nil {
+		t.Fatal(err)
+	}
+	for i, host := range []models.Host{web, db, web} {
+		entry := models.NewHistoryEntry(host, "", host.UserID, "mstsc")
+		entry.LaunchedAt = time.Date(2026, 1, 1, 10, i, 0, 0, time.UTC)
+		if err := repo.AddHistory(entry); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+// repositoryContent is everything a repository stores
+type repositoryContent struct {
+	Users   []models.User
+	Hosts   []models.Host
+	Groups  []models.Group
+	History []models.HistoryEntry
+}
+
+func loadContent(t *testing.T, repo Repository) repositoryContent {
+	t.Helper()
+	var content repositoryContent
+	var err error
+	if content.Users, err = repo.LoadUsers(); err != nil {
+		t.Fatal(err)
+	}
+	if content.Hosts, err = repo.LoadHosts(); err != nil {
+		t.Fatal(err)
+	}
+	if content.Groups, err = repo.LoadGroups(); err != nil {
+		t.Fatal(err)
+	}
+	if content.History, err = repo.LoadHistory(0); err != nil {
+		t.Fatal(err)
+	}
+	return content
+}
+
+func TestCopyRepositoryRoundTrip(t *testing.T) {
+	source := newTestStorage(t)
+	testRepositoryContent(t, source)
+	want := loadContent(t, source)
+	if len(want.Users) != 2 || len(want.Hosts) != 2 || len(want.Groups) != 1 || len(want.History) != 3 {
+		t.Fatalf("test content incomplete: %+v", want)
+	}
+
+	sqlite := openTestSQLite(t)
+	if err := CopyRepository(sqlite, source); err != nil {
+		t.Fatal(err)
+	}
+	if got := loadContent(t, sqlite); !reflect.DeepEqual(got, want) {
+		t.Errorf("JSON -> SQLite:\n%+v\nwant\n%+v", got, want)
+	}
+
+	// Switching back copies into the original files, which still hold everything
+	if err := CopyRepository(source, sqlite); err != nil {
+		t.Fatal(err)
+	}
+	if got := loadContent(t, source); !reflect.DeepEqual(got, want) {
+		t.Errorf("SQLite -> JSON:\n%+v\nwant\n%+v", got, want)
+	}
+	// and copying again into the database replaces its history instead of appending
+	if err := CopyRepository(sqlite, source); err != nil {
+		t.Fatal(err)
+	}
+	if got := loadContent(t, sqlite); !reflect.DeepEqual(got, want) {
+		t.Errorf("JSON -> SQLite again:\n%+v\nwant\n%+v", got, want)
+	}
+}
+
+func TestReplaceHistory(t *testing.T) {
+	for name, repo := range map[string]Repository{"json": newTestStorage(t), "sqlite": openTestSQLite(t)} {
+		t.Run(name, func(t *testing.T) {
+			testRepositoryContent(t, repo)
+			host := models.NewHost("Web", "web.example.com", 3389, "")
+			// Newest first, one more than the repository keeps
+			entries := make([]models.HistoryEntry, MaxHistoryEntries+1)
+			for i := range entries {
+				entries[i] = models.NewHistoryEntry(host, "", "", "mstsc")
+				entries[i].LaunchedAt = time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC).Add(-time.Duration(i) * time.Minute)
+			}
+			if err := repo.ReplaceHistory(entries); err != nil {
+				t.Fatal(err)
+			}
+			history, err := repo.LoadHistory(0)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(history) != MaxHistoryEntries {
+				t.Fatalf("%d entries, want %d", len(history), MaxHistoryEntries)
+			}
+			if !history[0].LaunchedAt.Equal(entries[0].LaunchedAt) || !history[len(history)-1].LaunchedAt.Equal(entries[MaxHistoryEntries-1].LaunchedAt) {
+				t.Errorf("history from %v to %v", history[0].LaunchedAt, history[len(history)-1].LaunchedAt)
+			}
+			// New launches are added after the replaced entries
+			entry := models.NewHistoryEntry(host, "", "", "mstsc")
+			if err := repo.AddHistory(entry); err != nil {
+				t.Fatal(err)
+			}
+			if latest, err := repo.LoadHistory(1); err != nil || len(latest) != 1 || latest[0].ID != entry.ID {
+				t.Errorf("latest entry = %+v, %v", latest, err)
+			}
+
+			if err := repo.ReplaceHistory(nil); err != nil {
+				t.Fatal(err)
+			}
+			if history, err := repo.LoadHistory(0); err != nil || len(history) != 0 {
+				t.Errorf("history after clearing = %d entries, %v", len(history), err)
+			}
+		})
+	}
+}
+
+func TestSQLiteSaveWritesChangedRows(t *testing.T) {
+	repo := openTestSQLite(t)
+	testRepositoryContent(t, repo)
+	// Record every row SQLite actually writes
+	for _, statement := range []string{
+		`CREATE TABLE writes (id TEXT)`,
+		`CREATE TRIGGER hosts_updated AFTER UPDATE ON hosts BEGIN INSERT INTO writes VALUES (new.id); END`,
+		`CREATE TRIGGER hosts_inserted AFTER INSERT ON hosts BEGIN INSERT INTO writes VALUES (new.id); END`,
+		`CREATE TRIGGER hosts_deleted AFTER DELETE ON hosts BEGIN INSERT INTO writes VALUES (old.id); END`,
+	} {
+		if _, err := repo.db.Exec(statement); err != nil {
+			t.Fatal(err)
+		}
+	}
+	writes := func() []string {
+		t.Helper()
+		ids, err := loadRows[string](repo.db, `SELECT json_quote(id) FROM writes`)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := repo.db.Exec(`DELETE FROM writes`); err != nil {
+			t.Fatal(err)
+		}
+		slices.Sort(ids)
+		return ids
+	}
+
+	hosts, err := repo.LoadHosts()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := repo.SaveHosts(hosts); err != nil {
+		t.Fatal(err)
+	}
+	if ids := writes(); len(ids) != 0 {
+		t.Errorf("unchanged save wrote %v", ids)
+	}
+
+	// hosts are sorted by name: DB, Web
+	var changed, removed string
+	err = repo.UpdateHosts(func(hosts []models.Host) ([]models.Host, error) {
+		hosts[0].Port = 3391
+		changed, removed = hosts[0].ID, hosts[1].ID
+		added := models.NewHost("New", "new.example.com", 3389, "")
+		return []models.Host{hosts[0], added}, nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	hosts, err = repo.LoadHosts()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if names := hostNames(hosts); !slices.Equal(names, []string{"DB", "New"}) || hosts[0].Port != 3391 {
+		t.Fatalf("hosts = %v", hosts)
+	}
+	want := []string{changed, hosts[1].ID, removed}
+	slices.Sort(want)
+	if ids := writes(); !slices.Equal(ids, want) {
+		t.Errorf("wrote %v, want %v", ids, want)
+	}
+
+	// Deleting all rows
+	if err := repo.SaveHosts(nil); err != nil {
+		t.Fatal(err)
+	}
+	if hosts, _ := repo.LoadHosts(); len(hosts) != 0 {
+		t.Errorf("hosts left: %v", hostNames(hosts))
+	}
+}
+
+func TestSQLiteLoadInsideUpdate(t *testing.T) {
+	repo := openTestSQLite(t)
+	testRepositoryContent(t, repo)
+
+	done := make(chan error, 1)
+	go func() {
+		done <- repo.UpdateHosts(func(hosts []models.Host) ([]models.Host, error) {
+			users, err := repo.LoadUsers()
+			if err != nil {
+				return nil, err
+			}
+			before, err := repo.LoadHosts()
+			if err != nil {
+				return nil, err
+			}
+			if len(users) != 2 || len(before) != len(hosts) {
+				t.Errorf("loaded %d users and %d hosts", len(users), len(before))
+			}
+			return hosts[:1], nil
+		})
+	}()
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(10 * time.Second):
+		t.Fatal("Load inside Update blocked")
+	}
+	if hosts, _ := repo.LoadHosts(); len(hosts) != 1 {
+		t.Errorf("%d hosts after the update, want 1", len(hosts))
+	}
+}
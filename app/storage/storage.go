@@ -6,52 +6,77 @@ import (
 	"os"
 	"sort"
 	"strings"
+	"sync"
 
 	"github.com/chrilep/LaunchRDP/app/config"
 	"github.com/chrilep/LaunchRDP/app/models"
 )
 
 const (
-	UsersFileName = "users.json"
-	HostsFileName = "hosts.json"
+	UsersFileName   = "users.json"
+	HostsFileName   = "hosts.json"
+	GroupsFileName  = "groups.json"
+	HistoryFileName = "history.json"
+	// Experience profiles are app-wide and stored next to the hosts
+	ProfilesFileName = "profiles.json"
+	SigningFileName  = "signing.json"
 )
 
-// Storage handles reading and writing of users and hosts
+// Storage handles reading and writing of users and hosts (the JSON Repository)
+// and of the app-wide settings
 type Storage struct {
-	usersPath string
-	hostsPath string
+	usersPath    string
+	hostsPath    string
+	groupsPath   string
+	historyPath  string
+	profilesPath string
+	signingPath  string
+	lockPath     string
+
+	mu           sync.Mutex // serializes modifications, see lock
+	recoveries   []Recovery // corrupt files loaded from their backup
+	recoveriesMu sync.Mutex
 }
 
 // NewStorage creates a new storage instance
 func NewStorage() *Storage {
 	return &Storage{
-		usersPath: config.GetConfigPath(UsersFileName),
-		hostsPath: config.GetConfigPath(HostsFileName),
+		usersPath:    config.GetConfigPath(UsersFileName),
+		hostsPath:    config.GetConfigPath(HostsFileName),
+		groupsPath:   config.GetConfigPath(GroupsFileName),
+		historyPath:  config.GetConfigPath(HistoryFileName),
+		profilesPath: config.GetConfigPath(ProfilesFileName),
+		signingPath:  config.GetConfigPath(SigningFileName),
+		lockPath:     config.GetConfigPath(LockFileName),
 	}
 }
 
-// LoadUsers loads users from JSON file
+// LoadUsers loads users from JSON file, falling back to the backup if it is corrupt
 func (s *Storage) LoadUsers() ([]models.User, error) {
-	if _, err := os.Stat(s.usersPath); os.IsNotExist(err) {
+	var users models.Users
+	if err := s.readJSON(s.usersPath, &users, usersMigrations); os.IsNotExist(err) {
 		// File doesn't exist, return empty slice
 		return []models.User{}, nil
-	}
-
-	data, err := os.ReadFile(s.usersPath)
-	if err != nil {
-		return nil, fmt.Errorf("failed to read users file: %w", err)
-	}
-
-	var users models.Users
-	if err := json.Unmarshal(data, &users); err != nil {
-		return nil, fmt.Errorf("failed to unmarshal users: %w", err)
+	} else if err != nil {
+		return nil, fmt.Errorf("failed to load users: %w", err)
 	}
 
 	return users.Users, nil
 }
 
-// SaveUsers saves users to JSON file (sorted alphabetically by username)
+// SaveUsers saves users to JSON file (sorted alphabetically by username, written atomically).
+// Use UpdateUsers to modify loaded users without losing concurrent changes.
 func (s *Storage) SaveUsers(users []models.User) error {
+	unlock, err := s.lock()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+	return s.saveUsers(users)
+}
+
+// saveUsers implements SaveUsers, the caller holds the lock
+func (s *Storage) saveUsers(users []models.User) error {
 	// Sort users before saving to keep config file organized
 	sortedUsers := make([]models.User, len(users))
 	copy(sortedUsers, users)
@@ -59,42 +84,46 @@ func (s *Storage) SaveUsers(users []models.User) error {
 		return strings.ToLower(sortedUsers[i].Username) < strings.ToLower(sortedUsers[j].Username)
 	})
 
-	usersData := models.Users{Users: sortedUsers}
+	usersData := models.Users{SchemaVersion: UsersSchemaVersion, Users: sortedUsers}
 
 	data, err := json.MarshalIndent(usersData, "", "  ")
 	if err != nil {
 		return fmt.Errorf("failed to marshal users: %w", err)
 	}
 
-	if err := os.WriteFile(s.usersPath, data, 0644); err != nil {
+	if err := writeFileAtomic(s.usersPath, data, 0644); err != nil {
 		return fmt.Errorf("failed to write users file: %w", err)
 	}
 
 	return nil
 }
 
-// LoadHosts loads hosts from JSON file
+// LoadHosts loads hosts from JSON file, falling back to the backup if it is corrupt
 func (s *Storage) LoadHosts() ([]models.Host, error) {
-	if _, err := os.Stat(s.hostsPath); os.IsNotExist(err) {
+	var hosts models.Hosts
+	if err := s.readJSON(s.hostsPath, &hosts, hostsMigrations); os.IsNotExist(err) {
 		// File doesn't exist, return empty slice
 		return []models.Host{}, nil
-	}
-
-	data, err := os.ReadFile(s.hostsPath)
-	if err != nil {
-		return nil, fmt.Errorf("failed to read hosts file: %w", err)
-	}
-
-	var hosts models.Hosts
-	if err := json.Unmarshal(data, &hosts); err != nil {
-		return nil, fmt.Errorf("failed to unmarshal hosts: %w", err)
+	} else if err != nil {
+		return nil, fmt.Errorf("failed to load hosts: %w", err)
 	}
 
 	return hosts.Hosts, nil
 }
 
-// SaveHosts saves hosts to JSON file (sorted alphabetically by name)
+// SaveHosts saves hosts to JSON file (sorted alphabetically by name, written atomically).
+// Use UpdateHosts to modify loaded hosts without losing concurrent changes.
 func (s *Storage) SaveHosts(hosts []models.Host) error {
+	unlock, err := s.lock()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+	return s.saveHosts(hosts)
+}
+
+// saveHosts implements SaveHosts, the caller holds the lock
+func (s *Storage) saveHosts(hosts []models.Host) error {
 	// Sort hosts before saving to keep config file organized
 	sortedHosts := make([]models.Host, len(hosts))
 	copy(sortedHosts, hosts)
@@ -102,16 +131,246 @@ func (s *Storage) SaveHosts(hosts []models.Host) error {
 		return strings.ToLower(sortedHosts[i].Name) < strings.ToLower(sortedHosts[j].Name)
 	})
 
-	hostsData := models.Hosts{Hosts: sortedHosts}
+	hostsData := models.Hosts{SchemaVersion: HostsSchemaVersion, Hosts: sortedHosts}
 
 	data, err := json.MarshalIndent(hostsData, "", "  ")
 	if err != nil {
 		return fmt.Errorf("failed to marshal hosts: %w", err)
 	}
 
-	if err := os.WriteFile(s.hostsPath, data, 0644); err != nil {
+	if err := writeFileAtomic(s.hostsPath, data, 0644); err != nil {
 		return fmt.Errorf("failed to write hosts file: %w", err)
 	}
 
 	return nil
 }
+
+// LoadGroups loads groups from JSON file
+func (s *Storage) LoadGroups() ([]models.Group, error) {
+	var groups models.Groups
+	if err := s.readJSON(s.groupsPath, &groups, nil); os.IsNotExist(err) {
+		return []models.Group{}, nil
+	} else if err != nil {
+		return nil, fmt.Errorf("failed to load groups: %w", err)
+	}
+
+	return groups.Groups, nil
+}
+
+// UpdateGroups is UpdateHosts for groups (saved sorted alphabetically by name)
+func (s *Storage) UpdateGroups(fn func([]models.Group) ([]models.Group, error)) error {
+	unlock, err := s.lock()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	groups, err := s.LoadGroups()
+	if err != nil {
+		return err
+	}
+	groups, err = fn(groups)
+	if err != nil {
+		return err
+	}
+
+	sortedGroups := make([]models.Group, len(groups))
+	copy(sortedGroups, groups)
+	sort.Slice(sortedGroups, func(i, j int) bool {
+		return strings.ToLower(sortedGroups[i].Name) < strings.ToLower(sortedGroups[j].Name)
+	})
+
+	data, err := json.MarshalIndent(models.Groups{Groups: sortedGroups}, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal groups: %w", err)
+	}
+
+	if err := writeFileAtomic(s.groupsPath, data, 0644); err != nil {
+		return fmt.Errorf("failed to write groups file: %w", err)
+	}
+
+	return nil
+}
+
+// LoadHistory loads the launch history from JSON file, newest first
+func (s *Storage) LoadHistory(limit int) ([]models.HistoryEntry, error) {
+	var history models.History
+	if err := s.readJSON(s.historyPath, &history, nil); os.IsNotExist(err) {
+		return []models.HistoryEntry{}, nil
+	} else if err != nil {
+		return nil, fmt.Errorf("failed to load history: %w", err)
+	}
+
+	// The file is oldest first
+	entries := make([]models.HistoryEntry, 0, len(history.Entries))
+	for i := len(history.Entries) - 1; i >= 0; i-- {
+		if limit > 0 && len(entries) == limit {
+			break
+		}
+		entries = append(entries, history.Entries[i])
+	}
+	return entries, nil
+}
+
+// AddHistory appends a launch to the history file
+func (s *Storage) AddHistory(entry models.HistoryEntry) error {
+	unlock, err := s.lock()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	var history models.History
+	if err := s.readJSON(s.historyPath, &history, nil); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to load history: %w", err)
+	}
+	history.Entries = append(history.Entries, entry)
+	if len(history.Entries) > MaxHistoryEntries {
+		history.Entries = history.Entries[len(history.Entries)-MaxHistoryEntries:]
+	}
+	return s.saveHistory(history)
+}
+
+// ReplaceHistory replaces all history entries (newest first, like LoadHistory)
+// with one write of the history file
+func (s *Storage) ReplaceHistory(entries []models.HistoryEntry) error {
+	unlock, err := s.lock()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	entries = latestHistory(entries)
+	// The file is oldest first
+	history := models.History{Entries: make([]models.HistoryEntry, 0, len(entries))}
+	for i := len(entries) - 1; i >= 0; i-- {
+		history.Entries = append(history.Entries, entries[i])
+	}
+	return s.saveHistory(history)
+}
+
+// saveHistory writes the history file, the caller holds the lock
+func (s *Storage) saveHistory(history models.History) error {
+	data, err := json.MarshalIndent(history, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal history: %w", err)
+	}
+
+	if err := writeFileAtomic(s.historyPath, data, 0644); err != nil {
+		return fmt.Errorf("failed to write history file: %w", err)
+	}
+
+	return nil
+}
+
+// Backend returns BackendJSON
+func (s *Storage) Backend() string {
+	return BackendJSON
+}
+
+// Close has nothing to release, files are closed after every access
+func (s *Storage) Close() error {
+	return nil
+}
+
+// LoadExperienceProfiles loads experience profiles from JSON file.
+// Returns nil if the file doesn't exist yet, so the caller can use built-in profiles.
+func (s *Storage) LoadExperienceProfiles() ([]models.ExperienceProfile, error) {
+	var profiles models.ExperienceProfiles
+	if err := s.readJSON(s.profilesPath, &profiles, nil); os.IsNotExist(err) {
+		return nil, nil
+	} else if err != nil {
+		return nil, fmt.Errorf("failed to load profiles: %w", err)
+	}
+	if profiles.Profiles == nil {
+		profiles.Profiles = []models.ExperienceProfile{}
+	}
+
+	return profiles.Profiles, nil
+}
+
+// SaveExperienceProfiles saves experience profiles to JSON file (in the given order)
+func (s *Storage) SaveExperienceProfiles(profiles []models.ExperienceProfile) error {
+	unlock, err := s.lock()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	return s.saveExperienceProfiles(profiles)
+}
+
+// UpdateExperienceProfiles is UpdateHosts for experience profiles. fn gets nil
+// while no profiles were saved yet, like LoadExperienceProfiles returns.
+func (s *Storage) UpdateExperienceProfiles(fn func([]models.ExperienceProfile) ([]models.ExperienceProfile, error)) error {
+	unlock, err := s.lock()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	profiles, err := s.LoadExperienceProfiles()
+	if err != nil {
+		return err
+	}
+	profiles, err = fn(profiles)
+	if err != nil {
+		return err
+	}
+	return s.saveExperienceProfiles(profiles)
+}
+
+// saveExperienceProfiles writes the profiles file, the caller holds the lock
+func (s *Storage) saveExperienceProfiles(profiles []models.ExperienceProfile) error {
+	profilesData := models.ExperienceProfiles{Profiles: profiles}
+
+	data, err := json.MarshalIndent(profilesData, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal profiles: %w", err)
+	}
+
+	if err := writeFileAtomic(s.profilesPath, data, 0644); err != nil {
+		return fmt.Errorf("failed to write profiles file: %w", err)
+	}
+
+	return nil
+}
+
+// LoadSigningSettings loads the signing certificate settings, nil if signing is not configured
+func (s *Storage) LoadSigningSettings() (*models.SigningSettings, error) {
+	var settings models.SigningSettings
+	if err := s.readJSON(s.signingPath, &settings, nil); os.IsNotExist(err) {
+		return nil, nil
+	} else if err != nil {
+		return nil, fmt.Errorf("failed to load signing settings: %w", err)
+	}
+
+	return &settings, nil
+}
+
+// SaveSigningSettings saves the signing certificate settings, nil removes them
+func (s *Storage) SaveSigningSettings(settings *models.SigningSettings) error {
+	unlock, err := s.lock()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	if settings == nil {
+		if err := os.Remove(s.signingPath); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("failed to remove signing file: %w", err)
+		}
+		return nil
+	}
+
+	data, err := json.MarshalIndent(settings, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal signing settings: %w", err)
+	}
+
+	if err := writeFileAtomic(s.signingPath, data, 0644); err != nil {
+		return fmt.Errorf("failed to write signing file: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,26 @@
+package storage
+
+import (
+	"testing"
+
+	"github.com/chrilep/LaunchRDP/app/config"
+	"github.com/chrilep/LaunchRDP/app/models"
+)
+
+// newTestStorage returns a JSON storage in a temporary config directory
+func newTestStorage(t *testing.T) *Storage {
+	t.Helper()
+	previous := config.ConfigDir
+	config.ConfigDir = t.TempDir()
+	t.Cleanup(func() { config.ConfigDir = previous })
+	return NewStorage()
+}
+
+// hostNames returns the names of the hosts in order
+func hostNames(hosts []models.Host) []string {
+	names := make([]string, len(hosts))
+	for i, host := range hosts {
+		names[i] = host.Name
+	}
+	return names
+}
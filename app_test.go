@@ -0,0 +1,614 @@
+package main
+
+import (
+	"database/sql"
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/chrilep/LaunchRDP/app/config"
+	"github.com/chrilep/LaunchRDP/app/credentials"
+	"github.com/chrilep/LaunchRDP/app/models"
+	"github.com/chrilep/LaunchRDP/app/rdp"
+	"github.com/chrilep/LaunchRDP/app/rdp/rdptest"
+	"github.com/chrilep/LaunchRDP/app/storage"
+)
+
+// testApp is an app on JSON files in a temporary directory, with an in-memory
+// credential store and a generator that records launches
+type testApp struct {
+	*LaunchRDPApp
+	launcher *rdptest.Launcher
+	windows  *rdptest.WindowFinder
+	store    *credentials.MemoryStore
+}
+
+func newTestApp(t *testing.T) *testApp {
+	t.Helper()
+	previousConfig, previousTemp := config.ConfigDir, config.TempDir
+	config.ConfigDir = t.TempDir()
+	config.TempDir = t.TempDir()
+	t.Cleanup(func() { config.ConfigDir, config.TempDir = previousConfig, previousTemp })
+
+	cipher, err := credentials.NewMemoryCipher()
+	if err != nil {
+		t.Fatal(err)
+	}
+	store := credentials.NewMemoryStore()
+	launcher := &rdptest.Launcher{}
+	windows := rdptest.NewWindowFinder()
+	generator := rdp.NewGenerator()
+	generator.SetLauncher(launcher)
+	generator.SetWindowFinder(windows)
+
+	app := &LaunchRDPApp{
+		storage:   storage.NewStorage(),
+		cipher:    cipher,
+		credStore: store,
+		rdpGen:    generator,
+		winState:  &WindowState{},
+	}
+	app.repo = app.storage
+	generator.SetPasswordCallback(app.userPassword)
+	return &testApp{LaunchRDPApp: app, launcher: launcher, windows: windows, store: store}
+}
+
+// addUser saves a user with an encrypted password
+func (a *testApp) addUser(t *testing.T, username, password string) models.User {
+	t.Helper()
+	user := models.NewUser(username, username)
+	encrypted, err := a.cipher.Encrypt(password)
+	if err != nil {
+		t.Fatal(err)
+	}
+	user.EncryptedPassword = encrypted
+	err = a.repository().UpdateUsers(func(users []models.User) ([]models.User, error) {
+		return append(users, user), nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return user
+}
+
+// addHost saves an mstsc host (the same backend on every platform) without
+// going through the bindings
+func (a *testApp) addHost(t *testing.T, name, address, userID string) models.Host {
+	t.Helper()
+	host := models.NewHost(name, address, 3389, userID)
+	host.Launcher = rdp.BackendMSTSC
+	err := a.repository().UpdateHosts(func(hosts []models.Host) ([]models.Host, error) {
+		return append(hosts, host), nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return host
+}
+
+func (a *testApp) history(t *testing.T) []models.HistoryEntry {
+	t.Helper()
+	entries, err := a.repository().LoadHistory(0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return entries
+}
+
+func TestLaunchRDPRecordsHistory(t *testing.T) {
+	app := newTestApp(t)
+	user := app.addUser(t, "alice", "pw")
+	host := app.addHost(t, "Server", "srv.example.com", user.ID)
+
+	reused, err := app.LaunchRDP(host.ID, user.ID, 0, 0)
+	if err != nil || reused {
+		t.Fatalf("LaunchRDP = %v, %v", reused, err)
+	}
+	if len(app.launcher.Launches()) != 1 {
+		t.Fatalf("%d launches, want 1", len(app.launcher.Launches()))
+	}
+	history := app.history(t)
+	if len(history) != 1 || history[0].HostID != host.ID || history[0].UserID != user.ID {
+		t.Fatalf("history = %+v", history)
+	}
+
+	// Activating an open session is not a new launch
+	app.windows.AddWindow(host.Address, 1)
+	if reused, err := app.LaunchRDP(host.ID, user.ID, 0, 0); err != nil || !reused {
+		t.Fatalf("LaunchRDP = %v, %v, want reused", reused, err)
+	}
+	if len(app.history(t)) != 1 {
+		t.Errorf("reused window recorded in history")
+	}
+}
+
+func TestLaunchRDPFailureRecordsNoHistory(t *testing.T) {
+	app := newTestApp(t)
+	user := app.addUser(t, "alice", "pw")
+	host := app.addHost(t, "Server", "srv.example.com", user.ID)
+	failure := errors.New("executable not found")
+	app.launcher.Err = failure
+
+	if _, err := app.LaunchRDP(host.ID, user.ID, 0, 0); !errors.Is(err, failure) {
+		t.Fatalf("err = %v, want it to wrap %v", err, failure)
+	}
+	if history := app.history(t); len(history) != 0 {
+		t.Errorf("failed launch recorded: %+v", history)
+	}
+
+	// Unknown hosts and users fail before anything is started
+	if _, err := app.LaunchRDP("missing", user.ID, 0, 0); err == nil {
+		t.Error("unknown host launched")
+	}
+	if _, err := app.LaunchRDP(host.ID, "missing", 0, 0); err == nil {
+		t.Error("unknown user launched")
+	}
+	if len(app.launcher.Launches()) != 1 || len(app.history(t)) != 0 {
+		t.Errorf("%d launches, %d history entries", len(app.launcher.Launches()), len(app.history(t)))
+	}
+}
+
+func TestCreateHostRefusesCredentialConflict(t *testing.T) {
+	app := newTestApp(t)
+	alice := app.addUser(t, "alice", "pw1")
+	bob := app.addUser(t, "bob", "pw2")
+	app.addHost(t, "Alice's", "srv.example.com", alice.ID)
+
+	var conflict *credentials.ConflictError
+	if err := app.CreateHost("Bob's", "SRV.example.com", bob.ID, 3389); !errors.As(err, &conflict) {
+		t.Errorf("CreateHost() = %v, want a ConflictError", err)
+	}
+	err := app.CreateHostFull("Bob's", "srv.example.com", bob.ID, 3389, "window", 0, 0, 1024, 768,
+		false, false, "", false, "", 0, 0, false)
+	if !errors.As(err, &conflict) {
+		t.Errorf("CreateHostFull() = %v, want a ConflictError", err)
+	}
+	if hosts, _ := app.GetHosts(); len(hosts) != 1 {
+		t.Fatalf("%d hosts saved, want 1", len(hosts))
+	}
+
+	// The same user, or another target, is no conflict
+	if err := app.CreateHost("Alice's 2", "srv.example.com", alice.ID, 3389); err != nil {
+		t.Error(err)
+	}
+	if err := app.CreateHost("Bob's", "other.example.com", bob.ID, 3389); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestImportRDPFilesReportsCredentialConflict(t *testing.T) {
+	app := newTestApp(t)
+	alice := app.addUser(t, "alice", "pw1")
+	app.addUser(t, "bob", "pw2")
+	app.addHost(t, "Alice's", "srv.example.com", alice.ID)
+
+	dir := t.TempDir()
+	files := map[string]string{
+		"bob.rdp":   "full address:s:srv.example.com\r\nusername:s:bob\r\n",
+		"other.rdp": "full address:s:other.example.com\r\nusername:s:bob\r\n",
+		"carol.rdp": "full address:s:srv.example.com\r\nusername:s:carol\r\n",
+	}
+	var paths []string
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+		paths = append(paths, path)
+	}
+
+	result, err := app.ImportRDPFiles(paths)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// carol gets no password from the file, but would still log in with alice's
+	// credential: her host is rejected and her user is not created
+	if result.Imported != 1 || result.UsersCreated != 0 {
+		t.Errorf("result = %+v, want 1 imported and no new user", result)
+	}
+	if len(result.Errors) != 2 {
+		t.Errorf("errors = %q, want the conflicts of bob and carol", result.Errors)
+	}
+	for _, message := range result.Errors {
+		if !strings.Contains(message, "srv.example.com") {
+			t.Errorf("error %q is not the conflict on srv.example.com", message)
+		}
+	}
+	hosts, _ := app.GetHosts()
+	if len(hosts) != 2 {
+		t.Errorf("%d hosts saved, want 2", len(hosts))
+	}
+	users, _ := app.GetUsers()
+	if len(users) != 2 {
+		t.Errorf("%d users saved, want alice and bob", len(users))
+	}
+}
+
+// storeCredential stores the credential of a host like a launch or user update does
+func (a *testApp) storeCredential(t *testing.T, host models.Host) {
+	t.Helper()
+	if err := a.store.Store(host.CredentialTarget(), "alice", "pw"); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestDeleteHostDeletesUnusedCredential(t *testing.T) {
+	app := newTestApp(t)
+	user := app.addUser(t, "alice", "pw")
+	first := app.addHost(t, "First", "srv.example.com", user.ID)
+	second := app.addHost(t, "Second", "srv.example.com", user.ID)
+	app.storeCredential(t, first)
+
+	// The second host still connects to the target
+	if err := app.DeleteHost(first.ID); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := app.store.Password("srv.example.com"); !ok {
+		t.Fatal("credential deleted while another host uses it")
+	}
+
+	if err := app.DeleteHost(second.ID); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := app.store.Password("srv.example.com"); ok {
+		t.Error("credential of the last host kept")
+	}
+}
+
+func TestDeleteHostAliasTargets(t *testing.T) {
+	app := newTestApp(t)
+	user := app.addUser(t, "alice", "pw")
+	aliased := models.NewHost("Aliased", "srv.example.com", 3389, user.ID)
+	aliased.CredentialAlias = "srv-alias"
+	aliased.Launcher = rdp.BackendMSTSC
+	err := app.repository().UpdateHosts(func(hosts []models.Host) ([]models.Host, error) {
+		return append(hosts, aliased), nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	direct := app.addHost(t, "Direct", "srv.example.com", user.ID)
+	app.storeCredential(t, aliased)
+	app.storeCredential(t, direct)
+
+	// The alias is the target of the aliased host, the address belongs to the other one
+	if err := app.DeleteHost(aliased.ID); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := app.store.Password("srv-alias"); ok {
+		t.Error("credential of the alias kept")
+	}
+	if _, ok := app.store.Password("srv.example.com"); !ok {
+		t.Error("credential of the address deleted with the aliased host")
+	}
+}
+
+func TestPurgeOrphanedCredentialsKeepsUnmanaged(t *testing.T) {
+	app := newTestApp(t)
+	user := app.addUser(t, "alice", "pw")
+	host := app.addHost(t, "Server", "srv.example.com", user.ID)
+	app.storeCredential(t, host)
+	if err := app.store.Store("gone.example.com", "alice", "pw"); err != nil {
+		t.Fatal(err)
+	}
+	app.store.StoreUnmanaged("mstsc.example.com", "bob")
+
+	result, err := app.PurgeOrphanedCredentials()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(result.Deleted) != 1 || result.Deleted[0] != "gone.example.com" {
+		t.Errorf("deleted %v, want [gone.example.com]", result.Deleted)
+	}
+	for target, want := range map[string]bool{"srv.example.com": true, "mstsc.example.com": true, "gone.example.com": false} {
+		if _, ok := app.store.Password(target); ok != want {
+			t.Errorf("%s stored = %v, want %v", target, ok, want)
+		}
+	}
+}
+
+func TestExportVaultSkipsUndecryptablePasswords(t *testing.T) {
+	app := newTestApp(t)
+	app.addUser(t, "alice", "pw")
+	broken := app.addUser(t, "bob", "pw")
+	err := app.repository().UpdateUsers(func(users []models.User) ([]models.User, error) {
+		for i := range users {
+			if users[i].ID == broken.ID {
+				// Encrypted on another machine
+				users[i].EncryptedPassword = "AAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAA"
+			}
+		}
+		return users, nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	path := filepath.Join(t.TempDir(), "export.lrdpvault")
+	result, err := app.ExportVault(path, "correct horse", false)
+	if err != nil {
+		t.Fatalf("ExportVault() = %v", err)
+	}
+	if result.Users != 2 || len(result.UsersWithoutPassword) != 1 || result.UsersWithoutPassword[0] != "bob" {
+		t.Errorf("result = %+v, want 2 users, bob without password", result)
+	}
+
+	vault, err := credentials.ReadVault(path, "correct horse")
+	if err != nil {
+		t.Fatal(err)
+	}
+	passwords := map[string]string{}
+	for _, user := range vault.Users {
+		passwords[user.Username] = user.Password
+	}
+	if passwords["alice"] != "pw" || passwords["bob"] != "" || len(passwords) != 2 {
+		t.Errorf("exported passwords = %v", passwords)
+	}
+}
+
+func TestImportVaultRepairsOnlyImportedHosts(t *testing.T) {
+	app := newTestApp(t)
+	local := app.addUser(t, "local", "pw")
+	// Without a stored credential, left to RepairCredentials
+	app.addHost(t, "Local", "local.example.com", local.ID)
+
+	user := credentials.VaultUser{User: models.NewUser("Alice", "alice"), Password: "s3cret"}
+	host := models.NewHost("Server", "srv.example.com", 3389, user.ID)
+	host.Launcher = rdp.BackendMSTSC
+	path := filepath.Join(t.TempDir(), "export.lrdpvault")
+	vault := credentials.Vault{Users: []credentials.VaultUser{user}, Hosts: []models.Host{host}}
+	if err := credentials.WriteVault(path, "correct horse", vault); err != nil {
+		t.Fatal(err)
+	}
+
+	result, err := app.ImportVault(path, "correct horse", credentials.VaultKeep)
+	if err != nil {
+		t.Fatalf("ImportVault() = %v", err)
+	}
+	if result.HostsAdded != 1 || len(result.ChangedHostIDs) != 1 {
+		t.Errorf("result = %+v, want 1 host added and changed", result)
+	}
+	if password, ok := app.store.Password("srv.example.com"); !ok || password != "s3cret" {
+		t.Errorf("imported credential = %q, %v", password, ok)
+	}
+	if _, ok := app.store.Password("local.example.com"); ok {
+		t.Error("credential of a host the import did not change stored")
+	}
+}
+
+func TestImportRDPFilesSkipsDuplicates(t *testing.T) {
+	app := newTestApp(t)
+	path := filepath.Join(t.TempDir(), "Server.rdp")
+	if err := os.WriteFile(path, []byte("full address:s:srv.example.com\r\nusername:s:CORP\\alice\r\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	result, err := app.ImportRDPFiles([]string{path})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Imported != 1 || result.UsersCreated != 1 || len(result.Errors) != 0 {
+		t.Fatalf("first import = %+v", result)
+	}
+	users, _ := app.GetUsers()
+	if len(users) != 1 || users[0].Login != "alice" || users[0].Domain != "CORP" {
+		t.Errorf("users = %+v", users)
+	}
+
+	// Importing the file again, twice in one run, adds nothing
+	result, err = app.ImportRDPFiles([]string{path, path})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Imported != 0 || result.UsersCreated != 0 || len(result.Errors) != 2 {
+		t.Errorf("second import = %+v", result)
+	}
+	if hosts, _ := app.GetHosts(); len(hosts) != 1 {
+		t.Errorf("%d hosts, want 1", len(hosts))
+	}
+}
+
+func TestExperienceProfilesSaveAndDelete(t *testing.T) {
+	app := newTestApp(t)
+
+	// The first save starts from the built-in profiles
+	if err := app.SaveExperienceProfile(models.ExperienceProfile{Name: "Office", Properties: map[string]string{"Connection Type": "5"}}); err != nil {
+		t.Fatal(err)
+	}
+	profiles, err := app.GetExperienceProfiles()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(profiles) != len(rdp.DefaultExperienceProfiles())+1 {
+		t.Fatalf("%d profiles after the first save, want the built-in profiles and Office", len(profiles))
+	}
+	office := profiles[len(profiles)-1]
+	if office.ID == "" || office.Name != "Office" || office.Properties["connection type"] != "5" {
+		t.Errorf("saved profile = %+v", office)
+	}
+
+	office.Name = "Office WAN"
+	if err := app.SaveExperienceProfile(office); err != nil {
+		t.Fatal(err)
+	}
+	if err := app.SaveExperienceProfile(models.ExperienceProfile{ID: "missing", Name: "X"}); err == nil {
+		t.Error("updating a missing profile succeeded")
+	}
+	if err := app.DeleteExperienceProfile(rdp.ProfileLowSpeed); err != nil {
+		t.Fatal(err)
+	}
+	if err := app.DeleteExperienceProfile(rdp.ProfileLowSpeed); err == nil {
+		t.Error("deleting a missing profile succeeded")
+	}
+
+	profiles, err = app.GetExperienceProfiles()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, profile := range profiles {
+		names = append(names, profile.Name)
+	}
+	if want := []string{"LAN", "Broadband", "Office WAN"}; !slices.Equal(names, want) {
+		t.Errorf("profiles %v, want %v", names, want)
+	}
+}
+
+func TestChangeCredentialsPassphrase(t *testing.T) {
+	app := newTestApp(t)
+	if err := app.ChangeCredentialsPassphrase("old", "new"); err == nil {
+		t.Error("passphrase changed outside portable mode")
+	}
+
+	store := credentials.NewPassphraseStore(config.GetConfigPath(credentials.PassphraseFileName))
+	if err := store.Unlock("correct horse"); err != nil {
+		t.Fatal(err)
+	}
+	app.cipher = store
+	alice := app.addUser(t, "alice", "alice-pw")
+	// A password of another setup stays as it is
+	broken := models.NewUser("bob", "bob")
+	broken.EncryptedPassword = "AAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAA"
+	if err := app.repository().UpdateUsers(func(users []models.User) ([]models.User, error) {
+		return append(users, broken), nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := app.ChangeCredentialsPassphrase("wrong horse", "battery staple"); !errors.Is(err, credentials.ErrWrongPassphrase) {
+		t.Errorf("ChangeCredentialsPassphrase(wrong) = %v", err)
+	}
+	if err := app.ChangeCredentialsPassphrase("correct horse", "battery staple"); err != nil {
+		t.Fatal(err)
+	}
+
+	users, err := app.repository().LoadUsers()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, user := range users {
+		switch user.ID {
+		case alice.ID:
+			if user.EncryptedPassword == alice.EncryptedPassword {
+				t.Error("password of alice not re-encrypted")
+			}
+			if password, err := app.userPassword(user); err != nil || password != "alice-pw" {
+				t.Errorf("password of alice = %q, %v", password, err)
+			}
+		case broken.ID:
+			if user.EncryptedPassword != broken.EncryptedPassword {
+				t.Errorf("undecryptable password changed to %q", user.EncryptedPassword)
+			}
+		}
+	}
+	reopened := credentials.NewPassphraseStore(config.GetConfigPath(credentials.PassphraseFileName))
+	if err := reopened.Unlock("battery staple"); err != nil {
+		t.Errorf("new passphrase not saved: %v", err)
+	}
+}
+
+func TestSetStorageBackendRoundTrip(t *testing.T) {
+	app := newTestApp(t)
+	alice := app.addUser(t, "alice", "pw")
+	host := app.addHost(t, "Server", "srv.example.com", alice.ID)
+	if _, err := app.LaunchRDP(host.ID, alice.ID, 0, 0); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := app.SetStorageBackend(storage.BackendSQLite); err != nil {
+		t.Fatal(err)
+	}
+	if app.GetStorageBackend() != storage.BackendSQLite {
+		t.Fatalf("backend = %s", app.GetStorageBackend())
+	}
+	if err := app.SetStorageBackend(storage.BackendJSON); err != nil {
+		t.Fatal(err)
+	}
+	if app.GetStorageBackend() != storage.BackendJSON {
+		t.Fatalf("backend = %s", app.GetStorageBackend())
+	}
+
+	// The JSON files got the history back once, not appended to their own copy
+	if history := app.history(t); len(history) != 1 {
+		t.Errorf("%d history entries after the round trip, want 1", len(history))
+	}
+	dbPath := config.GetConfigPath(storage.DatabaseFileName)
+	if _, err := os.Stat(dbPath); !os.IsNotExist(err) {
+		t.Errorf("database still selects SQLite: %v", err)
+	}
+	if _, err := os.Stat(dbPath + storage.BackupSuffix); err != nil {
+		t.Errorf("database not kept as backup: %v", err)
+	}
+}
+
+func TestOpenRepositoryReadOnlyOnDatabaseError(t *testing.T) {
+	app := newTestApp(t)
+	app.addUser(t, "alice", "pw")
+
+	// A database of a newer LaunchRDP
+	dbPath := config.GetConfigPath(storage.DatabaseFileName)
+	db, err := storage.OpenSQLite(dbPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	db.Close()
+	raw, err := sql.Open("sqlite", dbPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := raw.Exec(`PRAGMA user_version = 99`); err != nil {
+		t.Fatal(err)
+	}
+	raw.Close()
+
+	app.repo = openRepository(app.storage)
+	var tooNew *storage.SchemaTooNewError
+	if storageErr := app.GetStorageError(); storageErr == "" {
+		t.Error("GetStorageError() is empty")
+	}
+	if users, err := app.GetUsers(); err != nil || len(users) != 1 {
+		t.Errorf("GetUsers() = %d users, %v", len(users), err)
+	}
+	if err := app.CreateUser("bob", "bob", "", "pw"); !errors.As(err, &tooNew) {
+		t.Errorf("CreateUser() = %v, want the database error", err)
+	}
+	if err := app.SetStorageBackend(storage.BackendSQLite); !errors.As(err, &tooNew) {
+		t.Errorf("SetStorageBackend() = %v, want the database error", err)
+	}
+	if users, _ := app.GetUsers(); len(users) != 1 {
+		t.Errorf("%d users after rejected changes", len(users))
+	}
+}
+
+func TestUpdateUserReportsCredentialConflict(t *testing.T) {
+	app := newTestApp(t)
+	alice := app.addUser(t, "alice", "pw1")
+	bob := app.addUser(t, "bob", "pw2")
+	shared := app.addHost(t, "Alice's", "srv.example.com", alice.ID)
+	app.storeCredential(t, shared)
+	// Saved before conflicts were checked
+	app.addHost(t, "Bob's", "srv.example.com", bob.ID)
+	own := app.addHost(t, "Bob's own", "bob.example.com", bob.ID)
+
+	warnings, err := app.UpdateUser(bob.ID, "bob", "bob", "", "new-pw")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(warnings) != 1 || !strings.Contains(warnings[0], `"Bob's"`) {
+		t.Errorf("warnings = %q, want the conflict of Bob's", warnings)
+	}
+	if password, _ := app.store.Password(shared.CredentialTarget()); password != "pw" {
+		t.Errorf("credential of alice overwritten with %q", password)
+	}
+	if password, _ := app.store.Password(own.CredentialTarget()); password != "new-pw" {
+		t.Errorf("credential of bob's own host = %q, want the new password", password)
+	}
+
+	// Without a new password nothing is stored and nothing reported
+	if warnings, err := app.UpdateUser(bob.ID, "bob", "bob", "", "__UNCHANGED__"); err != nil || len(warnings) != 0 {
+		t.Errorf("UpdateUser() = %q, %v", warnings, err)
+	}
+}
@@ -78,7 +78,13 @@ async function apiCall(name, ...args) {
           d.window_width || 1200,
           d.window_height || 800,
           !!d.redirect_clipboard,
-          !!d.redirect_drives
+          !!d.redirect_drives,
+          d.drives_to_redirect ?? "*",
+          d.dynamic_resolution ?? true,
+          d.gateway_hostname || "",
+          d.gateway_usage_method ?? 1,
+          d.gateway_credentials_source ?? 4,
+          !!d.gateway_bypass_local
         );
       }
       case "UpdateHostFull": {
@@ -95,7 +101,13 @@ async function apiCall(name, ...args) {
           d.window_width || 1200,
           d.window_height || 800,
           !!d.redirect_clipboard,
-          !!d.redirect_drives
+          !!d.redirect_drives,
+          d.drives_to_redirect ?? "*",
+          d.dynamic_resolution ?? true,
+          d.gateway_hostname || "",
+          d.gateway_usage_method ?? 1,
+          d.gateway_credentials_source ?? 4,
+          !!d.gateway_bypass_local
         );
       }
       case "DeleteHost": {
@@ -315,9 +327,12 @@ async function saveHost() {
   const redirectClipboard =
     !!document.getElementById("host-clipboard")?.checked;
   const redirectDrives = !!document.getElementById("host-drives")?.checked;
+  // Settings without form fields (gateway etc.) are passed through unchanged
+  const existing = hosts.find((h) => h.id === hostId) || {};
   try {
     if (hostId) {
       await apiCall("UpdateHostFull", {
+        ...existing,
         id: hostId,
         address,
         name: name || address,
@@ -633,13 +648,14 @@ async function saveUser() {
       if (password === "********") {
         password = "__UNCHANGED__";
       }
-      await apiCall("UpdateUser", {
+      const warnings = await apiCall("UpdateUser", {
         id: userId,
         username,
         login,
         domain,
         password,
       });
+      (warnings || []).forEach((warning) => console.warn("User saved, but", warning));
       // Removed: showAlert("User updated", "success");
     } else {
       await apiCall("CreateUser", { username, login, domain, password });
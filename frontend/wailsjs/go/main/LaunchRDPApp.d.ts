@@ -1,26 +1,63 @@
 // Cynhyrchwyd y ffeil hon yn awtomatig. PEIDIWCH Â MODIWL
 // This file is automatically generated. DO NOT EDIT
+import {credentials} from '../models';
 import {models} from '../models';
 import {main} from '../models';
+import {rdp} from '../models';
+import {storage} from '../models';
+
+export function ChangeCredentialsPassphrase(arg1:string,arg2:string):Promise<void>;
 
 export function CreateHost(arg1:string,arg2:string,arg3:string,arg4:number):Promise<void>;
 
-export function CreateHostFull(arg1:string,arg2:string,arg3:string,arg4:number,arg5:string,arg6:number,arg7:number,arg8:number,arg9:number,arg10:boolean,arg11:boolean):Promise<void>;
+export function CreateHostFull(arg1:string,arg2:string,arg3:string,arg4:number,arg5:string,arg6:number,arg7:number,arg8:number,arg9:number,arg10:boolean,arg11:boolean,arg12:string,arg13:boolean,arg14:string,arg15:number,arg16:number,arg17:boolean):Promise<void>;
 
 export function CreateUser(arg1:string,arg2:string,arg3:string,arg4:string):Promise<void>;
 
+export function DeleteExperienceProfile(arg1:string):Promise<void>;
+
+export function DeleteGroup(arg1:string):Promise<void>;
+
 export function DeleteHost(arg1:string):Promise<void>;
 
 export function DeleteUser(arg1:string):Promise<void>;
 
+export function ExportVault(arg1:string,arg2:string,arg3:boolean):Promise<credentials.VaultExport>;
+
 export function GenerateHostRDP(arg1:string):Promise<string>;
 
+export function GetCredentialConflicts():Promise<Array<credentials.Conflict>>;
+
+export function GetExperienceProfiles():Promise<Array<models.ExperienceProfile>>;
+
+export function GetGroups():Promise<Array<models.Group>>;
+
+export function GetHistory(arg1:number):Promise<Array<models.HistoryEntry>>;
+
 export function GetHosts():Promise<Array<models.Host>>;
 
+export function GetLaunchItems():Promise<Array<main.LaunchItem>>;
+
+export function GetLauncherBackends():Promise<Array<string>>;
+
 export function GetMonitorWorkAreas():Promise<Array<main.MonitorWorkArea>>;
 
 export function GetMousePosition():Promise<main.MousePosition>;
 
+export function GetPerformanceProperties():Promise<Array<string>>;
+
+export function GetPortableStatus():Promise<main.PortableStatus>;
+
+export function GetRDPProperties():Promise<Array<rdp.PropertyDef>>;
+
+export function GetSigningCertificate():Promise<rdp.SignerInfo>;
+
+export function GetStorageBackend():Promise<string>;
+
+export function GetStorageError():Promise<string>;
+
+export function GetStorageRecoveries():Promise<Array<storage.Recovery>>;
+
 export function GetUsers():Promise<Array<models.User>>;
 
 export function GetWindowBorderInfo():Promise<main.WindowBorderInfo>;
@@ -31,14 +68,54 @@ export function GetWindowState():Promise<main.WindowState>;
 
 export function GetWorkArea():Promise<main.WorkArea>;
 
+export function ImportRDPFiles(arg1:Array<string>):Promise<main.ImportResult>;
+
+export function ImportVault(arg1:string,arg2:string,arg3:string):Promise<credentials.VaultImport>;
+
 export function LaunchRDP(arg1:string,arg2:string,arg3:number,arg4:number):Promise<boolean>;
 
+export function LaunchRemoteApp(arg1:string,arg2:string,arg3:string):Promise<void>;
+
 export function LogMessage(arg1:string,arg2:string):Promise<void>;
 
 export function PersistWindowState():Promise<void>;
 
+export function PurgeOrphanedCredentials():Promise<credentials.PurgeResult>;
+
+export function RepairCredentials():Promise<credentials.HealthReport>;
+
+export function SaveExperienceProfile(arg1:models.ExperienceProfile):Promise<void>;
+
+export function SaveGroup(arg1:models.Group):Promise<models.Group>;
+
+export function SetHostCredentialAlias(arg1:string,arg2:string):Promise<void>;
+
+export function SetHostCustomProperties(arg1:string,arg2:Record<string, string>):Promise<void>;
+
+export function SetHostExperienceProfile(arg1:string,arg2:string):Promise<void>;
+
+export function SetHostGroup(arg1:string,arg2:string):Promise<void>;
+
+export function SetHostLauncher(arg1:string,arg2:string):Promise<void>;
+
+export function SetHostMonitors(arg1:string,arg2:Array<number>,arg3:boolean):Promise<void>;
+
+export function SetHostRemoteApps(arg1:string,arg2:Array<models.RemoteApp>):Promise<void>;
+
+export function SetHostScaling(arg1:string,arg2:number,arg3:number,arg4:boolean):Promise<void>;
+
+export function SetSigningCertificate(arg1:string,arg2:string,arg3:string):Promise<rdp.SignerInfo>;
+
+export function SetStorageBackend(arg1:string):Promise<void>;
+
+export function SuggestCredentialAliases(arg1:string):Promise<Array<string>>;
+
+export function UnlockCredentials(arg1:string):Promise<void>;
+
 export function UpdateHost(arg1:string,arg2:string,arg3:string,arg4:string,arg5:number):Promise<void>;
 
-export function UpdateHostFull(arg1:string,arg2:string,arg3:string,arg4:string,arg5:number,arg6:string,arg7:number,arg8:number,arg9:number,arg10:number,arg11:boolean,arg12:boolean):Promise<void>;
+export function UpdateHostFull(arg1:string,arg2:string,arg3:string,arg4:string,arg5:number,arg6:string,arg7:number,arg8:number,arg9:number,arg10:number,arg11:boolean,arg12:boolean,arg13:string,arg14:boolean,arg15:string,arg16:number,arg17:number,arg18:boolean):Promise<void>;
+
+export function UpdateUser(arg1:string,arg2:string,arg3:string,arg4:string,arg5:string):Promise<Array<string>>;
 
-export function UpdateUser(arg1:string,arg2:string,arg3:string,arg4:string,arg5:string):Promise<void>;
+export function VerifyCredentials():Promise<credentials.HealthReport>;
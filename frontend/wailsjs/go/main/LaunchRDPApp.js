@@ -2,18 +2,30 @@
 // Cynhyrchwyd y ffeil hon yn awtomatig. PEIDIWCH Â MODIWL
 // This file is automatically generated. DO NOT EDIT
 
+export function ChangeCredentialsPassphrase(arg1, arg2) {
+  return window['go']['main']['LaunchRDPApp']['ChangeCredentialsPassphrase'](arg1, arg2);
+}
+
 export function CreateHost(arg1, arg2, arg3, arg4) {
   return window['go']['main']['LaunchRDPApp']['CreateHost'](arg1, arg2, arg3, arg4);
 }
 
-export function CreateHostFull(arg1, arg2, arg3, arg4, arg5, arg6, arg7, arg8, arg9, arg10, arg11) {
-  return window['go']['main']['LaunchRDPApp']['CreateHostFull'](arg1, arg2, arg3, arg4, arg5, arg6, arg7, arg8, arg9, arg10, arg11);
+export function CreateHostFull(arg1, arg2, arg3, arg4, arg5, arg6, arg7, arg8, arg9, arg10, arg11, arg12, arg13, arg14, arg15, arg16, arg17) {
+  return window['go']['main']['LaunchRDPApp']['CreateHostFull'](arg1, arg2, arg3, arg4, arg5, arg6, arg7, arg8, arg9, arg10, arg11, arg12, arg13, arg14, arg15, arg16, arg17);
 }
 
 export function CreateUser(arg1, arg2, arg3, arg4) {
   return window['go']['main']['LaunchRDPApp']['CreateUser'](arg1, arg2, arg3, arg4);
 }
 
+export function DeleteExperienceProfile(arg1) {
+  return window['go']['main']['LaunchRDPApp']['DeleteExperienceProfile'](arg1);
+}
+
+export function DeleteGroup(arg1) {
+  return window['go']['main']['LaunchRDPApp']['DeleteGroup'](arg1);
+}
+
 export function DeleteHost(arg1) {
   return window['go']['main']['LaunchRDPApp']['DeleteHost'](arg1);
 }
@@ -22,14 +34,42 @@ export function DeleteUser(arg1) {
   return window['go']['main']['LaunchRDPApp']['DeleteUser'](arg1);
 }
 
+export function ExportVault(arg1, arg2, arg3) {
+  return window['go']['main']['LaunchRDPApp']['ExportVault'](arg1, arg2, arg3);
+}
+
 export function GenerateHostRDP(arg1) {
   return window['go']['main']['LaunchRDPApp']['GenerateHostRDP'](arg1);
 }
 
+export function GetCredentialConflicts() {
+  return window['go']['main']['LaunchRDPApp']['GetCredentialConflicts']();
+}
+
+export function GetExperienceProfiles() {
+  return window['go']['main']['LaunchRDPApp']['GetExperienceProfiles']();
+}
+
+export function GetGroups() {
+  return window['go']['main']['LaunchRDPApp']['GetGroups']();
+}
+
+export function GetHistory(arg1) {
+  return window['go']['main']['LaunchRDPApp']['GetHistory'](arg1);
+}
+
 export function GetHosts() {
   return window['go']['main']['LaunchRDPApp']['GetHosts']();
 }
 
+export function GetLaunchItems() {
+  return window['go']['main']['LaunchRDPApp']['GetLaunchItems']();
+}
+
+export function GetLauncherBackends() {
+  return window['go']['main']['LaunchRDPApp']['GetLauncherBackends']();
+}
+
 export function GetMonitorWorkAreas() {
   return window['go']['main']['LaunchRDPApp']['GetMonitorWorkAreas']();
 }
@@ -38,6 +78,34 @@ export function GetMousePosition() {
   return window['go']['main']['LaunchRDPApp']['GetMousePosition']();
 }
 
+export function GetPerformanceProperties() {
+  return window['go']['main']['LaunchRDPApp']['GetPerformanceProperties']();
+}
+
+export function GetPortableStatus() {
+  return window['go']['main']['LaunchRDPApp']['GetPortableStatus']();
+}
+
+export function GetRDPProperties() {
+  return window['go']['main']['LaunchRDPApp']['GetRDPProperties']();
+}
+
+export function GetSigningCertificate() {
+  return window['go']['main']['LaunchRDPApp']['GetSigningCertificate']();
+}
+
+export function GetStorageBackend() {
+  return window['go']['main']['LaunchRDPApp']['GetStorageBackend']();
+}
+
+export function GetStorageError() {
+  return window['go']['main']['LaunchRDPApp']['GetStorageError']();
+}
+
+export function GetStorageRecoveries() {
+  return window['go']['main']['LaunchRDPApp']['GetStorageRecoveries']();
+}
+
 export function GetUsers() {
   return window['go']['main']['LaunchRDPApp']['GetUsers']();
 }
@@ -58,10 +126,22 @@ export function GetWorkArea() {
   return window['go']['main']['LaunchRDPApp']['GetWorkArea']();
 }
 
+export function ImportRDPFiles(arg1) {
+  return window['go']['main']['LaunchRDPApp']['ImportRDPFiles'](arg1);
+}
+
+export function ImportVault(arg1, arg2, arg3) {
+  return window['go']['main']['LaunchRDPApp']['ImportVault'](arg1, arg2, arg3);
+}
+
 export function LaunchRDP(arg1, arg2, arg3, arg4) {
   return window['go']['main']['LaunchRDPApp']['LaunchRDP'](arg1, arg2, arg3, arg4);
 }
 
+export function LaunchRemoteApp(arg1, arg2, arg3) {
+  return window['go']['main']['LaunchRDPApp']['LaunchRemoteApp'](arg1, arg2, arg3);
+}
+
 export function LogMessage(arg1, arg2) {
   return window['go']['main']['LaunchRDPApp']['LogMessage'](arg1, arg2);
 }
@@ -70,14 +150,82 @@ export function PersistWindowState() {
   return window['go']['main']['LaunchRDPApp']['PersistWindowState']();
 }
 
+export function PurgeOrphanedCredentials() {
+  return window['go']['main']['LaunchRDPApp']['PurgeOrphanedCredentials']();
+}
+
+export function RepairCredentials() {
+  return window['go']['main']['LaunchRDPApp']['RepairCredentials']();
+}
+
+export function SaveExperienceProfile(arg1) {
+  return window['go']['main']['LaunchRDPApp']['SaveExperienceProfile'](arg1);
+}
+
+export function SaveGroup(arg1) {
+  return window['go']['main']['LaunchRDPApp']['SaveGroup'](arg1);
+}
+
+export function SetHostCredentialAlias(arg1, arg2) {
+  return window['go']['main']['LaunchRDPApp']['SetHostCredentialAlias'](arg1, arg2);
+}
+
+export function SetHostCustomProperties(arg1, arg2) {
+  return window['go']['main']['LaunchRDPApp']['SetHostCustomProperties'](arg1, arg2);
+}
+
+export function SetHostExperienceProfile(arg1, arg2) {
+  return window['go']['main']['LaunchRDPApp']['SetHostExperienceProfile'](arg1, arg2);
+}
+
+export function SetHostGroup(arg1, arg2) {
+  return window['go']['main']['LaunchRDPApp']['SetHostGroup'](arg1, arg2);
+}
+
+export function SetHostLauncher(arg1, arg2) {
+  return window['go']['main']['LaunchRDPApp']['SetHostLauncher'](arg1, arg2);
+}
+
+export function SetHostMonitors(arg1, arg2, arg3) {
+  return window['go']['main']['LaunchRDPApp']['SetHostMonitors'](arg1, arg2, arg3);
+}
+
+export function SetHostRemoteApps(arg1, arg2) {
+  return window['go']['main']['LaunchRDPApp']['SetHostRemoteApps'](arg1, arg2);
+}
+
+export function SetHostScaling(arg1, arg2, arg3, arg4) {
+  return window['go']['main']['LaunchRDPApp']['SetHostScaling'](arg1, arg2, arg3, arg4);
+}
+
+export function SetSigningCertificate(arg1, arg2, arg3) {
+  return window['go']['main']['LaunchRDPApp']['SetSigningCertificate'](arg1, arg2, arg3);
+}
+
+export function SetStorageBackend(arg1) {
+  return window['go']['main']['LaunchRDPApp']['SetStorageBackend'](arg1);
+}
+
+export function SuggestCredentialAliases(arg1) {
+  return window['go']['main']['LaunchRDPApp']['SuggestCredentialAliases'](arg1);
+}
+
+export function UnlockCredentials(arg1) {
+  return window['go']['main']['LaunchRDPApp']['UnlockCredentials'](arg1);
+}
+
 export function UpdateHost(arg1, arg2, arg3, arg4, arg5) {
   return window['go']['main']['LaunchRDPApp']['UpdateHost'](arg1, arg2, arg3, arg4, arg5);
 }
 
-export function UpdateHostFull(arg1, arg2, arg3, arg4, arg5, arg6, arg7, arg8, arg9, arg10, arg11, arg12) {
-  return window['go']['main']['LaunchRDPApp']['UpdateHostFull'](arg1, arg2, arg3, arg4, arg5, arg6, arg7, arg8, arg9, arg10, arg11, arg12);
+export function UpdateHostFull(arg1, arg2, arg3, arg4, arg5, arg6, arg7, arg8, arg9, arg10, arg11, arg12, arg13, arg14, arg15, arg16, arg17, arg18) {
+  return window['go']['main']['LaunchRDPApp']['UpdateHostFull'](arg1, arg2, arg3, arg4, arg5, arg6, arg7, arg8, arg9, arg10, arg11, arg12, arg13, arg14, arg15, arg16, arg17, arg18);
 }
 
 export function UpdateUser(arg1, arg2, arg3, arg4, arg5) {
   return window['go']['main']['LaunchRDPApp']['UpdateUser'](arg1, arg2, arg3, arg4, arg5);
 }
+
+export function VerifyCredentials() {
+  return window['go']['main']['LaunchRDPApp']['VerifyCredentials']();
+}
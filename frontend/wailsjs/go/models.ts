@@ -1,5 +1,272 @@
+export namespace credentials {
+	
+	export class Conflict {
+	    target: string;
+	    hostIds: string[];
+	    hostNames: string[];
+	    usernames: string[];
+	
+	    static createFrom(source: any = {}) {
+	        return new Conflict(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.target = source["target"];
+	        this.hostIds = source["hostIds"];
+	        this.hostNames = source["hostNames"];
+	        this.usernames = source["usernames"];
+	    }
+	}
+	export class Credential {
+	    target: string;
+	    username: string;
+	    // Go type: time
+	    written: any;
+	    managed: boolean;
+	
+	    static createFrom(source: any = {}) {
+	        return new Credential(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.target = source["target"];
+	        this.username = source["username"];
+	        this.written = this.convertValues(source["written"], null);
+	        this.managed = source["managed"];
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class HostCredential {
+	    hostId: string;
+	    hostName: string;
+	    target: string;
+	    userId: string;
+	    expected: string;
+	    stored: string;
+	    // Go type: time
+	    written: any;
+	    status: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new HostCredential(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.hostId = source["hostId"];
+	        this.hostName = source["hostName"];
+	        this.target = source["target"];
+	        this.userId = source["userId"];
+	        this.expected = source["expected"];
+	        this.stored = source["stored"];
+	        this.written = this.convertValues(source["written"], null);
+	        this.status = source["status"];
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class HealthReport {
+	    store: string;
+	    hosts: HostCredential[];
+	    orphaned: Credential[];
+	    issues: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new HealthReport(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.store = source["store"];
+	        this.hosts = this.convertValues(source["hosts"], HostCredential);
+	        this.orphaned = this.convertValues(source["orphaned"], Credential);
+	        this.issues = source["issues"];
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class PurgeResult {
+	    deleted: string[];
+	    failed: string[];
+	
+	    static createFrom(source: any = {}) {
+	        return new PurgeResult(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.deleted = source["deleted"];
+	        this.failed = source["failed"];
+	    }
+	}
+	export class VaultConflict {
+	    kind: string;
+	    name: string;
+	    resolution: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new VaultConflict(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.kind = source["kind"];
+	        this.name = source["name"];
+	        this.resolution = source["resolution"];
+	    }
+	}
+	export class VaultExport {
+	    users: number;
+	    hosts: number;
+	    usersWithoutPassword: string[];
+	
+	    static createFrom(source: any = {}) {
+	        return new VaultExport(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.users = source["users"];
+	        this.hosts = source["hosts"];
+	        this.usersWithoutPassword = source["usersWithoutPassword"];
+	    }
+	}
+	export class VaultImport {
+	    usersAdded: number;
+	    usersUpdated: number;
+	    hostsAdded: number;
+	    hostsUpdated: number;
+	    unchanged: number;
+	    conflicts: VaultConflict[];
+	    hostsWithoutUser: string[];
+	    changedHostIds: string[];
+	
+	    static createFrom(source: any = {}) {
+	        return new VaultImport(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.usersAdded = source["usersAdded"];
+	        this.usersUpdated = source["usersUpdated"];
+	        this.hostsAdded = source["hostsAdded"];
+	        this.hostsUpdated = source["hostsUpdated"];
+	        this.unchanged = source["unchanged"];
+	        this.conflicts = this.convertValues(source["conflicts"], VaultConflict);
+	        this.hostsWithoutUser = source["hostsWithoutUser"];
+	        this.changedHostIds = source["changedHostIds"];
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+
+}
+
 export namespace main {
 	
+	export class ImportResult {
+	    imported: number;
+	    usersCreated: number;
+	    errors: string[];
+	
+	    static createFrom(source: any = {}) {
+	        return new ImportResult(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.imported = source["imported"];
+	        this.usersCreated = source["usersCreated"];
+	        this.errors = source["errors"];
+	    }
+	}
+	export class LaunchItem {
+	    hostId: string;
+	    appId: string;
+	    name: string;
+	    address: string;
+	    userId: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new LaunchItem(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.hostId = source["hostId"];
+	        this.appId = source["appId"];
+	        this.name = source["name"];
+	        this.address = source["address"];
+	        this.userId = source["userId"];
+	    }
+	}
 	export class MonitorWorkArea {
 	    index: number;
 	    monitorLeft: number;
@@ -44,6 +311,22 @@ export namespace main {
 	        this.y = source["y"];
 	    }
 	}
+	export class PortableStatus {
+	    portable: boolean;
+	    initialized: boolean;
+	    locked: boolean;
+	
+	    static createFrom(source: any = {}) {
+	        return new PortableStatus(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.portable = source["portable"];
+	        this.initialized = source["initialized"];
+	        this.locked = source["locked"];
+	    }
+	}
 	export class WindowBorderInfo {
 	    left: number;
 	    right: number;
@@ -119,18 +402,163 @@ export namespace main {
 
 export namespace models {
 	
+	export class ExperienceProfile {
+	    id: string;
+	    name: string;
+	    properties: Record<string, string>;
+	    // Go type: time
+	    modified_at: any;
+	
+	    static createFrom(source: any = {}) {
+	        return new ExperienceProfile(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.id = source["id"];
+	        this.name = source["name"];
+	        this.properties = source["properties"];
+	        this.modified_at = this.convertValues(source["modified_at"], null);
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class Group {
+	    id: string;
+	    name: string;
+	    // Go type: time
+	    created_at: any;
+	    // Go type: time
+	    modified_at: any;
+	
+	    static createFrom(source: any = {}) {
+	        return new Group(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.id = source["id"];
+	        this.name = source["name"];
+	        this.created_at = this.convertValues(source["created_at"], null);
+	        this.modified_at = this.convertValues(source["modified_at"], null);
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class HistoryEntry {
+	    id: string;
+	    host_id: string;
+	    host_name: string;
+	    app_id?: string;
+	    user_id: string;
+	    backend: string;
+	    // Go type: time
+	    launched_at: any;
+	
+	    static createFrom(source: any = {}) {
+	        return new HistoryEntry(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.id = source["id"];
+	        this.host_id = source["host_id"];
+	        this.host_name = source["host_name"];
+	        this.app_id = source["app_id"];
+	        this.user_id = source["user_id"];
+	        this.backend = source["backend"];
+	        this.launched_at = this.convertValues(source["launched_at"], null);
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class RemoteApp {
+	    id: string;
+	    name: string;
+	    program: string;
+	    command_line: string;
+	    working_directory: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new RemoteApp(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.id = source["id"];
+	        this.name = source["name"];
+	        this.program = source["program"];
+	        this.command_line = source["command_line"];
+	        this.working_directory = source["working_directory"];
+	    }
+	}
 	export class Host {
 	    id: string;
 	    name: string;
 	    address: string;
 	    port: number;
 	    user_id: string;
+	    group_id?: string;
+	    credential_alias?: string;
 	    redirect_clipboard: boolean;
 	    redirect_drives: boolean;
 	    drives_to_redirect: string;
 	    display_mode: string;
 	    dynamic_resolution: boolean;
 	    screen_mode: number;
+	    selected_monitors?: number[];
+	    span_current_monitor: boolean;
+	    desktop_scale_factor: number;
+	    device_scale_factor: number;
+	    smart_sizing: boolean;
 	    window_width: number;
 	    window_height: number;
 	    desktop_width: number;
@@ -138,6 +566,15 @@ export namespace models {
 	    position_x: number;
 	    position_y: number;
 	    win_pos_str: string;
+	    gateway_hostname: string;
+	    gateway_usage_method: number;
+	    gateway_credentials_source: number;
+	    gateway_bypass_local: boolean;
+	    launcher?: string;
+	    experience_profile?: string;
+	    remote_apps?: RemoteApp[];
+	    custom_properties?: Record<string, string>;
+	    imported_properties?: Record<string, string>;
 	    // Go type: time
 	    created_at: any;
 	    // Go type: time
@@ -154,12 +591,19 @@ export namespace models {
 	        this.address = source["address"];
 	        this.port = source["port"];
 	        this.user_id = source["user_id"];
+	        this.group_id = source["group_id"];
+	        this.credential_alias = source["credential_alias"];
 	        this.redirect_clipboard = source["redirect_clipboard"];
 	        this.redirect_drives = source["redirect_drives"];
 	        this.drives_to_redirect = source["drives_to_redirect"];
 	        this.display_mode = source["display_mode"];
 	        this.dynamic_resolution = source["dynamic_resolution"];
 	        this.screen_mode = source["screen_mode"];
+	        this.selected_monitors = source["selected_monitors"];
+	        this.span_current_monitor = source["span_current_monitor"];
+	        this.desktop_scale_factor = source["desktop_scale_factor"];
+	        this.device_scale_factor = source["device_scale_factor"];
+	        this.smart_sizing = source["smart_sizing"];
 	        this.window_width = source["window_width"];
 	        this.window_height = source["window_height"];
 	        this.desktop_width = source["desktop_width"];
@@ -167,6 +611,15 @@ export namespace models {
 	        this.position_x = source["position_x"];
 	        this.position_y = source["position_y"];
 	        this.win_pos_str = source["win_pos_str"];
+	        this.gateway_hostname = source["gateway_hostname"];
+	        this.gateway_usage_method = source["gateway_usage_method"];
+	        this.gateway_credentials_source = source["gateway_credentials_source"];
+	        this.gateway_bypass_local = source["gateway_bypass_local"];
+	        this.launcher = source["launcher"];
+	        this.experience_profile = source["experience_profile"];
+	        this.remote_apps = this.convertValues(source["remote_apps"], RemoteApp);
+	        this.custom_properties = source["custom_properties"];
+	        this.imported_properties = source["imported_properties"];
 	        this.created_at = this.convertValues(source["created_at"], null);
 	        this.modified_at = this.convertValues(source["modified_at"], null);
 	    }
@@ -200,6 +653,8 @@ export namespace models {
 	    created_at: any;
 	    // Go type: time
 	    modified_at: any;
+	    // Go type: time
+	    password_changed_at: any;
 	
 	    static createFrom(source: any = {}) {
 	        return new User(source);
@@ -215,6 +670,73 @@ export namespace models {
 	        this.encrypted_password = source["encrypted_password"];
 	        this.created_at = this.convertValues(source["created_at"], null);
 	        this.modified_at = this.convertValues(source["modified_at"], null);
+	        this.password_changed_at = this.convertValues(source["password_changed_at"], null);
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+
+}
+
+export namespace rdp {
+	
+	export class PropertyDef {
+	    name: string;
+	    type: string;
+	    default: string;
+	    allowed?: string[];
+	    group: string;
+	    description: string;
+	    omitEmpty?: boolean;
+	
+	    static createFrom(source: any = {}) {
+	        return new PropertyDef(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.name = source["name"];
+	        this.type = source["type"];
+	        this.default = source["default"];
+	        this.allowed = source["allowed"];
+	        this.group = source["group"];
+	        this.description = source["description"];
+	        this.omitEmpty = source["omitEmpty"];
+	    }
+	}
+	export class SignerInfo {
+	    subject: string;
+	    issuer: string;
+	    thumbprint: string;
+	    // Go type: time
+	    notAfter: any;
+	
+	    static createFrom(source: any = {}) {
+	        return new SignerInfo(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.subject = source["subject"];
+	        this.issuer = source["issuer"];
+	        this.thumbprint = source["thumbprint"];
+	        this.notAfter = this.convertValues(source["notAfter"], null);
 	    }
 	
 		convertValues(a: any, classs: any, asMap: boolean = false): any {
@@ -238,3 +760,21 @@ export namespace models {
 
 }
 
+export namespace storage {
+	
+	export class Recovery {
+	    file: string;
+	    error: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new Recovery(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.file = source["file"];
+	        this.error = source["error"];
+	    }
+	}
+
+}
@@ -25,15 +25,22 @@ func main() {
 	// Set up panic handling and logging - SAME AS BEFORE!
 	defer logging.PanicHandler()
 
+	// Command line flags - parsed before logging starts so the log follows --portable
+	var version = flag.Bool("version", false, "Show version information")
+	var v = flag.Bool("v", false, "Show version information")
+	var portable = flag.Bool("portable", false, "Keep config, logs and temp files next to the executable (same as a "+config.PortableFlagFileName+" file)")
+	flag.Parse()
+	if *portable {
+		config.EnablePortable()
+	}
+
 	// Initialize logging - SAME AS BEFORE!
 	logging.Log(debug, "===", AppName, "Starting (Wails Version) ===")
 	logging.Log(debug, "Version:", Version)
 	logging.Log(debug, "Build Date: 2025-11-07")
-
-	// Command line flags - SAME AS BEFORE!
-	var version = flag.Bool("version", false, "Show version information")
-	var v = flag.Bool("v", false, "Show version information")
-	flag.Parse()
+	if config.IsPortable() {
+		logging.Log(debug, "Portable mode: files are stored next to the executable")
+	}
 
 	// Check for version flag - SAME AS BEFORE!
 	if *version || *v {
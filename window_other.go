@@ -0,0 +1,113 @@
+//go:build !windows
+
+package main
+
+import (
+	"fmt"
+
+	"github.com/wailsapp/wails/v2/pkg/runtime"
+
+	"github.com/chrilep/LaunchRDP/app/logging"
+)
+
+// Fallbacks of the native window functions for platforms without the Win32 API.
+// Window geometry is tracked by the geometry ticker only.
+
+// GetMousePosition - Replaces GET /api/mouse-position
+// The cursor position is not available without the Win32 API, the frontend then
+// places new windows at the origin of the work area.
+func (a *LaunchRDPApp) GetMousePosition() (*MousePosition, error) {
+	debug := false
+	logging.Log(debug, "API: Getting mouse position (not supported on this platform)")
+	return &MousePosition{X: 0, Y: 0}, nil
+}
+
+// GetWindowBorderInfo returns zero frame metrics; the window manager draws the
+// decorations and FreeRDP sizes the session by its client area.
+func (a *LaunchRDPApp) GetWindowBorderInfo() (*WindowBorderInfo, error) {
+	return &WindowBorderInfo{}, nil
+}
+
+// initHWND is a no-op, there is no window handle to look up
+func (a *LaunchRDPApp) initHWND() {}
+
+// startMoveSizeHook is not supported; the geometry ticker keeps the state instead
+func (a *LaunchRDPApp) startMoveSizeHook() error {
+	return fmt.Errorf("startMoveSizeHook: not supported on this platform")
+}
+
+// stopMoveSizeHook is a no-op, no hook is ever set
+func (a *LaunchRDPApp) stopMoveSizeHook() {}
+
+// GetWorkArea returns the primary screen size as reported by the runtime.
+// Panels and docks are not subtracted.
+func (a *LaunchRDPApp) GetWorkArea() (*WorkArea, error) {
+	monitors, err := a.GetMonitorWorkAreas()
+	if err != nil {
+		return nil, err
+	}
+	for _, m := range monitors {
+		if m.Primary {
+			return &WorkArea{
+				Left:   m.WorkLeft,
+				Top:    m.WorkTop,
+				Right:  m.WorkRight,
+				Bottom: m.WorkBottom,
+				Width:  m.WorkRight - m.WorkLeft,
+				Height: m.WorkBottom - m.WorkTop,
+			}, nil
+		}
+	}
+	return nil, fmt.Errorf("no primary screen found")
+}
+
+// GetMonitorWorkAreas lists the screens reported by the runtime. The runtime does
+// not report screen positions, so screens are laid out left to right with the
+// primary screen first, and the work area equals the full screen.
+func (a *LaunchRDPApp) GetMonitorWorkAreas() ([]MonitorWorkArea, error) {
+	debug := true
+	logging.Log(debug, "API: GetMonitorWorkAreas start")
+	if a.ctx == nil {
+		return nil, fmt.Errorf("GetMonitorWorkAreas: runtime not ready")
+	}
+
+	screens, err := runtime.ScreenGetAll(a.ctx)
+	if err != nil {
+		logging.Log(true, "API: GetMonitorWorkAreas failed", err)
+		return nil, err
+	}
+
+	// Primary screen first so it starts at the origin
+	ordered := make([]runtime.Screen, 0, len(screens))
+	for _, screen := range screens {
+		if screen.IsPrimary {
+			ordered = append(ordered, screen)
+		}
+	}
+	for _, screen := range screens {
+		if !screen.IsPrimary {
+			ordered = append(ordered, screen)
+		}
+	}
+
+	monitors := make([]MonitorWorkArea, 0, len(ordered))
+	left := 0
+	for index, screen := range ordered {
+		width, height := screen.PhysicalSize.Width, screen.PhysicalSize.Height
+		monitors = append(monitors, MonitorWorkArea{
+			Index:         index,
+			MonitorLeft:   left,
+			MonitorTop:    0,
+			MonitorRight:  left + width,
+			MonitorBottom: height,
+			WorkLeft:      left,
+			WorkTop:       0,
+			WorkRight:     left + width,
+			WorkBottom:    height,
+			Primary:       screen.IsPrimary,
+		})
+		left += width
+	}
+	logging.Log(debug, fmt.Sprintf("API: GetMonitorWorkAreas success monitors=%d", len(monitors)))
+	return monitors, nil
+}
@@ -0,0 +1,251 @@
+//go:build windows
+
+package main
+
+import (
+	"fmt"
+	"syscall"
+	"unsafe"
+
+	"github.com/chrilep/LaunchRDP/app/logging"
+)
+
+// Native window and monitor functions of the Win32 API. The fallbacks for other
+// platforms are in window_other.go.
+
+// GetMousePosition - Replaces GET /api/mouse-position
+func (a *LaunchRDPApp) GetMousePosition() (*MousePosition, error) {
+	debug := false
+	logging.Log(debug, "API: Getting mouse position")
+
+	user32 := syscall.NewLazyDLL("user32.dll")
+	procGetCursorPos := user32.NewProc("GetCursorPos")
+	type point struct {
+		X int32
+		Y int32
+	}
+	var pt point
+	r, _, err := procGetCursorPos.Call(uintptr(unsafe.Pointer(&pt)))
+	if r == 0 { // failed
+		logging.Log(true, "ERROR: GetCursorPos failed", err)
+		return &MousePosition{X: 0, Y: 0}, nil
+	}
+	position := &MousePosition{X: int(pt.X), Y: int(pt.Y)}
+	logging.Log(debug, "Mouse position:", position.X, position.Y)
+	return position, nil
+}
+
+// GetWindowBorderInfo returns the real window frame metrics from the OS so the
+// frontend can calculate the usable client size and WinPosStr accurately.
+// This uses GetSystemMetrics for size frame, padded border and caption height.
+// Reference metrics:
+//
+//	SM_CXSIZEFRAME / SM_CYSIZEFRAME: Thickness of the sizing border
+//	SM_CXPADDEDBORDER: Extra border padding introduced with Aero
+//	SM_CYCAPTION: Caption (title bar) height
+//
+// We return a simplified model: left/right/top/bottom total non-client offsets.
+func (a *LaunchRDPApp) GetWindowBorderInfo() (*WindowBorderInfo, error) {
+	user32 := syscall.NewLazyDLL("user32.dll")
+	procGetSystemMetrics := user32.NewProc("GetSystemMetrics")
+
+	// Helper to read metric
+	getMetric := func(index int) int {
+		r, _, _ := procGetSystemMetrics.Call(uintptr(index))
+		return int(r)
+	}
+
+	const (
+		SM_CXSIZEFRAME    = 32
+		SM_CYSIZEFRAME    = 33
+		SM_CXPADDEDBORDER = 92
+		SM_CYCAPTION      = 4
+	)
+
+	sizeFrameX := getMetric(SM_CXSIZEFRAME)
+	sizeFrameY := getMetric(SM_CYSIZEFRAME)
+	padded := getMetric(SM_CXPADDEDBORDER)
+	caption := getMetric(SM_CYCAPTION)
+
+	// Non-client borders left/right include size frame + padded border
+	left := sizeFrameX + padded
+	right := sizeFrameX + padded
+	top := sizeFrameY + padded + caption
+	bottom := sizeFrameY + padded
+
+	// We do not know the runtime window size here; fill with zeroes and let frontend compute client size from user input.
+	info := &WindowBorderInfo{
+		Left:         left,
+		Right:        right,
+		Top:          top,
+		Bottom:       bottom,
+		ClientWidth:  0,
+		ClientHeight: 0,
+		WindowWidth:  0,
+		WindowHeight: 0,
+	}
+	return info, nil
+}
+
+// initHWND finds the window handle by title (class optional) after window is shown
+func (a *LaunchRDPApp) initHWND() {
+	if a.windowHWND != 0 {
+		return
+	}
+	user32 := syscall.NewLazyDLL("user32.dll")
+	procFindWindow := user32.NewProc("FindWindowW")
+	titlePtr, _ := syscall.UTF16PtrFromString("LaunchRDP")
+	hwnd, _, _ := procFindWindow.Call(0, uintptr(unsafe.Pointer(titlePtr)))
+	a.windowHWND = hwnd
+	logging.Log(true, fmt.Sprintf("initHWND: hwnd=%x", hwnd))
+}
+
+// startMoveSizeHook sets a WinEvent hook for MOVESIZEEND events to capture final geometry after user interaction
+func (a *LaunchRDPApp) startMoveSizeHook() error {
+	user32 := syscall.NewLazyDLL("user32.dll")
+	procSetWinEventHook := user32.NewProc("SetWinEventHook")
+	procUnhookWinEvent := user32.NewProc("UnhookWinEvent")
+	procGetWindowRect := user32.NewProc("GetWindowRect")
+	procGetWindowThreadProcessId := user32.NewProc("GetWindowThreadProcessId")
+
+	const EVENT_SYSTEM_MOVESIZEEND = 0x000B
+	const WINEVENT_INCONTEXT = 0x0004
+
+	if a.windowHWND == 0 {
+		a.initHWND()
+	}
+	if a.windowHWND == 0 {
+		return fmt.Errorf("startMoveSizeHook: hwnd not found")
+	}
+
+	// Get the thread ID of the window to hook events for that thread only
+	var processID uint32
+	threadID, _, _ := procGetWindowThreadProcessId.Call(a.windowHWND, uintptr(unsafe.Pointer(&processID)))
+	logging.Log(true, fmt.Sprintf("startMoveSizeHook: hwnd=%x threadId=%d processId=%d", a.windowHWND, threadID, processID))
+
+	// Load the current module handle for INCONTEXT hook
+	kernel32 := syscall.NewLazyDLL("kernel32.dll")
+	procGetModuleHandle := kernel32.NewProc("GetModuleHandleW")
+	hModule, _, _ := procGetModuleHandle.Call(0)
+
+	// Callback - INCONTEXT runs in the UI thread's message loop (compatible with Wails)
+	a.winEventCallback = syscall.NewCallback(func(hWinEventHook, event, hwnd, idObject, idChild, dwEventThread, dwmsEventTime uintptr) uintptr {
+		if event == EVENT_SYSTEM_MOVESIZEEND && hwnd == a.windowHWND {
+			type rect struct{ Left, Top, Right, Bottom int32 }
+			var r rect
+			rOk, _, _ := procGetWindowRect.Call(hwnd, uintptr(unsafe.Pointer(&r)))
+			if rOk != 0 {
+				x := int(r.Left)
+				y := int(r.Top)
+				w := int(r.Right - r.Left)
+				h := int(r.Bottom - r.Top)
+				a.winStateMu.Lock()
+				a.winState.X = x
+				a.winState.Y = y
+				a.winState.Width = w
+				a.winState.Height = h
+				_ = saveWindowState(a.winState)
+				a.winStateMu.Unlock()
+				logging.Log(true, fmt.Sprintf("Hook MOVESIZEEND: saved X=%d Y=%d W=%d H=%d", x, y, w, h))
+			}
+		}
+		return 0
+	})
+
+	hHook, _, err := procSetWinEventHook.Call(
+		uintptr(EVENT_SYSTEM_MOVESIZEEND), // eventMin
+		uintptr(EVENT_SYSTEM_MOVESIZEEND), // eventMax
+		hModule,                           // hmod - our module for INCONTEXT
+		a.winEventCallback,                // callback
+		uintptr(processID),                // idProcess - our process only
+		threadID,                          // idThread - our UI thread only
+		uintptr(WINEVENT_INCONTEXT),       // flags - INCONTEXT for message loop integration
+	)
+	if hHook == 0 {
+		return fmt.Errorf("SetWinEventHook failed: %v", err)
+	}
+	a.winEventHook = hHook
+	_ = procUnhookWinEvent // silence unused (we unhook in Shutdown)
+	logging.Log(true, fmt.Sprintf("startMoveSizeHook: hook=%x", hHook))
+	return nil
+}
+
+// stopMoveSizeHook removes the WinEvent hook set by startMoveSizeHook
+func (a *LaunchRDPApp) stopMoveSizeHook() {
+	if a.winEventHook == 0 {
+		return
+	}
+	user32 := syscall.NewLazyDLL("user32.dll")
+	procUnhookWinEvent := user32.NewProc("UnhookWinEvent")
+	_, _, _ = procUnhookWinEvent.Call(a.winEventHook)
+	logging.Log(true, fmt.Sprintf("Shutdown: unhooked winEventHook=%x", a.winEventHook))
+}
+
+// GetWorkArea retrieves primary monitor work area (excluding taskbar)
+func (a *LaunchRDPApp) GetWorkArea() (*WorkArea, error) {
+	user32 := syscall.NewLazyDLL("user32.dll")
+	procSPI := user32.NewProc("SystemParametersInfoW")
+	const SPI_GETWORKAREA = 0x0030
+	type rect struct{ Left, Top, Right, Bottom int32 }
+	var r rect
+	ret, _, err := procSPI.Call(uintptr(SPI_GETWORKAREA), 0, uintptr(unsafe.Pointer(&r)), 0)
+	if ret == 0 {
+		return nil, err
+	}
+	wa := &WorkArea{Left: int(r.Left), Top: int(r.Top), Right: int(r.Right), Bottom: int(r.Bottom)}
+	wa.Width = wa.Right - wa.Left
+	wa.Height = wa.Bottom - wa.Top
+	return wa, nil
+}
+
+// GetMonitorWorkAreas enumeriert alle Monitore (EnumDisplayMonitors) und liest deren rcMonitor & rcWork
+func (a *LaunchRDPApp) GetMonitorWorkAreas() ([]MonitorWorkArea, error) {
+	user32 := syscall.NewLazyDLL("user32.dll")
+	procEnum := user32.NewProc("EnumDisplayMonitors")
+	procGetInfo := user32.NewProc("GetMonitorInfoW")
+	debug := true
+	logging.Log(debug, "API: GetMonitorWorkAreas start")
+
+	type rect struct{ Left, Top, Right, Bottom int32 }
+	type monitorInfoEx struct {
+		cbSize    uint32
+		rcMonitor rect
+		rcWork    rect
+		dwFlags   uint32
+		szDevice  [32]uint16
+	}
+
+	monitors := make([]MonitorWorkArea, 0, 8)
+	index := 0
+
+	enumCallback := syscall.NewCallback(func(hMonitor, hdc, lprcMonitor, dwData uintptr) uintptr {
+		var mi monitorInfoEx
+		mi.cbSize = uint32(unsafe.Sizeof(mi))
+		r, _, _ := procGetInfo.Call(hMonitor, uintptr(unsafe.Pointer(&mi)))
+		if r != 0 {
+			m := MonitorWorkArea{
+				Index:         index,
+				MonitorLeft:   int(mi.rcMonitor.Left),
+				MonitorTop:    int(mi.rcMonitor.Top),
+				MonitorRight:  int(mi.rcMonitor.Right),
+				MonitorBottom: int(mi.rcMonitor.Bottom),
+				WorkLeft:      int(mi.rcWork.Left),
+				WorkTop:       int(mi.rcWork.Top),
+				WorkRight:     int(mi.rcWork.Right),
+				WorkBottom:    int(mi.rcWork.Bottom),
+				Primary:       (mi.dwFlags & 1) == 1, // MONITORINFOF_PRIMARY
+			}
+			monitors = append(monitors, m)
+			index++
+		}
+		return 1 // continue enumeration
+	})
+
+	r, _, err := procEnum.Call(0, 0, enumCallback, 0)
+	if r == 0 {
+		logging.Log(true, "API: GetMonitorWorkAreas failed", err)
+		return nil, err
+	}
+	logging.Log(debug, fmt.Sprintf("API: GetMonitorWorkAreas success monitors=%d", len(monitors)))
+	return monitors, nil
+}